@@ -2,19 +2,63 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
 
 	"github.com/w-h-a/agent/generator"
 	"github.com/w-h-a/agent/internal/service/agent"
 	"github.com/w-h-a/agent/internal/service/session"
 	"github.com/w-h-a/agent/internal/service/space"
 	memorymanager "github.com/w-h-a/agent/memory_manager"
+	"github.com/w-h-a/agent/router"
 	toolhandler "github.com/w-h-a/agent/tool_handler"
+	jsonschema "github.com/w-h-a/agent/util/json_schema"
 )
 
+type Usage = agent.Usage
+
+// AgentOption configures how an agent works, such as its Strategy
+type AgentOption = agent.Option
+
+type Strategy = agent.Strategy
+
+const (
+	StrategyLoop        = agent.StrategyLoop
+	StrategyPlanExecute = agent.StrategyPlanExecute
+)
+
+var (
+	WithStrategy          = agent.WithStrategy
+	WithMaxSteps          = agent.WithMaxSteps
+	WithMaxReplans        = agent.WithMaxReplans
+	WithReflection        = agent.WithReflection
+	WithCritic            = agent.WithCritic
+	WithMaxRepairs        = agent.WithMaxRepairs
+	WithMaxAttachmentSize = agent.WithMaxAttachmentSize
+)
+
+// DefaultAgent names the agent given to New. It answers whenever no other
+// agent was routed or handed the message.
+const DefaultAgent = "coordinator"
+
+const maxHandoffs = 3
+
 type ADK struct {
 	agent   *agent.Service
 	space   *space.Service
 	session *session.Service
+	memory  memorymanager.MemoryManager
+	turns   int
+	context int
+	hops    int
+	agents  map[string]*agent.Service
+	routes  []router.Route
+	router  router.Router
+	mtx     sync.RWMutex
 }
 
 func (a *ADK) CreateSpace(ctx context.Context, name string) (string, error) {
@@ -37,8 +81,13 @@ func (a *ADK) GetSpaceName(ctx context.Context, id string) (string, error) {
 	return space.Name(), nil
 }
 
-func (a *ADK) DeleteSpace(ctx context.Context, id string) {
-	a.space.DeleteSpace(ctx, id)
+func (a *ADK) DeleteSpace(ctx context.Context, id string) error {
+	if err := a.space.DeleteSpace(ctx, id); err != nil {
+		return err
+	}
+	// the memory manager removes the space's sessions along with it
+	a.session.DropSpace(ctx, id)
+	return nil
 }
 
 func (a *ADK) CreateSession(ctx context.Context, spaceId string) (string, error) {
@@ -49,6 +98,22 @@ func (a *ADK) CreateSession(ctx context.Context, spaceId string) (string, error)
 	return session.ID(), nil
 }
 
+// CreateChildSession starts a session for work delegated from parentId, as
+// when this agent runs as a sub-agent of another
+func (a *ADK) CreateChildSession(ctx context.Context, spaceId string, parentId string) (string, error) {
+	session, err := a.session.CreateChildSession(ctx, spaceId, parentId)
+	if err != nil {
+		return "", err
+	}
+	return session.ID(), nil
+}
+
+// ChildSessionId finds the session delegated from parentId in the space,
+// returning an empty id when it has none yet
+func (a *ADK) ChildSessionId(ctx context.Context, spaceId string, parentId string) (string, error) {
+	return a.session.FindChildSession(ctx, spaceId, parentId)
+}
+
 func (a *ADK) ListSessionIds(ctx context.Context) ([]string, error) {
 	return a.session.ListSessionIds(ctx)
 }
@@ -61,12 +126,209 @@ func (a *ADK) GetSessionSpaceId(ctx context.Context, id string) (string, error)
 	return session.SpaceId(), nil
 }
 
-func (a *ADK) DeleteSession(ctx context.Context, id string) {
-	a.session.DeleteSession(ctx, id)
+func (a *ADK) GetSessionParentId(ctx context.Context, id string) (string, error) {
+	session, err := a.session.GetSession(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return session.ParentId(), nil
+}
+
+func (a *ADK) DeleteSession(ctx context.Context, id string) error {
+	return a.session.DeleteSession(ctx, id)
+}
+
+// AddAgent registers a specialist that a router can pick or another agent
+// can hand the session to. It shares the session's memory but brings its
+// own model, tools and instructions.
+func (a *ADK) AddAgent(name string, description string, generator generator.Generator, toolHandlers []toolhandler.ToolHandler, systemPrompt string, opts ...AgentOption) error {
+	name = strings.TrimSpace(name)
+	if len(name) == 0 {
+		return errors.New("agent name is required")
+	}
+
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	for existing := range a.agents {
+		if strings.EqualFold(existing, name) {
+			return fmt.Errorf("agent %s already registered", name)
+		}
+	}
+
+	a.agents[name] = agent.New(
+		a.memory,
+		generator,
+		toolHandlers,
+		a.turns,
+		a.context,
+		a.hops,
+		systemPrompt,
+		opts...,
+	)
+
+	a.routes = append(a.routes, router.Route{Name: name, Description: description})
+
+	// every agent can hand off to every other one
+	for self, svc := range a.agents {
+		others := make([]router.Route, 0, len(a.routes)-1)
+		for _, route := range a.routes {
+			if route.Name != self {
+				others = append(others, route)
+			}
+		}
+		svc.SetHandoffs(others)
+	}
+
+	return nil
+}
+
+// SetRouter has every user message classified to pick the agent that
+// answers it, unless an agent was handed the session and still holds it
+func (a *ADK) SetRouter(r router.Router) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	a.router = r
+}
+
+func (a *ADK) GetSessionActiveAgent(ctx context.Context, id string) (string, error) {
+	name, _, err := a.session.ActiveAgent(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if len(name) == 0 {
+		return DefaultAgent, nil
+	}
+	return name, nil
 }
 
 func (a *ADK) Generate(ctx context.Context, sessionId string, userInput string, files map[string]memorymanager.InputFile) (string, error) {
-	return a.agent.Respond(ctx, sessionId, userInput, files)
+	return a.generate(ctx, sessionId, userInput, files, nil)
+}
+
+// GenerateJSON answers like Generate but replies with a JSON document
+// conforming to schema, for callers that consume the answer as data
+func (a *ADK) GenerateJSON(ctx context.Context, sessionId string, userInput string, files map[string]memorymanager.InputFile, schema map[string]any) (json.RawMessage, error) {
+	if len(schema) == 0 {
+		return nil, errors.New("schema is required")
+	}
+
+	answer, err := a.generate(ctx, sessionId, userInput, files, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.RawMessage(answer), nil
+}
+
+// GenerateInto answers with JSON shaped like out, which should be a pointer
+// to a struct, and unmarshals the answer into it
+func (a *ADK) GenerateInto(ctx context.Context, sessionId string, userInput string, files map[string]memorymanager.InputFile, out any) error {
+	schema, err := jsonschema.For(out)
+	if err != nil {
+		return err
+	}
+
+	answer, err := a.GenerateJSON(ctx, sessionId, userInput, files, schema)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(answer, out)
+}
+
+func (a *ADK) generate(ctx context.Context, sessionId string, userInput string, files map[string]memorymanager.InputFile, schema map[string]any) (string, error) {
+	ctx, files, err := agent.Attach(ctx, files)
+	if err != nil {
+		return "", err
+	}
+
+	name := a.pick(ctx, sessionId, userInput)
+
+	var answer string
+	if schema == nil {
+		answer, err = a.agentFor(name).Respond(ctx, sessionId, userInput, files)
+	} else {
+		answer, err = a.agentFor(name).RespondJSON(ctx, sessionId, userInput, files, schema)
+	}
+
+	for handoffs := 0; ; handoffs++ {
+		var handoff *agent.Handoff
+		if !errors.As(err, &handoff) {
+			return answer, err
+		}
+
+		if handoffs == maxHandoffs {
+			return "", fmt.Errorf("session was handed off more than %d times in one turn", maxHandoffs)
+		}
+
+		slog.InfoContext(ctx, "handed off session", "session", sessionId, "from", name, "to", handoff.Agent, "reason", handoff.Reason)
+
+		name = handoff.Agent
+
+		// handing back to the default agent returns the session to the router
+		a.session.SetActiveAgent(ctx, sessionId, name, name != DefaultAgent)
+
+		if schema == nil {
+			answer, err = a.agentFor(name).Resume(ctx, sessionId, userInput)
+		} else {
+			answer, err = a.agentFor(name).ResumeJSON(ctx, sessionId, userInput, schema)
+		}
+	}
+}
+
+// pick chooses the agent for a new user message: the one holding the
+// session after a handoff, else the router's choice, else the default
+func (a *ADK) pick(ctx context.Context, sessionId string, userInput string) string {
+	active, handedOff, err := a.session.ActiveAgent(ctx, sessionId)
+	if err == nil && handedOff {
+		return active
+	}
+
+	a.mtx.RLock()
+	r, routes := a.router, a.routes
+	a.mtx.RUnlock()
+
+	if r == nil || len(routes) < 2 {
+		return DefaultAgent
+	}
+
+	name, err := r.Route(ctx, userInput, routes)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to route message", "session", sessionId, "error", err)
+		name = ""
+	}
+
+	if len(name) == 0 {
+		name = DefaultAgent
+	}
+
+	// sessions the ADK did not create have no state to record it in
+	a.session.SetActiveAgent(ctx, sessionId, name, false)
+
+	return name
+}
+
+func (a *ADK) agentFor(name string) *agent.Service {
+	a.mtx.RLock()
+	defer a.mtx.RUnlock()
+	if svc, ok := a.agents[name]; ok {
+		return svc
+	}
+	return a.agent
+}
+
+// Usage reports what responding in the session has cost so far, including
+// any sub-agents it delegated to
+func (a *ADK) Usage(sessionId string) Usage {
+	a.mtx.RLock()
+	defer a.mtx.RUnlock()
+
+	var total Usage
+	for _, svc := range a.agents {
+		total = total.Add(svc.Usage(sessionId))
+	}
+	return total
 }
 
 func (a *ADK) FlushSession(ctx context.Context, sessionId string) error {
@@ -86,8 +348,9 @@ func New(
 	context int,
 	hops int,
 	systemPrompt string,
+	opts ...AgentOption,
 ) *ADK {
-	agent := agent.New(
+	coordinator := agent.New(
 		memory,
 		generator,
 		toolHandlers,
@@ -95,6 +358,7 @@ func New(
 		context,
 		hops,
 		systemPrompt,
+		opts...,
 	)
 
 	space := space.New(
@@ -106,9 +370,19 @@ func New(
 	)
 
 	adk := &ADK{
-		agent:   agent,
+		agent:   coordinator,
 		space:   space,
 		session: session,
+		memory:  memory,
+		turns:   turns,
+		context: context,
+		hops:    hops,
+		agents:  map[string]*agent.Service{DefaultAgent: coordinator},
+		routes: []router.Route{{
+			Name:        DefaultAgent,
+			Description: "Coordinates the team and handles anything no specialist covers.",
+		}},
+		mtx: sync.RWMutex{},
 	}
 
 	return adk
@@ -54,6 +54,8 @@ var (
 		Context      int    `help:"Number of conversation turns to send to the model" default:"6"`
 		Hops         int    `help:"Number of hops to search for graphically related memories" default:"1"`
 		SystemPrompt string `help:"System prompt for the agent" default:"You orchestrate tooling and specialists to help the user build AI agents."`
+		Strategy     string `help:"How the agent works a prompt: loop or plan_execute" enum:"loop,plan_execute" default:"loop"`
+		Reflection   int    `help:"Number of rounds the agent may critique and revise its answer" default:"0"`
 
 		// Space config
 		Space string `help:"Option space identifier" default:""`
@@ -85,33 +87,39 @@ func main() {
 	}()
 	time.Sleep(200 * time.Millisecond)
 
+	// Create primary agent's model
+	primaryModel := openaigenerator.NewGenerator(
+		generator.WithApiKey(cfg.GeneratorKey),
+		generator.WithModel(cfg.Generator),
+		generator.WithPromptPrefix("Coordinator response:"),
+	)
+
 	// Create memory manager
 	// re := gomento.NewMemoryManager(
 	// 	memorymanager.WithLocation(cfg.MemoryLocation),
 	// )
 
+	memoryStorer, err := neo4j.NewStorer(
+		storer.WithLocation(cfg.MemoryLocation),
+		storer.WithCollection("neo4j"),
+		storer.WithVectorIndex("agent_memory_index"),
+		storer.WithVectorSize(1536),
+		storer.WithLexicalIndex(true),
+	)
+	if err != nil {
+		log.Fatalf("❌ failed to connect to memory storer: %v", err)
+	}
+
 	re := munin.NewMemoryManager(
-		memorymanager.WithStorer(
-			neo4j.NewStorer(
-				storer.WithLocation(cfg.MemoryLocation),
-				storer.WithCollection("neo4j"),
-				storer.WithVectorIndex("agent_memory_index"),
-				storer.WithVectorSize(1536),
-			),
-		),
+		memorymanager.WithStorer(memoryStorer),
 		memorymanager.WithEmbedder(
 			openaiembedder.NewEmbedder(
 				embedder.WithApiKey(cfg.EmbedderKey),
 				embedder.WithModel(cfg.Embedder),
 			),
 		),
-	)
-
-	// Create primary agent's model
-	primaryModel := openaigenerator.NewGenerator(
-		generator.WithApiKey(cfg.GeneratorKey),
-		generator.WithModel(cfg.Generator),
-		generator.WithPromptPrefix("Coordinator response:"),
+		munin.WithGenerator(primaryModel),
+		munin.WithCompaction(munin.Compaction{}),
 	)
 
 	// Load dynamic tooling
@@ -144,6 +152,8 @@ func main() {
 		cfg.Context,
 		cfg.Hops,
 		cfg.SystemPrompt,
+		agent.WithStrategy(agent.Strategy(cfg.Strategy)),
+		agent.WithReflection(cfg.Reflection),
 	)
 	defer adk.Close()
 
@@ -0,0 +1,422 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/alecthomas/kong"
+	memorymanager "github.com/w-h-a/agent/memory_manager"
+	"github.com/w-h-a/agent/memory_manager/munin"
+	"github.com/w-h-a/agent/memory_manager/providers/embedder"
+	cachedembedder "github.com/w-h-a/agent/memory_manager/providers/embedder/cached"
+	googleembedder "github.com/w-h-a/agent/memory_manager/providers/embedder/google"
+	openaiembedder "github.com/w-h-a/agent/memory_manager/providers/embedder/openai"
+	embeddingcache "github.com/w-h-a/agent/memory_manager/providers/embedding_cache"
+	sqlitecache "github.com/w-h-a/agent/memory_manager/providers/embedding_cache/sqlite"
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+	memorystorer "github.com/w-h-a/agent/memory_manager/providers/storer/memory"
+	"github.com/w-h-a/agent/memory_manager/providers/storer/neo4j"
+	"github.com/w-h-a/agent/memory_manager/providers/storer/postgres"
+	"github.com/w-h-a/agent/memory_manager/providers/storer/qdrant"
+	sqlitestorer "github.com/w-h-a/agent/memory_manager/providers/storer/sqlite"
+)
+
+type storerConfig struct {
+	Storer      string `help:"Storer holding the space" enum:"memory,sqlite,postgres,qdrant,neo4j" default:"sqlite"`
+	Location    string `help:"Address or file of the storer" default:"agent.db"`
+	ApiKey      string `help:"API Key for the storer" default:""`
+	Collection  string `help:"Collection or database name" default:""`
+	VectorIndex string `help:"Vector index name" default:""`
+	VectorSize  uint64 `help:"Embedding dimension of the storer" default:"1536"`
+	Snapshot    string `help:"Snapshot file backing the memory storer" default:"memory.json"`
+	Migrate     bool   `help:"Apply the embedded schema migrations to a postgres storer first"`
+	Username    string `help:"Username for neo4j basic auth" default:""`
+	Password    string `help:"Password for neo4j basic auth" default:"" env:"NEO4J_PASSWORD"`
+}
+
+func (c storerConfig) open(extra ...storer.Option) (storer.Storer, error) {
+	opts := append([]storer.Option{
+		storer.WithLocation(c.Location),
+		storer.WithApiKey(c.ApiKey),
+		storer.WithCollection(c.Collection),
+		storer.WithVectorIndex(c.VectorIndex),
+		storer.WithVectorSize(c.VectorSize),
+	}, extra...)
+
+	switch c.Storer {
+	case "memory":
+		return memorystorer.NewStorer(append(opts, memorystorer.WithSnapshot(c.Snapshot))...), nil
+	case "postgres":
+		if c.Migrate {
+			opts = append(opts, postgres.WithAutoMigrate())
+		}
+		return postgres.NewStorer(opts...), nil
+	case "qdrant":
+		return qdrant.NewStorer(opts...), nil
+	case "neo4j":
+		if len(c.Username) > 0 {
+			opts = append(opts, neo4j.WithBasicAuth(c.Username, c.Password, ""))
+		}
+		return neo4j.NewStorer(opts...)
+	default:
+		return sqlitestorer.NewStorer(opts...), nil
+	}
+}
+
+type embedderConfig struct {
+	Embedder    string `help:"Embedder to re-embed with" enum:"openai,google" default:"openai"`
+	EmbedderKey string `help:"API Key for the embedder" default:""`
+	Model       string `help:"Model identifier for embedder" default:"text-embedding-3-small"`
+	Cache       string `help:"Optional SQLite file caching vectors across runs" default:""`
+}
+
+func (c embedderConfig) newEmbedder() embedder.Embedder {
+	opts := []embedder.Option{
+		embedder.WithApiKey(c.EmbedderKey),
+		embedder.WithModel(c.Model),
+	}
+
+	var emb embedder.Embedder
+	if c.Embedder == "google" {
+		emb = googleembedder.NewEmbedder(opts...)
+	} else {
+		emb = openaiembedder.NewEmbedder(opts...)
+	}
+
+	if len(c.Cache) == 0 {
+		return emb
+	}
+
+	return cachedembedder.NewEmbedder(
+		cachedembedder.WithEmbedder(emb),
+		cachedembedder.WithCache(sqlitecache.NewCache(embeddingcache.WithLocation(c.Cache))),
+	)
+}
+
+type exportCmd struct {
+	storerConfig
+
+	Space string `help:"Space to export" required:""`
+	Out   string `help:"File to write, - for stdout" default:"-"`
+}
+
+func (c *exportCmd) Run(ctx context.Context) error {
+	s, err := c.open()
+	if err != nil {
+		return err
+	}
+
+	exporter, ok := s.(storer.Exporter)
+	if !ok {
+		return fmt.Errorf("%s storer cannot export", c.Storer)
+	}
+
+	var out io.Writer = os.Stdout
+	if c.Out != "-" {
+		f, err := os.Create(c.Out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	w, err := storer.NewJSONLWriter(out, storer.Header{
+		SpaceId:   c.Space,
+		Dimension: int(c.VectorSize),
+	})
+	if err != nil {
+		return err
+	}
+
+	return exporter.Export(ctx, c.Space, w)
+}
+
+type importCmd struct {
+	storerConfig
+
+	In    string `help:"File to read, - for stdin" default:"-"`
+	Space string `help:"Optional space to import into instead of the exported one" default:""`
+
+	// Re-embedding config
+	Reembed   bool `help:"Recompute every embedding with the embedder below while importing"`
+	BatchSize int  `help:"Items embedded per batch when re-embedding" default:"64"`
+	embedderConfig
+}
+
+func (c *importCmd) Run(ctx context.Context) error {
+	s, err := c.open()
+	if err != nil {
+		return err
+	}
+
+	importer, ok := s.(storer.Importer)
+	if !ok {
+		return fmt.Errorf("%s storer cannot import", c.Storer)
+	}
+
+	var in io.Reader = os.Stdin
+	if c.In != "-" {
+		f, err := os.Open(c.In)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	r, header, err := storer.NewJSONLReader(in)
+	if err != nil {
+		return err
+	}
+
+	read := func() (storer.Item, error) {
+		item, err := r.Read()
+		if err != nil {
+			return item, err
+		}
+		if len(c.Space) > 0 {
+			retarget(&item, c.Space)
+		}
+		return item, nil
+	}
+
+	if c.Reembed {
+		rr := &reembedReader{next: read, emb: c.newEmbedder(), size: max(c.BatchSize, 1)}
+		read = func() (storer.Item, error) {
+			return rr.Read(ctx)
+		}
+	}
+
+	stats, err := importer.Import(ctx, storer.ItemReaderFunc(read))
+	if err != nil {
+		return err
+	}
+
+	if err := snapshot(ctx, s); err != nil {
+		return err
+	}
+
+	return json.NewEncoder(os.Stderr).Encode(map[string]any{
+		"exported_space": header.SpaceId,
+		"version":        header.Version,
+		"stats":          stats,
+	})
+}
+
+type reembedCmd struct {
+	storerConfig
+	embedderConfig
+
+	Space     string `help:"Space to move onto the embedder" required:""`
+	BatchSize int    `help:"Memories embedded per batch" default:"64"`
+}
+
+func (c *reembedCmd) Run(ctx context.Context) error {
+	s, err := c.open()
+	if err != nil {
+		return err
+	}
+	emb := c.newEmbedder()
+
+	manager := munin.NewMemoryManager(
+		memorymanager.WithStorer(s),
+		memorymanager.WithEmbedder(emb),
+	)
+
+	reembedder, ok := manager.(memorymanager.Reembedder)
+	if !ok {
+		return fmt.Errorf("memory manager cannot re-embed")
+	}
+
+	job, err := reembedder.Reembed(ctx, c.Space, emb, memorymanager.WithReembedBatchSize(c.BatchSize))
+	if err != nil {
+		return err
+	}
+
+	// an interrupted run stops cleanly and resumes next time
+	interrupted, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(5 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-interrupted.Done():
+				job.Stop()
+				return
+			case <-ticker.C:
+				p := job.Progress()
+				log.Printf("staged %d memories for %s", p.Staged, p.Model)
+			}
+		}
+	}()
+
+	progress, err := job.Wait()
+	close(done)
+
+	// staged vectors are progress too so they are saved even when the job failed
+	if snapErr := snapshot(ctx, s); snapErr != nil && err == nil {
+		err = snapErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return json.NewEncoder(os.Stderr).Encode(progress)
+}
+
+type expireCmd struct {
+	storerConfig
+
+	TTL time.Duration `help:"Age past which memories are deleted" required:""`
+}
+
+func (c *expireCmd) Run(ctx context.Context) error {
+	s, err := c.open(storer.WithTTL(c.TTL))
+	if err != nil {
+		return err
+	}
+
+	expired, err := s.DeleteExpired(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := snapshot(ctx, s); err != nil {
+		return err
+	}
+
+	return json.NewEncoder(os.Stderr).Encode(map[string]any{
+		"expired": expired,
+	})
+}
+
+// the memory storer only outlives this process through its snapshot
+func snapshot(ctx context.Context, s storer.Storer) error {
+	if snapshotter, ok := s.(interface{ Snapshot(context.Context) error }); ok {
+		return snapshotter.Snapshot(ctx)
+	}
+	return nil
+}
+
+func retarget(item *storer.Item, spaceId string) {
+	switch {
+	case item.Record != nil:
+		item.Record.SpaceId = spaceId
+	case item.Entity != nil:
+		item.Entity.SpaceId = spaceId
+	case item.Skill != nil:
+		item.Skill.SpaceId = spaceId
+	case item.Chunk != nil:
+		item.Chunk.SpaceId = spaceId
+	}
+}
+
+// embeddable points at the text an item is embedded from and the vector it
+// is stored with
+func embeddable(emb embedder.Embedder, item *storer.Item) (string, *[]float32, bool) {
+	switch {
+	case item.Record != nil:
+		item.Record.EmbeddingModel = emb.Model()
+		return item.Record.Content, &item.Record.Embedding, true
+	case item.Entity != nil:
+		return munin.EntityText(item.Entity.Name, item.Entity.Kind, item.Entity.Aliases), &item.Entity.Embedding, true
+	case item.Skill != nil:
+		return item.Skill.Trigger, &item.Skill.Embedding, true
+	case item.Chunk != nil:
+		return item.Chunk.Content, &item.Chunk.Embedding, true
+	default:
+		return "", nil, false
+	}
+}
+
+// reembedReader reads items ahead in batches so each batch is embedded in
+// one call
+type reembedReader struct {
+	next    func() (storer.Item, error)
+	emb     embedder.Embedder
+	size    int
+	pending []storer.Item
+	err     error
+}
+
+func (r *reembedReader) Read(ctx context.Context) (storer.Item, error) {
+	if len(r.pending) == 0 && r.err == nil {
+		r.fill(ctx)
+	}
+
+	if len(r.pending) == 0 {
+		return storer.Item{}, r.err
+	}
+
+	item := r.pending[0]
+	r.pending = r.pending[1:]
+
+	return item, nil
+}
+
+func (r *reembedReader) fill(ctx context.Context) {
+	for len(r.pending) < r.size {
+		item, err := r.next()
+		if err != nil {
+			// io.EOF included, handed back once the batch is drained
+			r.err = err
+			break
+		}
+		r.pending = append(r.pending, item)
+	}
+
+	texts := []string{}
+	targets := []*[]float32{}
+
+	for i := range r.pending {
+		if text, target, ok := embeddable(r.emb, &r.pending[i]); ok {
+			texts = append(texts, text)
+			targets = append(targets, target)
+		}
+	}
+
+	if len(texts) == 0 {
+		return
+	}
+
+	vectors, err := r.emb.EmbedBatch(ctx, texts)
+	if err != nil {
+		r.pending = nil
+		r.err = err
+		return
+	}
+
+	for i, target := range targets {
+		*target = vectors[i]
+	}
+}
+
+var cli struct {
+	Export  exportCmd  `cmd:"" help:"Write a space's long-term memory to a JSONL export"`
+	Import  importCmd  `cmd:"" help:"Load a JSONL export into a storer"`
+	Reembed reembedCmd `cmd:"" help:"Move a space's memories onto another embedding model, resuming any earlier run"`
+	Expire  expireCmd  `cmd:"" help:"Delete memories past a retention period, as from a periodic job"`
+}
+
+func main() {
+	// Parse inputs
+	ctx := context.Background()
+	kctx := kong.Parse(&cli, kong.BindTo(ctx, (*context.Context)(nil)))
+
+	if err := kctx.Run(); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+}
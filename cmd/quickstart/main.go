@@ -16,13 +16,24 @@ import (
 	openaigenerator "github.com/w-h-a/agent/generator/openai"
 	memorymanager "github.com/w-h-a/agent/memory_manager"
 	"github.com/w-h-a/agent/memory_manager/gomento"
+	"github.com/w-h-a/agent/memory_manager/munin"
+	"github.com/w-h-a/agent/memory_manager/providers/buffer"
+	sqlitebuffer "github.com/w-h-a/agent/memory_manager/providers/buffer/sqlite"
+	"github.com/w-h-a/agent/memory_manager/providers/embedder"
+	openaiembedder "github.com/w-h-a/agent/memory_manager/providers/embedder/openai"
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+	sqlitestorer "github.com/w-h-a/agent/memory_manager/providers/storer/sqlite"
 	toolhandler "github.com/w-h-a/agent/tool_handler"
 )
 
 var (
 	cfg struct {
 		// Memory config
+		Memory         string `help:"Memory manager to use" enum:"gomento,munin" default:"gomento"`
 		MemoryLocation string `help:"Address of memory store for memory manager" default:"http://localhost:4000"`
+		MemoryKey      string `help:"API Key for the gomento memory store" default:""`
+		BufferLocation string `help:"SQLite file holding munin short-term memory so sessions survive restarts" default:"agent.db"`
+		StorerLocation string `help:"SQLite file holding munin long-term memory" default:"agent.db"`
 		Window         int    `help:"Short-term memory window size per session" default:"8"`
 		EmbedderKey    string `help:"API Key for the embedder" default:""`
 		Embedder       string `help:"Model identifier for embedder" default:"text-embedding-3-small"`
@@ -54,17 +65,43 @@ func main() {
 	_ = kong.Parse(&cfg)
 	ctx := context.Background()
 
-	// Create memory manager
-	re := gomento.NewMemoryManager(
-		memorymanager.WithLocation(cfg.MemoryLocation),
-	)
-
 	// Create primary agent's model
 	primaryModel := openaigenerator.NewGenerator(
 		generator.WithApiKey(cfg.GeneratorKey),
 		generator.WithModel(cfg.Generator),
 	)
 
+	// Create memory manager
+	var re memorymanager.MemoryManager
+	switch cfg.Memory {
+	case "munin":
+		re = munin.NewMemoryManager(
+			memorymanager.WithBuffer(
+				sqlitebuffer.NewBuffer(
+					buffer.WithLocation(cfg.BufferLocation),
+				),
+			),
+			memorymanager.WithStorer(
+				sqlitestorer.NewStorer(
+					storer.WithLocation(cfg.StorerLocation),
+					storer.WithLexicalIndex(true),
+				),
+			),
+			memorymanager.WithEmbedder(
+				openaiembedder.NewEmbedder(
+					embedder.WithApiKey(cfg.EmbedderKey),
+					embedder.WithModel(cfg.Embedder),
+				),
+			),
+			munin.WithGenerator(primaryModel),
+		)
+	default:
+		re = gomento.NewMemoryManager(
+			memorymanager.WithLocation(cfg.MemoryLocation),
+			gomento.WithApiKey(cfg.MemoryKey),
+		)
+	}
+
 	// Create custom tooling
 	echo := echo.NewToolHandler()
 
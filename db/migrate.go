@@ -0,0 +1,151 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Migrations holds the postgres schema as numbered up and down files in
+// the layout the migrate CLI reads
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
+
+type migration struct {
+	version uint64
+	name    string
+	query   string
+}
+
+// Migrate applies every up migration newer than the database's version.
+// Versions are kept in the same schema_migrations table the migrate CLI
+// uses so databases it migrated carry on from where it stopped. A row in
+// schema_migrations_lock is held for the whole run so concurrent callers
+// wait for one another instead of racing.
+func Migrate(ctx context.Context, conn *sql.DB) error {
+	migrations, err := load()
+	if err != nil {
+		return err
+	}
+
+	if err := prepare(ctx, conn); err != nil {
+		return err
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT id FROM schema_migrations_lock WHERE id = 1 FOR UPDATE`); err != nil {
+		return fmt.Errorf("failed to take migration lock: %w", err)
+	}
+
+	var current uint64
+	var dirty bool
+
+	err = tx.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&current, &dirty)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	if dirty {
+		return fmt.Errorf("database is dirty at version %d and must be fixed by hand", current)
+	}
+
+	applied := false
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+
+		// every migration shares the transaction so a failure leaves the schema untouched
+		if _, err := tx.ExecContext(ctx, m.query); err != nil {
+			return fmt.Errorf("failed to apply migration %d %s: %w", m.version, m.name, err)
+		}
+
+		current = m.version
+		applied = true
+	}
+
+	if !applied {
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations`); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)`, current); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func prepare(ctx context.Context, conn *sql.DB) error {
+	for _, query := range []string{
+		`CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)`,
+		`CREATE TABLE IF NOT EXISTS schema_migrations_lock (id INT PRIMARY KEY)`,
+		`INSERT INTO schema_migrations_lock (id) VALUES (1) ON CONFLICT (id) DO NOTHING`,
+	} {
+		if _, err := conn.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to prepare migration tables: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func load() ([]migration, error) {
+	entries, err := fs.ReadDir(Migrations, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []migration
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, ".up.sql") {
+			continue
+		}
+
+		prefix, rest, ok := strings.Cut(name, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s has no version", name)
+		}
+
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s has no version: %w", name, err)
+		}
+
+		query, err := fs.ReadFile(Migrations, path.Join("migrations", name))
+		if err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, migration{
+			version: version,
+			name:    strings.TrimSuffix(rest, ".up.sql"),
+			query:   string(query),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+
+	return migrations, nil
+}
@@ -7,5 +7,5 @@ CREATE TABLE IF NOT EXISTS message_edges (
     PRIMARY KEY (source_id, target_id, type)
 );
 
-CREATE INDEX IF NOT EXISTS idx_messages_edges_source ON messages_edges(source_id);
-CREATE INDEX IF NOT EXISTS idx_messages_edges_target ON messages_edges(target_id);
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS idx_message_edges_source ON message_edges(source_id);
+CREATE INDEX IF NOT EXISTS idx_message_edges_target ON message_edges(target_id);
\ No newline at end of file
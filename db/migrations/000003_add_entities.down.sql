@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS entity_relations;
+DROP TABLE IF EXISTS entities;
\ No newline at end of file
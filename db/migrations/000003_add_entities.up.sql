@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS entities (
+    id BIGSERIAL PRIMARY KEY,
+    space_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL DEFAULT '',
+    aliases JSONB DEFAULT '[]'::jsonb,
+    attributes JSONB DEFAULT '{}'::jsonb,
+    embedding vector(1536),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS entity_space_idx ON entities (space_id);
+CREATE INDEX IF NOT EXISTS entity_embedding_idx ON entities USING hnsw (embedding vector_cosine_ops);
+
+CREATE TABLE IF NOT EXISTS entity_relations (
+    source_id BIGINT NOT NULL REFERENCES entities(id) ON DELETE CASCADE,
+    target_id BIGINT NOT NULL REFERENCES entities(id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (source_id, target_id, type)
+);
+
+CREATE INDEX IF NOT EXISTS idx_entity_relations_source ON entity_relations(source_id);
+CREATE INDEX IF NOT EXISTS idx_entity_relations_target ON entity_relations(target_id);
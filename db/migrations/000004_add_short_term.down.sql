@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS session_tasks;
+DROP TABLE IF EXISTS session_messages;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS spaces;
\ No newline at end of file
@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS spaces (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS sessions (
+    id TEXT PRIMARY KEY,
+    space_id TEXT NOT NULL DEFAULT '',
+    summaries JSONB DEFAULT '[]'::jsonb,
+    last_record_id TEXT NOT NULL DEFAULT '',
+    last_role TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS sessions_space_idx ON sessions (space_id);
+
+CREATE TABLE IF NOT EXISTS session_messages (
+    seq BIGSERIAL PRIMARY KEY,
+    id TEXT NOT NULL UNIQUE,
+    session_id TEXT NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    role TEXT NOT NULL,
+    parts JSONB DEFAULT '[]'::jsonb,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS session_messages_session_idx ON session_messages (session_id, seq);
+
+CREATE TABLE IF NOT EXISTS session_tasks (
+    id TEXT NOT NULL,
+    session_id TEXT NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    task_order INT NOT NULL DEFAULT 0,
+    data JSONB DEFAULT 'null'::jsonb,
+    status TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (session_id, id)
+);
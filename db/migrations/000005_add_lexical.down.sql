@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS memory_content_tsv_idx;
+
+ALTER TABLE messages DROP COLUMN IF EXISTS content_tsv;
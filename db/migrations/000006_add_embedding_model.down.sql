@@ -0,0 +1,20 @@
+DO $$
+DECLARE
+    idx TEXT;
+BEGIN
+    FOR idx IN SELECT indexname FROM pg_indexes WHERE indexname ~ '^(memory|entity)_embedding_[0-9]+_idx$' LOOP
+        EXECUTE format('DROP INDEX IF EXISTS %I', idx);
+    END LOOP;
+END $$;
+
+DROP INDEX IF EXISTS memory_staged_idx;
+
+ALTER TABLE messages DROP COLUMN IF EXISTS staged_model;
+ALTER TABLE messages DROP COLUMN IF EXISTS staged_embedding;
+ALTER TABLE messages DROP COLUMN IF EXISTS embedding_model;
+
+ALTER TABLE entities ALTER COLUMN embedding TYPE vector(1536);
+ALTER TABLE messages ALTER COLUMN embedding TYPE vector(1536);
+
+CREATE INDEX IF NOT EXISTS entity_embedding_idx ON entities USING hnsw (embedding vector_cosine_ops);
+CREATE INDEX IF NOT EXISTS memory_embedding_idx ON messages USING hnsw (embedding vector_cosine_ops);
@@ -0,0 +1,13 @@
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS embedding_model TEXT NOT NULL DEFAULT '';
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS staged_embedding vector;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS staged_model TEXT;
+
+-- the storer picks the dimension so the columns take any size and the
+-- storer builds a partial index per dimension in use
+DROP INDEX IF EXISTS memory_embedding_idx;
+ALTER TABLE messages ALTER COLUMN embedding TYPE vector;
+
+DROP INDEX IF EXISTS entity_embedding_idx;
+ALTER TABLE entities ALTER COLUMN embedding TYPE vector;
+
+CREATE INDEX IF NOT EXISTS memory_staged_idx ON messages (space_id, staged_model) WHERE staged_model IS NOT NULL;
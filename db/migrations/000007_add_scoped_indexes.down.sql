@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS memory_created_idx;
+DROP INDEX IF EXISTS memory_space_created_idx;
@@ -0,0 +1,6 @@
+-- 000002 once indexed a table that does not exist so older databases lack these
+CREATE INDEX IF NOT EXISTS idx_message_edges_source ON message_edges(source_id);
+CREATE INDEX IF NOT EXISTS idx_message_edges_target ON message_edges(target_id);
+
+CREATE INDEX IF NOT EXISTS memory_space_created_idx ON messages (space_id, created_at);
+CREATE INDEX IF NOT EXISTS memory_created_idx ON messages (created_at);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS session_tasks_order_idx;
+DROP TABLE IF EXISTS chunks;
+DROP TABLE IF EXISTS files;
+DROP TABLE IF EXISTS skills;
@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS skills (
+    id TEXT PRIMARY KEY,
+    space_id TEXT NOT NULL,
+    trigger TEXT NOT NULL,
+    sop TEXT NOT NULL DEFAULT '',
+    embedding vector,
+    embedding_model TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS skills_space_idx ON skills (space_id, created_at);
+
+CREATE TABLE IF NOT EXISTS files (
+    id TEXT PRIMARY KEY,
+    space_id TEXT NOT NULL,
+    filename TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS files_space_idx ON files (space_id, created_at);
+
+CREATE TABLE IF NOT EXISTS chunks (
+    id TEXT PRIMARY KEY,
+    file_id TEXT NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+    space_id TEXT NOT NULL,
+    chunk_index INT NOT NULL,
+    content TEXT NOT NULL,
+    embedding vector,
+    embedding_model TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (file_id, chunk_index)
+);
+
+CREATE INDEX IF NOT EXISTS chunks_space_idx ON chunks (space_id);
+
+-- session_tasks from 000004 already holds tasks per session so only the
+-- lookups the buffer makes are indexed here
+CREATE INDEX IF NOT EXISTS session_tasks_order_idx ON session_tasks (session_id, task_order, created_at);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS memory_space_model_idx;
@@ -0,0 +1,2 @@
+-- lets the memory manager check cheaply that a space is on the model it searches with
+CREATE INDEX IF NOT EXISTS memory_space_model_idx ON messages (space_id, embedding_model);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS sessions_parent_idx;
+
+ALTER TABLE sessions DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,4 @@
+-- lets a sub-agent find the child session it delegated to after a restart
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS parent_id TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS sessions_parent_idx ON sessions (space_id, parent_id);
@@ -0,0 +1,161 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	memorymanager "github.com/w-h-a/agent/memory_manager"
+)
+
+type ForgetReceipt struct {
+	SpaceId     string    `json:"space_id"`
+	SessionIds  []string  `json:"session_ids"`
+	RequestedBy string    `json:"requested_by"`
+	Reason      string    `json:"reason"`
+	RequestedAt time.Time `json:"requested_at"`
+	CompletedAt time.Time `json:"completed_at,omitzero"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// AuditLog durably records erasure requests. A receipt is recorded when
+// the request is made and again once it completes or fails.
+type AuditLog interface {
+	Record(ctx context.Context, receipt ForgetReceipt) error
+}
+
+// ForgetUser erases everything remembered in the user's space: short-term
+// sessions, long-term memories, their edges and extracted entities. Nothing
+// is erased unless the request can first be recorded in audit and the
+// returned receipt can be kept as evidence of the erasure.
+func (a *ADK) ForgetUser(ctx context.Context, spaceId string, requestedBy string, reason string, audit AuditLog) (ForgetReceipt, error) {
+	receipt := ForgetReceipt{
+		SpaceId:     spaceId,
+		RequestedBy: requestedBy,
+		Reason:      reason,
+		RequestedAt: time.Now().UTC(),
+	}
+
+	if audit == nil {
+		return receipt, errors.New("an audit log is required to forget a user")
+	}
+
+	sessionIds, err := a.listSessionIds(ctx, spaceId)
+	if err != nil {
+		return receipt, a.failForget(ctx, audit, receipt, err)
+	}
+
+	receipt.SessionIds = sessionIds
+
+	if err := audit.Record(ctx, receipt); err != nil {
+		return receipt, fmt.Errorf("failed to record forget request: %w", err)
+	}
+
+	if err := a.DeleteSpace(ctx, spaceId); err != nil {
+		return receipt, a.failForget(ctx, audit, receipt, err)
+	}
+
+	receipt.CompletedAt = time.Now().UTC()
+
+	if err := audit.Record(ctx, receipt); err != nil {
+		return receipt, fmt.Errorf("forgot user but failed to record it: %w", err)
+	}
+
+	slog.InfoContext(ctx, "forgot user", "space", spaceId, "sessions", len(sessionIds))
+
+	return receipt, nil
+}
+
+// DeleteExpired erases the long-term memories older than the storer's TTL.
+// Searches already leave them out, so it can run on whatever schedule suits.
+func (a *ADK) DeleteExpired(ctx context.Context) (int, error) {
+	expirer, ok := a.memory.(memorymanager.Expirer)
+	if !ok {
+		return 0, errors.New("memory manager cannot expire memories")
+	}
+
+	return expirer.DeleteExpired(ctx)
+}
+
+// listSessionIds finds the space's sessions wherever they are kept,
+// including those persisted by earlier processes
+func (a *ADK) listSessionIds(ctx context.Context, spaceId string) ([]string, error) {
+	sessionIds, err := a.session.ListSessionIdsBySpace(ctx, spaceId)
+	if err != nil {
+		return nil, err
+	}
+
+	if lister, ok := a.memory.(memorymanager.SessionLister); ok {
+		stored, err := lister.ListSessionIds(ctx, spaceId)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range stored {
+			if !slices.Contains(sessionIds, id) {
+				sessionIds = append(sessionIds, id)
+			}
+		}
+	}
+
+	slices.Sort(sessionIds)
+
+	return sessionIds, nil
+}
+
+func (a *ADK) failForget(ctx context.Context, audit AuditLog, receipt ForgetReceipt, err error) error {
+	receipt.Error = err.Error()
+
+	if auditErr := audit.Record(ctx, receipt); auditErr != nil {
+		slog.ErrorContext(ctx, "failed to record failed forget request", "space", receipt.SpaceId, "error", auditErr)
+		return errors.Join(err, auditErr)
+	}
+
+	return err
+}
+
+type fileAuditLog struct {
+	path string
+	mtx  sync.Mutex
+}
+
+// NewFileAuditLog appends each receipt to path as a line of JSON and syncs
+// it to disk before returning
+func NewFileAuditLog(path string) AuditLog {
+	if len(path) == 0 {
+		panic("missing path for file audit log")
+	}
+	return &fileAuditLog{path: path}
+}
+
+func (l *fileAuditLog) Record(ctx context.Context, receipt ForgetReceipt) error {
+	line, err := json.Marshal(receipt)
+	if err != nil {
+		return err
+	}
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -2,7 +2,10 @@ package anthropic
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"slices"
 	"strings"
 
 	anthropic "github.com/anthropics/anthropic-sdk-go"
@@ -10,6 +13,8 @@ import (
 	"github.com/w-h-a/agent/generator"
 )
 
+var imageFormats = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
 type anthropicGenerator struct {
 	options generator.Options
 	client  *anthropic.Client
@@ -49,6 +54,130 @@ func (g *anthropicGenerator) Generate(ctx context.Context, prompt string) (strin
 	return result, nil
 }
 
+// GenerateStructured forces a call to a tool whose input schema is the
+// requested one. Tool inputs must be objects so other schemas are wrapped.
+func (g *anthropicGenerator) GenerateStructured(ctx context.Context, prompt string, name string, schema map[string]any) (string, error) {
+	fullPrompt := prompt
+	if len(g.options.PromptPrefix) > 0 {
+		fullPrompt = g.options.PromptPrefix + "\n" + prompt
+	}
+
+	wrapped := schema["type"] != "object"
+	if wrapped {
+		schema = map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"value": schema},
+			"required":   []string{"value"},
+		}
+	}
+
+	var required []string
+	switch r := schema["required"].(type) {
+	case []string:
+		required = r
+	case []any:
+		for _, v := range r {
+			if name, ok := v.(string); ok {
+				required = append(required, name)
+			}
+		}
+	}
+
+	extra := map[string]any{}
+	for k, v := range schema {
+		if k != "type" && k != "properties" && k != "required" {
+			extra[k] = v
+		}
+	}
+
+	req := anthropic.MessageNewParams{
+		Model:     anthropic.Model(g.options.Model),
+		MaxTokens: 1024,
+		Messages: []anthropic.MessageParam{
+			anthropic.NewUserMessage(anthropic.NewTextBlock(fullPrompt)),
+		},
+		Tools: []anthropic.ToolUnionParam{
+			anthropic.ToolUnionParamOfTool(anthropic.ToolInputSchemaParam{
+				Properties:  schema["properties"],
+				Required:    required,
+				ExtraFields: extra,
+			}, name),
+		},
+		ToolChoice: anthropic.ToolChoiceParamOfTool(name),
+	}
+
+	rsp, err := g.client.Messages.New(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	for _, content := range rsp.Content {
+		use, ok := content.AsAny().(anthropic.ToolUseBlock)
+		if !ok || use.Name != name {
+			continue
+		}
+
+		if !wrapped {
+			return string(use.Input), nil
+		}
+
+		var input struct {
+			Value json.RawMessage `json:"value"`
+		}
+		if err := json.Unmarshal(use.Input, &input); err != nil {
+			return "", err
+		}
+
+		return string(input.Value), nil
+	}
+
+	return "", errors.New("no response from Anthropic")
+}
+
+func (g *anthropicGenerator) GenerateWithAttachments(ctx context.Context, prompt string, attachments []generator.Attachment) (string, error) {
+	fullPrompt := prompt
+	if len(g.options.PromptPrefix) > 0 {
+		fullPrompt = g.options.PromptPrefix + "\n" + prompt
+	}
+
+	blocks := make([]anthropic.ContentBlockParamUnion, 0, len(attachments)+1)
+	for _, attachment := range attachments {
+		blocks = append(blocks, anthropic.NewImageBlockBase64(attachment.MimeType, base64.StdEncoding.EncodeToString(attachment.Data)))
+	}
+	blocks = append(blocks, anthropic.NewTextBlock(fullPrompt))
+
+	req := anthropic.MessageNewParams{
+		Model:     anthropic.Model(g.options.Model),
+		MaxTokens: 1024,
+		Messages: []anthropic.MessageParam{
+			anthropic.NewUserMessage(blocks...),
+		},
+	}
+
+	rsp, err := g.client.Messages.New(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, content := range rsp.Content {
+		if text, ok := content.AsAny().(anthropic.TextBlock); ok {
+			b.WriteString(text.Text)
+		}
+	}
+
+	result := b.String()
+	if len(result) == 0 {
+		return "", errors.New("no response from Anthropic")
+	}
+
+	return result, nil
+}
+
+func (g *anthropicGenerator) Supports(mimeType string) bool {
+	return g.options.Accepts(mimeType, "image") && slices.Contains(imageFormats, mimeType)
+}
+
 func NewGenerator(opts ...generator.Option) generator.Generator {
 	options := generator.NewOptions(opts...)
 
@@ -0,0 +1,27 @@
+package anthropic_test
+
+import (
+	"cmp"
+	"os"
+	"testing"
+
+	"github.com/w-h-a/agent/generator"
+	"github.com/w-h-a/agent/generator/anthropic"
+	"github.com/w-h-a/agent/generator/generatortest"
+)
+
+func TestConformance(t *testing.T) {
+	if os.Getenv("INTEGRATION") != "1" {
+		t.Skip("set INTEGRATION=1 to run against the hosted model")
+	}
+
+	apiKey := os.Getenv("ANTHROPIC_API_KEY")
+	if len(apiKey) == 0 {
+		t.Skip("set ANTHROPIC_API_KEY to run against the hosted model")
+	}
+
+	generatortest.Run(t, anthropic.NewGenerator(
+		generator.WithApiKey(apiKey),
+		generator.WithModel(cmp.Or(os.Getenv("ANTHROPIC_MODEL"), "claude-haiku-4-5")),
+	))
+}
@@ -0,0 +1,93 @@
+package generatortest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/w-h-a/agent/generator"
+)
+
+// Run checks g against what every generator must do. The checks call the real
+// model, so keep the prompts short.
+func Run(t *testing.T, g generator.Generator) {
+	for _, c := range []struct {
+		name string
+		run  func(ctx context.Context, g generator.Generator) error
+	}{
+		{"generate", generate},
+		{"follows the prompt", followsPrompt},
+		{"concurrent", concurrent},
+		{"cancelled", cancelled},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.run(t.Context(), g); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func generate(ctx context.Context, g generator.Generator) error {
+	reply, err := g.Generate(ctx, "Say hello in one short sentence.")
+	if err != nil {
+		return fmt.Errorf("generate: %w", err)
+	}
+
+	if len(strings.TrimSpace(reply)) == 0 {
+		return errors.New("generate: empty reply")
+	}
+
+	return nil
+}
+
+func followsPrompt(ctx context.Context, g generator.Generator) error {
+	reply, err := g.Generate(ctx, "Reply with the single word PONG and nothing else.")
+	if err != nil {
+		return fmt.Errorf("generate: %w", err)
+	}
+
+	if !strings.Contains(strings.ToUpper(reply), "PONG") {
+		return fmt.Errorf("generate: want PONG, got %q", reply)
+	}
+
+	return nil
+}
+
+func concurrent(ctx context.Context, g generator.Generator) error {
+	const callers = 3
+
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+
+	for i := range callers {
+		wg.Go(func() {
+			if _, err := g.Generate(ctx, fmt.Sprintf("What is %d plus %d? Answer with the number only.", i, i)); err != nil {
+				errs <- err
+			}
+		})
+	}
+
+	wg.Wait()
+	close(errs)
+
+	if err := <-errs; err != nil {
+		return fmt.Errorf("concurrent generate: %w", err)
+	}
+
+	return nil
+}
+
+func cancelled(ctx context.Context, g generator.Generator) error {
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	if _, err := g.Generate(ctx, "Say hello."); err == nil {
+		return errors.New("generate: want an error for a cancelled context")
+	}
+
+	return nil
+}
@@ -3,6 +3,8 @@ package google
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/google/generative-ai-go/genai"
@@ -10,6 +12,11 @@ import (
 	genaiopt "google.golang.org/api/option"
 )
 
+var formats = []string{
+	"image/png", "image/jpeg", "image/webp", "image/heic", "image/heif",
+	"audio/wav", "audio/mpeg", "audio/aiff", "audio/aac", "audio/ogg", "audio/flac",
+}
+
 type googleGenerator struct {
 	options generator.Options
 	client  *genai.Client
@@ -43,6 +50,194 @@ func (g *googleGenerator) Generate(ctx context.Context, prompt string) (string,
 	return b.String(), nil
 }
 
+func (g *googleGenerator) GenerateStructured(ctx context.Context, prompt string, name string, schema map[string]any) (string, error) {
+	fullPrompt := prompt
+	if len(g.options.PromptPrefix) > 0 {
+		fullPrompt = g.options.PromptPrefix + "\n" + prompt
+	}
+
+	responseSchema, err := toSchema(schema, "$")
+	if err != nil {
+		return "", err
+	}
+
+	model := g.client.GenerativeModel(g.options.Model)
+	model.ResponseMIMEType = "application/json"
+	model.ResponseSchema = responseSchema
+
+	rsp, err := model.GenerateContent(ctx, genai.Text(fullPrompt))
+	if err != nil {
+		return "", err
+	}
+
+	if len(rsp.Candidates) == 0 || rsp.Candidates[0].Content == nil || len(rsp.Candidates[0].Content.Parts) == 0 {
+		return "", errors.New("no response from Google")
+	}
+
+	var b strings.Builder
+	for _, part := range rsp.Candidates[0].Content.Parts {
+		if text, ok := part.(genai.Text); ok {
+			b.WriteString(string(text))
+		}
+	}
+
+	return b.String(), nil
+}
+
+func (g *googleGenerator) GenerateWithAttachments(ctx context.Context, prompt string, attachments []generator.Attachment) (string, error) {
+	fullPrompt := prompt
+	if len(g.options.PromptPrefix) > 0 {
+		fullPrompt = g.options.PromptPrefix + "\n" + prompt
+	}
+
+	parts := []genai.Part{genai.Text(fullPrompt)}
+	for _, attachment := range attachments {
+		parts = append(parts, genai.Blob{MIMEType: attachment.MimeType, Data: attachment.Data})
+	}
+
+	model := g.client.GenerativeModel(g.options.Model)
+	rsp, err := model.GenerateContent(ctx, parts...)
+	if err != nil {
+		return "", err
+	}
+
+	if len(rsp.Candidates) == 0 || rsp.Candidates[0].Content == nil || len(rsp.Candidates[0].Content.Parts) == 0 {
+		return "", errors.New("no response from Google")
+	}
+
+	var b strings.Builder
+	for _, part := range rsp.Candidates[0].Content.Parts {
+		if text, ok := part.(genai.Text); ok {
+			b.WriteString(string(text))
+		}
+	}
+
+	return b.String(), nil
+}
+
+func (g *googleGenerator) Supports(mimeType string) bool {
+	return g.options.Accepts(mimeType, "image", "audio") && slices.Contains(formats, mimeType)
+}
+
+// toSchema converts a schema within Gemini's subset. Gemini rejects
+// open-ended schemas, such as maps, untyped values and unions beyond a
+// nullable type, so those are reported as unsupported rather than sent.
+func toSchema(schema map[string]any, path string) (*genai.Schema, error) {
+	for _, keyword := range []string{"anyOf", "oneOf", "allOf", "not", "$ref"} {
+		if _, ok := schema[keyword]; ok {
+			return nil, fmt.Errorf("%w: %s uses %s", generator.ErrUnsupportedSchema, path, keyword)
+		}
+	}
+
+	s := &genai.Schema{}
+
+	types := schemaTypes(schema["type"])
+	if i := slices.Index(types, "null"); i >= 0 {
+		s.Nullable = true
+		types = slices.Delete(types, i, i+1)
+	}
+
+	if len(types) != 1 {
+		return nil, fmt.Errorf("%w: %s has no single type", generator.ErrUnsupportedSchema, path)
+	}
+
+	switch types[0] {
+	case "string":
+		s.Type = genai.TypeString
+	case "number":
+		s.Type = genai.TypeNumber
+	case "integer":
+		s.Type = genai.TypeInteger
+	case "boolean":
+		s.Type = genai.TypeBoolean
+	case "array":
+		s.Type = genai.TypeArray
+	case "object":
+		s.Type = genai.TypeObject
+	default:
+		return nil, fmt.Errorf("%w: %s has type %s", generator.ErrUnsupportedSchema, path, types[0])
+	}
+
+	s.Format, _ = schema["format"].(string)
+	s.Description, _ = schema["description"].(string)
+
+	switch enum := schema["enum"].(type) {
+	case []string:
+		s.Enum = enum
+	case []any:
+		for _, e := range enum {
+			if v, ok := e.(string); ok {
+				s.Enum = append(s.Enum, v)
+			}
+		}
+	}
+
+	if s.Type == genai.TypeArray {
+		items, ok := schema["items"].(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s has no items", generator.ErrUnsupportedSchema, path)
+		}
+		converted, err := toSchema(items, path+"[]")
+		if err != nil {
+			return nil, err
+		}
+		s.Items = converted
+	}
+
+	if s.Type == genai.TypeObject {
+		if _, ok := schema["additionalProperties"].(map[string]any); ok {
+			return nil, fmt.Errorf("%w: %s has arbitrary keys", generator.ErrUnsupportedSchema, path)
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		if len(properties) == 0 {
+			return nil, fmt.Errorf("%w: %s has no properties", generator.ErrUnsupportedSchema, path)
+		}
+		s.Properties = map[string]*genai.Schema{}
+		for name, property := range properties {
+			p, ok := property.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%w: %s.%s is not a schema", generator.ErrUnsupportedSchema, path, name)
+			}
+			converted, err := toSchema(p, path+"."+name)
+			if err != nil {
+				return nil, err
+			}
+			s.Properties[name] = converted
+		}
+	}
+
+	switch required := schema["required"].(type) {
+	case []string:
+		s.Required = required
+	case []any:
+		for _, r := range required {
+			if v, ok := r.(string); ok {
+				s.Required = append(s.Required, v)
+			}
+		}
+	}
+
+	return s, nil
+}
+
+func schemaTypes(v any) []string {
+	switch t := v.(type) {
+	case string:
+		return []string{t}
+	case []string:
+		return slices.Clone(t)
+	case []any:
+		types := make([]string, 0, len(t))
+		for _, e := range t {
+			if s, ok := e.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
 func NewGenerator(opts ...generator.Option) generator.Generator {
 	options := generator.NewOptions(opts...)
 
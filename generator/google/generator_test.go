@@ -0,0 +1,27 @@
+package google_test
+
+import (
+	"cmp"
+	"os"
+	"testing"
+
+	"github.com/w-h-a/agent/generator"
+	"github.com/w-h-a/agent/generator/generatortest"
+	"github.com/w-h-a/agent/generator/google"
+)
+
+func TestConformance(t *testing.T) {
+	if os.Getenv("INTEGRATION") != "1" {
+		t.Skip("set INTEGRATION=1 to run against the hosted model")
+	}
+
+	apiKey := os.Getenv("GOOGLE_API_KEY")
+	if len(apiKey) == 0 {
+		t.Skip("set GOOGLE_API_KEY to run against the hosted model")
+	}
+
+	generatortest.Run(t, google.NewGenerator(
+		generator.WithApiKey(apiKey),
+		generator.WithModel(cmp.Or(os.Getenv("GOOGLE_MODEL"), "gemini-2.0-flash")),
+	))
+}
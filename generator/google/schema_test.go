@@ -0,0 +1,78 @@
+package google
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/generative-ai-go/genai"
+	"github.com/w-h-a/agent/generator"
+	jsonschema "github.com/w-h-a/agent/util/json_schema"
+)
+
+func TestToSchemaConvertsStructs(t *testing.T) {
+	schema, err := jsonschema.For(struct {
+		Name  string   `json:"name"`
+		Tags  []string `json:"tags"`
+		Score *float64 `json:"score,omitempty"`
+	}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := toSchema(schema, "$")
+	if err != nil {
+		t.Fatalf("toSchema: %v", err)
+	}
+
+	if s.Type != genai.TypeObject || len(s.Properties) != 3 {
+		t.Fatalf("want an object with 3 properties, got %+v", s)
+	}
+
+	if tags := s.Properties["tags"]; tags.Type != genai.TypeArray || tags.Items.Type != genai.TypeString {
+		t.Fatalf("want tags to be an array of strings, got %+v", tags)
+	}
+}
+
+func TestToSchemaConvertsNullableTypes(t *testing.T) {
+	s, err := toSchema(map[string]any{"type": []any{"string", "null"}}, "$")
+	if err != nil {
+		t.Fatalf("toSchema: %v", err)
+	}
+
+	if s.Type != genai.TypeString || !s.Nullable {
+		t.Fatalf("want a nullable string, got %+v", s)
+	}
+}
+
+func TestToSchemaReportsUnsupportedSchemas(t *testing.T) {
+	withMap, err := jsonschema.For(struct {
+		Labels map[string]string `json:"labels"`
+	}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withAny, err := jsonschema.For(struct {
+		Value any `json:"value"`
+	}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]map[string]any{
+		"map":   withMap,
+		"any":   withAny,
+		"empty": {},
+		"union": {"type": []any{"string", "integer"}},
+		"array": {"type": "array"},
+		"anyOf": {"anyOf": []any{map[string]any{"type": "string"}}},
+	}
+
+	for name, schema := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := toSchema(schema, "$"); !errors.Is(err, generator.ErrUnsupportedSchema) {
+				t.Fatalf("want ErrUnsupportedSchema, got %v", err)
+			}
+		})
+	}
+}
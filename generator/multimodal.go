@@ -0,0 +1,18 @@
+package generator
+
+import "context"
+
+// Attachment is a file sent to the model along with the prompt
+type Attachment struct {
+	Name     string
+	MimeType string
+	Data     []byte
+}
+
+// MultimodalGenerator is implemented by generators whose model can read
+// attachments as native content rather than as text
+type MultimodalGenerator interface {
+	GenerateWithAttachments(ctx context.Context, prompt string, attachments []Attachment) (string, error)
+	// Supports reports whether attachments of the MIME type can be sent
+	Supports(mimeType string) bool
+}
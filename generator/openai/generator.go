@@ -2,12 +2,17 @@ package openai
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"slices"
 
 	"github.com/sashabaranov/go-openai"
 	"github.com/w-h-a/agent/generator"
 )
 
+var imageFormats = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
 type openAIGenerator struct {
 	options generator.Options
 	client  *openai.Client
@@ -41,6 +46,91 @@ func (g *openAIGenerator) Generate(ctx context.Context, prompt string) (string,
 	return rsp.Choices[0].Message.Content, nil
 }
 
+func (g *openAIGenerator) GenerateStructured(ctx context.Context, prompt string, name string, schema map[string]any) (string, error) {
+	fullPrompt := prompt
+	if len(g.options.PromptPrefix) > 0 {
+		fullPrompt = g.options.PromptPrefix + "\n" + prompt
+	}
+
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		return "", err
+	}
+
+	req := openai.ChatCompletionRequest{
+		Model: g.options.Model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: fullPrompt,
+			},
+		},
+		ResponseFormat: &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+			JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+				Name:   name,
+				Schema: json.RawMessage(raw),
+			},
+		},
+	}
+
+	rsp, err := g.client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	if len(rsp.Choices) == 0 || len(rsp.Choices[0].Message.Content) == 0 {
+		return "", errors.New("no response from OpenAI")
+	}
+
+	return rsp.Choices[0].Message.Content, nil
+}
+
+func (g *openAIGenerator) GenerateWithAttachments(ctx context.Context, prompt string, attachments []generator.Attachment) (string, error) {
+	fullPrompt := prompt
+	if len(g.options.PromptPrefix) > 0 {
+		fullPrompt = g.options.PromptPrefix + "\n" + prompt
+	}
+
+	parts := []openai.ChatMessagePart{
+		{Type: openai.ChatMessagePartTypeText, Text: fullPrompt},
+	}
+
+	for _, attachment := range attachments {
+		parts = append(parts, openai.ChatMessagePart{
+			Type: openai.ChatMessagePartTypeImageURL,
+			ImageURL: &openai.ChatMessageImageURL{
+				URL: "data:" + attachment.MimeType + ";base64," + base64.StdEncoding.EncodeToString(attachment.Data),
+			},
+		})
+	}
+
+	req := openai.ChatCompletionRequest{
+		Model: g.options.Model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:         openai.ChatMessageRoleUser,
+				MultiContent: parts,
+			},
+		},
+	}
+
+	rsp, err := g.client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	if len(rsp.Choices) == 0 || len(rsp.Choices[0].Message.Content) == 0 {
+		return "", errors.New("no response from OpenAI")
+	}
+
+	return rsp.Choices[0].Message.Content, nil
+}
+
+func (g *openAIGenerator) Supports(mimeType string) bool {
+	return g.options.Accepts(mimeType, "image") && slices.Contains(imageFormats, mimeType)
+}
+
 func NewGenerator(opts ...generator.Option) generator.Generator {
 	options := generator.NewOptions(opts...)
 
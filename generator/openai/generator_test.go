@@ -0,0 +1,27 @@
+package openai_test
+
+import (
+	"cmp"
+	"os"
+	"testing"
+
+	"github.com/w-h-a/agent/generator"
+	"github.com/w-h-a/agent/generator/generatortest"
+	"github.com/w-h-a/agent/generator/openai"
+)
+
+func TestConformance(t *testing.T) {
+	if os.Getenv("INTEGRATION") != "1" {
+		t.Skip("set INTEGRATION=1 to run against the hosted model")
+	}
+
+	apiKey := os.Getenv("OPENAI_API_KEY")
+	if len(apiKey) == 0 {
+		t.Skip("set OPENAI_API_KEY to run against the hosted model")
+	}
+
+	generatortest.Run(t, openai.NewGenerator(
+		generator.WithApiKey(apiKey),
+		generator.WithModel(cmp.Or(os.Getenv("OPENAI_MODEL"), "gpt-4o-mini")),
+	))
+}
@@ -1,6 +1,10 @@
 package generator
 
-import "context"
+import (
+	"context"
+	"slices"
+	"strings"
+)
 
 type Option func(*Options)
 
@@ -8,7 +12,21 @@ type Options struct {
 	ApiKey       string
 	Model        string
 	PromptPrefix string
-	Context      context.Context
+	// Modalities lists the kinds of attachment, such as image or audio, the
+	// model reads. Nil keeps the provider's defaults and empty means text only.
+	Modalities []string
+	Context    context.Context
+}
+
+// Accepts reports whether the model reads the MIME type's modality,
+// falling back to the provider's defaults when none were configured
+func (o Options) Accepts(mimeType string, defaults ...string) bool {
+	modalities := o.Modalities
+	if modalities == nil {
+		modalities = defaults
+	}
+	modality, _, _ := strings.Cut(mimeType, "/")
+	return slices.Contains(modalities, modality)
 }
 
 func WithApiKey(apiKey string) Option {
@@ -29,6 +47,12 @@ func WithPromptPrefix(prefix string) Option {
 	}
 }
 
+func WithModalities(modalities ...string) Option {
+	return func(o *Options) {
+		o.Modalities = append([]string{}, modalities...)
+	}
+}
+
 func NewOptions(opts ...Option) Options {
 	options := Options{
 		Context: context.Background(),
@@ -0,0 +1,16 @@
+package generator
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrUnsupportedSchema is returned by GenerateStructured, before anything
+// is sent, when the provider's structured mode cannot express the schema
+var ErrUnsupportedSchema = errors.New("schema is not supported by structured mode")
+
+// StructuredGenerator is implemented by generators whose provider can
+// constrain a reply to a JSON schema. The reply is the JSON document.
+type StructuredGenerator interface {
+	GenerateStructured(ctx context.Context, prompt string, name string, schema map[string]any) (string, error)
+}
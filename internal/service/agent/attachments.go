@@ -0,0 +1,167 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/w-h-a/agent/generator"
+	memorymanager "github.com/w-h-a/agent/memory_manager"
+)
+
+const (
+	// maxBufferedAttachment bounds how much of a file is held in memory to
+	// show the model. Larger files are still stored whole.
+	maxBufferedAttachment = 20 << 20
+	maxInlineText         = 8 << 10
+)
+
+type attachment struct {
+	generator.Attachment
+	truncated bool
+}
+
+type attachmentsKey struct{}
+
+// Attach reads the user's files so they can be shown to the model as well
+// as stored in memory. The files returned replay what was read and should
+// be passed on in place of the originals.
+func Attach(ctx context.Context, files map[string]memorymanager.InputFile) (context.Context, map[string]memorymanager.InputFile, error) {
+	fields := make([]string, 0, len(files))
+	for field := range files {
+		fields = append(fields, field)
+	}
+	slices.Sort(fields)
+
+	attachments := make([]attachment, 0, len(files))
+	replayed := make(map[string]memorymanager.InputFile, len(files))
+
+	for _, field := range fields {
+		file := files[field]
+
+		if file.Reader == nil {
+			replayed[field] = file
+			continue
+		}
+
+		data, err := io.ReadAll(io.LimitReader(file.Reader, maxBufferedAttachment+1))
+		if err != nil {
+			return ctx, nil, fmt.Errorf("failed to read %s: %w", file.Name, err)
+		}
+
+		// only the model's copy is cut; memory is replayed every byte read
+		shown := data
+		truncated := len(shown) > maxBufferedAttachment
+		if truncated {
+			shown = shown[:maxBufferedAttachment]
+		}
+
+		if len(file.MimeType) == 0 {
+			file.MimeType = memorymanager.DetectMimeType(file.Name, shown)
+		}
+
+		attachments = append(attachments, attachment{
+			Attachment: generator.Attachment{Name: file.Name, MimeType: file.MimeType, Data: shown},
+			truncated:  truncated,
+		})
+
+		file.Reader = io.MultiReader(bytes.NewReader(data), file.Reader)
+		replayed[field] = file
+	}
+
+	// set even when empty so a sub-agent does not see its caller's files
+	return context.WithValue(ctx, attachmentsKey{}, attachments), replayed, nil
+}
+
+func attachmentsFrom(ctx context.Context) []attachment {
+	attachments, _ := ctx.Value(attachmentsKey{}).([]attachment)
+	return attachments
+}
+
+// generateWith sends the current message's attachments natively where gen
+// supports them and describes the rest in the prompt
+func (s *Service) generateWith(ctx context.Context, m *meter, gen generator.Generator, prompt string) (string, error) {
+	attachments := attachmentsFrom(ctx)
+	if len(attachments) == 0 {
+		content, err := gen.Generate(ctx, prompt)
+		if err != nil {
+			return "", err
+		}
+		m.add(Usage{Generations: 1, PromptTokens: estimateTokens(prompt), CompletionTokens: estimateTokens(content)})
+		return content, nil
+	}
+
+	mg, multimodal := gen.(generator.MultimodalGenerator)
+
+	var native []generator.Attachment
+	var sb strings.Builder
+	sb.WriteString(prompt)
+	sb.WriteString("\n\nAttachments to the current user message:\n")
+
+	for _, a := range attachments {
+		size := formatSize(len(a.Data))
+		if a.truncated {
+			size = "over " + size
+		}
+
+		fits := !a.truncated && int64(len(a.Data)) <= s.options.MaxAttachmentSize
+
+		switch {
+		case multimodal && fits && mg.Supports(a.MimeType):
+			native = append(native, a.Attachment)
+			sb.WriteString(fmt.Sprintf("- %s (%s, %s): shown to you with this prompt\n", a.Name, a.MimeType, size))
+		case isText(a.MimeType) && utf8.Valid(a.Data):
+			text := string(a.Data)
+			if len(text) > maxInlineText {
+				text = strings.ToValidUTF8(text[:maxInlineText], "") + "\n[truncated]"
+			}
+			sb.WriteString(fmt.Sprintf("- %s (%s, %s) contents:\n%s\n", a.Name, a.MimeType, size, text))
+		case multimodal && !fits && mg.Supports(a.MimeType):
+			sb.WriteString(fmt.Sprintf("- %s (%s, %s): too large to show you, only its name and type are known\n", a.Name, a.MimeType, size))
+		default:
+			sb.WriteString(fmt.Sprintf("- %s (%s, %s): you cannot view this kind of file, only its name and type are known\n", a.Name, a.MimeType, size))
+		}
+	}
+
+	full := sb.String()
+
+	var content string
+	var err error
+	if len(native) > 0 {
+		content, err = mg.GenerateWithAttachments(ctx, full, native)
+	} else {
+		content, err = gen.Generate(ctx, full)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	m.add(Usage{Generations: 1, PromptTokens: estimateTokens(full), CompletionTokens: estimateTokens(content)})
+
+	return content, nil
+}
+
+func isText(mimeType string) bool {
+	if strings.HasPrefix(mimeType, "text/") {
+		return true
+	}
+	switch mimeType {
+	case "application/json", "application/xml", "application/x-yaml", "application/yaml", "application/javascript":
+		return true
+	}
+	return false
+}
+
+func formatSize(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
@@ -0,0 +1,142 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/w-h-a/agent/router"
+	toolhandler "github.com/w-h-a/agent/tool_handler"
+)
+
+const handoffToolName = "handoff"
+
+// Handoff is returned by Respond when the agent passed the session to
+// another agent instead of answering. The caller should let that agent
+// resume the same user message.
+type Handoff struct {
+	Agent  string
+	Reason string
+}
+
+func (h *Handoff) Error() string {
+	return fmt.Sprintf("handed off to %s", h.Agent)
+}
+
+type handoffTarget struct {
+	agent  string
+	reason string
+}
+
+type handoff struct {
+	target *handoffTarget
+	mtx    sync.Mutex
+}
+
+func (h *handoff) request(agent string, reason string) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.target = &handoffTarget{agent: agent, reason: reason}
+}
+
+func (h *handoff) requested() (handoffTarget, bool) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	if h.target == nil {
+		return handoffTarget{}, false
+	}
+	return *h.target, true
+}
+
+type handoffKey struct{}
+
+func withHandoff(ctx context.Context, h *handoff) context.Context {
+	return context.WithValue(ctx, handoffKey{}, h)
+}
+
+func handoffFrom(ctx context.Context) (*handoff, bool) {
+	h, ok := ctx.Value(handoffKey{}).(*handoff)
+	return h, ok
+}
+
+type handoffToolHandler struct {
+	routes []router.Route
+	spec   toolhandler.ToolSpec
+}
+
+func (th *handoffToolHandler) Spec() toolhandler.ToolSpec {
+	return th.spec
+}
+
+func (th *handoffToolHandler) Invoke(ctx context.Context, req toolhandler.ToolRequest) (toolhandler.ToolResponse, error) {
+	name, _ := req.Arguments["agent"].(string)
+	if len(name) == 0 {
+		name, _ = req.Arguments["input"].(string)
+	}
+	name = strings.TrimSpace(name)
+
+	reason, _ := req.Arguments["reason"].(string)
+
+	h, ok := handoffFrom(ctx)
+	if !ok {
+		return toolhandler.ToolResponse{}, errors.New("handoff is only possible while responding")
+	}
+
+	for _, route := range th.routes {
+		if strings.EqualFold(route.Name, name) {
+			h.request(route.Name, strings.TrimSpace(reason))
+			return toolhandler.ToolResponse{
+				Content:  fmt.Sprintf("conversation handed off to %s", route.Name),
+				Metadata: map[string]string{"handoff": route.Name},
+			}, nil
+		}
+	}
+
+	return toolhandler.ToolResponse{}, fmt.Errorf("unknown agent: %s", name)
+}
+
+func newHandoffToolHandler(routes []router.Route) *handoffToolHandler {
+	names := make([]string, 0, len(routes))
+
+	var sb strings.Builder
+	sb.WriteString("Transfers the conversation to another agent, who answers this message and the ones that follow. Agents:")
+	for _, route := range routes {
+		names = append(names, route.Name)
+		sb.WriteString(fmt.Sprintf(" %s (%s);", route.Name, route.Description))
+	}
+
+	return &handoffToolHandler{
+		routes: routes,
+		spec: toolhandler.ToolSpec{
+			Name:        handoffToolName,
+			Description: strings.TrimSuffix(sb.String(), ";"),
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"agent": map[string]any{
+						"type": "string",
+						"enum": names,
+					},
+					"reason": map[string]any{
+						"type":        "string",
+						"description": "Why the other agent is better placed to help.",
+					},
+				},
+				"required": []string{"agent"},
+			},
+		},
+	}
+}
+
+// SetHandoffs lets the agent hand the session to any of routes. Calling it
+// again replaces the agents on offer.
+func (s *Service) SetHandoffs(routes []router.Route) {
+	if len(routes) == 0 {
+		s.catalog.Remove(handoffToolName)
+		return
+	}
+
+	s.catalog.Set(newHandoffToolHandler(routes))
+}
@@ -0,0 +1,95 @@
+package agent
+
+import "github.com/w-h-a/agent/generator"
+
+// Strategy is how an agent works a user message
+type Strategy string
+
+const (
+	// StrategyLoop answers in a single generate and call tools loop
+	StrategyLoop Strategy = "loop"
+	// StrategyPlanExecute plans the message into steps, works each step
+	// in turn and replans when one fails
+	StrategyPlanExecute Strategy = "plan_execute"
+)
+
+type Option func(o *Options)
+
+type Options struct {
+	Strategy   Strategy
+	MaxSteps   int
+	MaxReplans int
+	// ReflectionRounds is how many times a final answer may be critiqued
+	// and revised; 0 returns the first draft as is
+	ReflectionRounds int
+	// Critic reviews drafts instead of the agent's own generator
+	Critic generator.Generator
+	// MaxRepairs caps how many times a JSON reply that does not conform to
+	// its schema is sent back to be fixed
+	MaxRepairs int
+	// MaxAttachmentSize is the largest file, in bytes, sent to the model as
+	// native content. Larger files are only described.
+	MaxAttachmentSize int64
+}
+
+func WithStrategy(strategy Strategy) Option {
+	return func(o *Options) {
+		o.Strategy = strategy
+	}
+}
+
+// WithMaxSteps caps how many steps a single plan may have
+func WithMaxSteps(n int) Option {
+	return func(o *Options) {
+		o.MaxSteps = n
+	}
+}
+
+// WithMaxReplans caps how many times failed steps are planned around
+// before the response gives up
+func WithMaxReplans(n int) Option {
+	return func(o *Options) {
+		o.MaxReplans = n
+	}
+}
+
+func WithReflection(rounds int) Option {
+	return func(o *Options) {
+		o.ReflectionRounds = rounds
+	}
+}
+
+func WithCritic(critic generator.Generator) Option {
+	return func(o *Options) {
+		o.Critic = critic
+	}
+}
+
+func WithMaxRepairs(n int) Option {
+	return func(o *Options) {
+		o.MaxRepairs = n
+	}
+}
+
+func WithMaxAttachmentSize(n int64) Option {
+	return func(o *Options) {
+		o.MaxAttachmentSize = n
+	}
+}
+
+func NewOptions(opts ...Option) Options {
+	options := Options{
+		Strategy:   StrategyLoop,
+		MaxSteps:   8,
+		MaxReplans: 2,
+		MaxRepairs: 2,
+		// the smallest of the providers' per image limits
+		MaxAttachmentSize: 5 << 20,
+	}
+
+	for _, fn := range opts {
+		fn(&options)
+	}
+
+	return options
+}
@@ -0,0 +1,300 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	memorymanager "github.com/w-h-a/agent/memory_manager"
+	extractjson "github.com/w-h-a/agent/util/extract_json"
+)
+
+const (
+	TaskPending    = "pending"
+	TaskInProgress = "in_progress"
+	TaskDone       = "done"
+	TaskFailed     = "failed"
+)
+
+const stepFailedPrefix = "step failed:"
+
+// step is the data of a planned task
+type step struct {
+	Step   string `json:"step"`
+	Result string `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type planTask struct {
+	task memorymanager.Task
+	step step
+}
+
+// plan tracks the steps of one response. They are kept as the session's
+// tasks when the memory manager can write them.
+type plan struct {
+	sessionId string
+	tasks     []*planTask
+	order     int
+	store     memorymanager.TaskManager
+}
+
+func (p *plan) add(ctx context.Context, steps []step) error {
+	for _, st := range steps {
+		p.order++
+		t := &planTask{
+			task: memorymanager.Task{SessionId: p.sessionId, TaskOrder: p.order, Status: TaskPending},
+			step: st,
+		}
+		if err := p.save(ctx, t); err != nil {
+			return err
+		}
+		p.tasks = append(p.tasks, t)
+	}
+	return nil
+}
+
+func (p *plan) mark(ctx context.Context, t *planTask, status string) error {
+	t.task.Status = status
+	return p.save(ctx, t)
+}
+
+func (p *plan) save(ctx context.Context, t *planTask) error {
+	data, err := json.Marshal(t.step)
+	if err != nil {
+		return err
+	}
+	t.task.Data = data
+
+	if p.store == nil {
+		return nil
+	}
+
+	saved, err := p.store.UpsertTask(ctx, p.sessionId, t.task)
+	if err != nil {
+		return fmt.Errorf("failed to save task: %w", err)
+	}
+	t.task = saved
+
+	return nil
+}
+
+// dropPending discards the steps a replan supersedes
+func (p *plan) dropPending(ctx context.Context) error {
+	kept := p.tasks[:0]
+	for _, t := range p.tasks {
+		if t.task.Status != TaskPending {
+			kept = append(kept, t)
+			continue
+		}
+		if p.store != nil {
+			if err := p.store.DeleteTask(ctx, p.sessionId, t.task.Id); err != nil {
+				return fmt.Errorf("failed to delete task: %w", err)
+			}
+		}
+	}
+	p.tasks = kept
+	return nil
+}
+
+func (p *plan) String() string {
+	var sb strings.Builder
+	for i, t := range p.tasks {
+		sb.WriteString(fmt.Sprintf("%d. [%s] %s\n", i+1, t.task.Status, t.step.Step))
+		if len(t.step.Result) > 0 {
+			sb.WriteString(fmt.Sprintf("   Result: %s\n", t.step.Result))
+		}
+		if len(t.step.Error) > 0 {
+			sb.WriteString(fmt.Sprintf("   Error: %s\n", t.step.Error))
+		}
+	}
+	return sb.String()
+}
+
+func (s *Service) planAndExecute(ctx context.Context, m *meter, sessionId string, userInput string) (string, error) {
+	p, err := s.executePlan(ctx, m, sessionId, userInput)
+	if err != nil {
+		return "", err
+	}
+
+	// nothing worth planning is answered directly
+	if p == nil {
+		return s.loop(ctx, m, sessionId, userInput)
+	}
+
+	base, err := s.buildContext(ctx, sessionId, userInput)
+	if err != nil {
+		return "", err
+	}
+
+	answer, err := s.generate(ctx, m, base+fmt.Sprintf(
+		"\n\nPlan:\n%s\nCompose the best possible assistant reply to the current user message from the results of the steps above.\n",
+		p.String(),
+	))
+	if err != nil {
+		return "", err
+	}
+
+	return s.answer(ctx, m, sessionId, userInput, answer)
+}
+
+// executePlan plans the message and works each step, replanning when a
+// step fails. There is no plan when the message is not worth planning.
+func (s *Service) executePlan(ctx context.Context, m *meter, sessionId string, userInput string) (*plan, error) {
+	p := &plan{sessionId: sessionId}
+	if store, ok := s.memory.(memorymanager.TaskManager); ok {
+		p.store = store
+	}
+
+	base, err := s.buildContext(ctx, sessionId, userInput)
+	if err != nil {
+		return nil, err
+	}
+
+	steps, err := s.plan(ctx, m, base+fmt.Sprintf(
+		"\n\nBefore answering, break the current user message into at most %d short, concrete steps that can each be completed with the available tools or your own knowledge. Reply with only a JSON array such as [{\"step\": \"...\"}].\n",
+		s.options.MaxSteps,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(steps) == 0 {
+		return nil, nil
+	}
+
+	if err := p.add(ctx, steps); err != nil {
+		return nil, err
+	}
+
+	replans := 0
+
+	for i := 0; i < len(p.tasks); i++ {
+		t := p.tasks[i]
+		if t.task.Status != TaskPending {
+			continue
+		}
+
+		if err := p.mark(ctx, t, TaskInProgress); err != nil {
+			return nil, err
+		}
+
+		result, err := s.executeStep(ctx, m, p, t, userInput)
+		if err == nil {
+			t.step.Result = result
+			if err := p.mark(ctx, t, TaskDone); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		var handoff *Handoff
+		if errors.As(err, &handoff) || ctx.Err() != nil {
+			return nil, err
+		}
+
+		t.step.Error = err.Error()
+		if err := p.mark(ctx, t, TaskFailed); err != nil {
+			return nil, err
+		}
+
+		if replans >= s.options.MaxReplans {
+			return nil, fmt.Errorf("step %q failed after %d replans: %w", t.step.Step, replans, err)
+		}
+		replans++
+
+		slog.InfoContext(ctx, "replanning after failed step", "session", sessionId, "step", t.step.Step, "error", err)
+
+		base, err := s.buildContext(ctx, sessionId, userInput)
+		if err != nil {
+			return nil, err
+		}
+
+		steps, err := s.plan(ctx, m, base+fmt.Sprintf(
+			"\n\nPlan so far:\n%s\nStep %q failed. Plan the remaining work again in at most %d steps, avoiding what failed. Reply with only a JSON array of the steps still to do such as [{\"step\": \"...\"}], or [] when the completed steps are enough to answer.\n",
+			p.String(), t.step.Step, s.options.MaxSteps,
+		))
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.dropPending(ctx); err != nil {
+			return nil, err
+		}
+
+		if err := p.add(ctx, steps); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+func (s *Service) plan(ctx context.Context, m *meter, prompt string) ([]step, error) {
+	content, err := s.generate(ctx, m, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	var planned []step
+	if err := extractjson.Into(content, &planned); err != nil {
+		return nil, fmt.Errorf("failed to parse plan: %w", err)
+	}
+
+	steps := make([]step, 0, len(planned))
+	for _, st := range planned {
+		st.Step = strings.TrimSpace(st.Step)
+		if len(st.Step) == 0 {
+			continue
+		}
+		steps = append(steps, step{Step: st.Step})
+		if len(steps) == s.options.MaxSteps {
+			break
+		}
+	}
+
+	return steps, nil
+}
+
+// executeStep works a single step with the tool loop. The model gives up
+// on a step by replying with the step failed prefix.
+func (s *Service) executeStep(ctx context.Context, m *meter, p *plan, t *planTask, userInput string) (string, error) {
+	for range s.maxTurns {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
+		base, err := s.buildContext(ctx, p.sessionId, userInput)
+		if err != nil {
+			return "", err
+		}
+
+		prompt := base + fmt.Sprintf(
+			"\n\nPlan:\n%s\nCurrent step: %s\nComplete only this step. Call a tool if it helps, otherwise reply with the step's result. If the step cannot be completed, reply with `%s <reason>`.\n",
+			p.String(), t.step.Step, stepFailedPrefix,
+		)
+
+		content, done, err := s.turn(ctx, m, p.sessionId, prompt)
+		if err != nil {
+			return "", err
+		}
+
+		if !done {
+			continue
+		}
+
+		s.addShortTerm(ctx, p.sessionId, "assistant", content, nil, nil)
+
+		trimmed := strings.TrimSpace(content)
+		if strings.HasPrefix(strings.ToLower(trimmed), stepFailedPrefix) {
+			return "", errors.New(strings.TrimSpace(trimmed[len(stepFailedPrefix):]))
+		}
+
+		return trimmed, nil
+	}
+
+	return "", fmt.Errorf("step exceeded max turns (%d) without a result", s.maxTurns)
+}
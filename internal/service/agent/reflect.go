@@ -0,0 +1,105 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	memorymanager "github.com/w-h-a/agent/memory_manager"
+	extractjson "github.com/w-h-a/agent/util/extract_json"
+)
+
+type critique struct {
+	Approved bool     `json:"approved"`
+	Issues   []string `json:"issues"`
+}
+
+func (c critique) String() string {
+	if c.Approved {
+		return "Reflection: the draft answer was approved."
+	}
+	return "Reflection: the draft answer needs revision.\n- " + strings.Join(c.Issues, "\n- ")
+}
+
+// answer records the reviewed draft as the assistant's reply
+func (s *Service) answer(ctx context.Context, m *meter, sessionId string, userInput string, draft string) (string, error) {
+	final, err := s.reflect(ctx, m, sessionId, userInput, draft)
+	if err != nil {
+		return "", err
+	}
+
+	s.addShortTerm(ctx, sessionId, "assistant", final, nil, nil)
+
+	return final, nil
+}
+
+// reflect has the critic check a draft against what the agent knows and
+// revises it until approved or out of rounds. Critiques and the drafts
+// they reject are remembered tagged as reflection.
+func (s *Service) reflect(ctx context.Context, m *meter, sessionId string, userInput string, draft string) (string, error) {
+	critic := s.options.Critic
+	if critic == nil {
+		critic = s.generator
+	}
+
+	for round := 1; round <= s.options.ReflectionRounds; round++ {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
+		base, err := s.buildContext(ctx, sessionId, userInput)
+		if err != nil {
+			return "", err
+		}
+
+		prompt := base + fmt.Sprintf(
+			"\n\nDraft answer:\n%s\n\nReview the draft answer to the current user message. Check it against the tool results, memories and conversation above: flag claims they do not support and requirements of the message it leaves unmet. Reply with only JSON such as {\"approved\": false, \"issues\": [\"...\"]}, approving when there is nothing to fix.\n",
+			strings.TrimSpace(draft),
+		)
+
+		content, err := s.generateWith(ctx, m, critic, prompt)
+		if err != nil {
+			return "", err
+		}
+
+		var c critique
+		if err := extractjson.Into(content, &c); err != nil {
+			// a critic that cannot be understood should not block the answer
+			slog.WarnContext(ctx, "failed to parse critique", "session", sessionId, "error", err)
+			return draft, nil
+		}
+
+		if !c.Approved && len(c.Issues) == 0 {
+			c.Approved = true
+		}
+
+		s.addShortTerm(ctx, sessionId, "system", c.String(), nil, map[string]any{
+			"source":   memorymanager.SourceReflection,
+			"round":    round,
+			"approved": c.Approved,
+		})
+
+		if c.Approved {
+			return draft, nil
+		}
+
+		s.addShortTerm(ctx, sessionId, "assistant", draft, nil, map[string]any{
+			"source": memorymanager.SourceReflection,
+			"round":  round,
+			"draft":  true,
+		})
+
+		revised, err := s.generate(ctx, m, base+fmt.Sprintf(
+			"\n\nDraft answer:\n%s\n\nA reviewer found these issues with the draft:\n- %s\n\nRewrite the draft answer to the current user message so the issues are fixed. Reply with only the revised answer.\n",
+			strings.TrimSpace(draft), strings.Join(c.Issues, "\n- "),
+		))
+		if err != nil {
+			return "", err
+		}
+
+		draft = revised
+	}
+
+	return draft, nil
+}
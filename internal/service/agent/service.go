@@ -26,6 +26,9 @@ type Service struct {
 	contextLimit       int
 	linkedMemoriesHops int
 	systemPrompt       string
+	options            Options
+	usage              map[string]Usage
+	mtx                sync.RWMutex
 }
 
 func (s *Service) Respond(ctx context.Context, sessionId string, userInput string, files map[string]memorymanager.InputFile) (string, error) {
@@ -35,39 +38,160 @@ func (s *Service) Respond(ctx context.Context, sessionId string, userInput strin
 
 	s.addShortTerm(ctx, sessionId, "user", userInput, files, nil)
 
+	return s.respond(ctx, sessionId, userInput, nil)
+}
+
+// RespondJSON is Respond for callers that consume the reply as data. The
+// reply is a JSON document conforming to schema.
+func (s *Service) RespondJSON(ctx context.Context, sessionId string, userInput string, files map[string]memorymanager.InputFile, schema map[string]any) (string, error) {
+	if len(strings.TrimSpace(userInput)) == 0 {
+		return "", errors.New("user input is required")
+	}
+
+	if len(schema) == 0 {
+		return "", errors.New("schema is required")
+	}
+
+	s.addShortTerm(ctx, sessionId, "user", userInput, files, nil)
+
+	return s.respond(ctx, sessionId, userInput, schema)
+}
+
+// Resume answers a user message another agent already recorded, as when
+// the session is handed off to this agent mid turn
+func (s *Service) Resume(ctx context.Context, sessionId string, userInput string) (string, error) {
+	if len(strings.TrimSpace(userInput)) == 0 {
+		return "", errors.New("user input is required")
+	}
+
+	return s.respond(ctx, sessionId, userInput, nil)
+}
+
+func (s *Service) ResumeJSON(ctx context.Context, sessionId string, userInput string, schema map[string]any) (string, error) {
+	if len(strings.TrimSpace(userInput)) == 0 {
+		return "", errors.New("user input is required")
+	}
+
+	if len(schema) == 0 {
+		return "", errors.New("schema is required")
+	}
+
+	return s.respond(ctx, sessionId, userInput, schema)
+}
+
+func (s *Service) respond(ctx context.Context, sessionId string, userInput string, schema map[string]any) (string, error) {
+	h := &handoff{}
+	ctx = withHandoff(ctx, h)
+
+	// usage of any sub-agent called from here rolls up into this response
+	m := &meter{}
+	parent, nested := meterFrom(ctx)
+	ctx = withMeter(context.WithValue(ctx, depthKey{}, Depth(ctx)+1), m)
+
+	defer func() {
+		u := m.total()
+		s.recordUsage(sessionId, u)
+		if nested {
+			parent.add(u)
+		}
+	}()
+
+	if schema != nil {
+		return s.structured(ctx, m, sessionId, userInput, schema)
+	}
+
+	return s.work(ctx, m, sessionId, userInput)
+}
+
+func (s *Service) work(ctx context.Context, m *meter, sessionId string, userInput string) (string, error) {
+	if s.options.Strategy == StrategyPlanExecute {
+		return s.planAndExecute(ctx, m, sessionId, userInput)
+	}
+
+	return s.loop(ctx, m, sessionId, userInput)
+}
+
+func (s *Service) loop(ctx context.Context, m *meter, sessionId string, userInput string) (string, error) {
 	for range s.maxTurns {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
 		prompt, err := s.buildPrompt(ctx, sessionId, userInput)
 		if err != nil {
 			return "", err
 		}
 
-		content, err := s.generator.Generate(ctx, prompt)
+		content, done, err := s.turn(ctx, m, sessionId, prompt)
 		if err != nil {
 			return "", err
 		}
 
-		s.addShortTerm(ctx, sessionId, "assistant", content, nil, nil)
-
-		isTool, output, metadata, err := s.executeTool(ctx, sessionId, content)
-		if !isTool {
-			return content, nil
-		}
-		if err != nil {
-			s.addShortTerm(ctx, sessionId, "system", fmt.Sprintf("Tool execution failed: %v", err), nil, map[string]any{"source": "tool_error"})
-			continue
+		if done {
+			return s.answer(ctx, m, sessionId, userInput, content)
 		}
+	}
 
-		extra := map[string]any{"source": "tool"}
-		for k, v := range metadata {
-			if len(strings.TrimSpace(k)) > 0 {
-				extra[k] = v
-			}
+	return "", fmt.Errorf("agent exceeded max turns (%d) without final response", s.maxTurns)
+}
+
+// turn generates one reply and runs the tool it calls, if any. It is done
+// when the reply calls no tool; otherwise the model should be asked again.
+// A reply that calls no tool is left for the caller to record.
+func (s *Service) turn(ctx context.Context, m *meter, sessionId string, prompt string) (string, bool, error) {
+	content, err := s.generate(ctx, m, prompt)
+	if err != nil {
+		return "", false, err
+	}
+
+	isTool, output, metadata, err := s.executeTool(ctx, sessionId, content)
+	if !isTool {
+		return content, true, nil
+	}
+
+	s.addShortTerm(ctx, sessionId, "assistant", content, nil, nil)
+
+	m.add(Usage{ToolCalls: 1})
+
+	if err != nil {
+		s.addShortTerm(ctx, sessionId, "system", fmt.Sprintf("Tool execution failed: %v", err), nil, map[string]any{"source": "tool_error"})
+		return "", false, nil
+	}
+
+	extra := map[string]any{"source": "tool"}
+	for k, v := range metadata {
+		if len(strings.TrimSpace(k)) > 0 {
+			extra[k] = v
 		}
+	}
+
+	s.addShortTerm(ctx, sessionId, "tool", output, nil, extra)
 
-		s.addShortTerm(ctx, sessionId, "tool", output, nil, extra)
+	if h, ok := handoffFrom(ctx); ok {
+		if target, ok := h.requested(); ok {
+			return "", false, &Handoff{Agent: target.agent, Reason: target.reason}
+		}
 	}
 
-	return "", fmt.Errorf("agent exceeded max turns (%d) without final response", s.maxTurns)
+	return "", false, nil
+}
+
+func (s *Service) generate(ctx context.Context, m *meter, prompt string) (string, error) {
+	return s.generateWith(ctx, m, s.generator, prompt)
+}
+
+// Usage is everything spent responding in the session so far, including
+// the sub-agents it delegated to
+func (s *Service) Usage(sessionId string) Usage {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	return s.usage[sessionId]
+}
+
+func (s *Service) recordUsage(sessionId string, u Usage) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.usage[sessionId] = s.usage[sessionId].Add(u)
 }
 
 func (s *Service) Flush(ctx context.Context, sessionId string) error {
@@ -91,6 +215,17 @@ func (s *Service) addShortTerm(ctx context.Context, sessionId string, role strin
 }
 
 func (s *Service) buildPrompt(ctx context.Context, sessionId string, input string) (string, error) {
+	prompt, err := s.buildContext(ctx, sessionId, input)
+	if err != nil {
+		return "", err
+	}
+
+	return prompt + "\n\nCompose the best possible assistant reply.\n", nil
+}
+
+// buildContext is everything the agent knows when answering input: its
+// instructions, tools, memories, tasks and the conversation so far
+func (s *Service) buildContext(ctx context.Context, sessionId string, input string) (string, error) {
 	// 1. Fetch Short-Term (Messages + Tasks)
 	shortTermMsgs, tasks, err := s.memory.ListShortTerm(
 		ctx,
@@ -101,7 +236,8 @@ func (s *Service) buildPrompt(ctx context.Context, sessionId string, input strin
 		return "", fmt.Errorf("short-term error: %w", err)
 	}
 
-	// 2. Fetch Long-Term (Messages + Skills)
+	// 2. Fetch Long-Term (Messages + Skills + Entities)
+	var entities memorymanager.EntityGraph
 	longTermMsgs, chunks, skills, err := s.memory.SearchLongTerm(
 		ctx,
 		sessionId,
@@ -109,6 +245,7 @@ func (s *Service) buildPrompt(ctx context.Context, sessionId string, input strin
 		memorymanager.WithSearchLongTermLimit(s.contextLimit),
 		memorymanager.WithSearchLongTermLinkedMemoriesLimit(s.contextLimit),
 		memorymanager.WithSearchLongTermLinkedMemoriesHops(s.linkedMemoriesHops),
+		memorymanager.WithSearchLongTermEntityGraph(&entities),
 	)
 	if err != nil {
 		return "", fmt.Errorf("long-term error: %w", err)
@@ -120,8 +257,17 @@ func (s *Service) buildPrompt(ctx context.Context, sessionId string, input strin
 		isRelevant[msg.Id] = true
 	}
 
+	var summaries []memorymanager.Message
 	var uniqueShortTerm []memorymanager.Message
 	for _, msg := range shortTermMsgs {
+		// in case a memory manager buffers reflection with the conversation
+		if msg.IsReflection() {
+			continue
+		}
+		if msg.Role == "summary" {
+			summaries = append(summaries, msg)
+			continue
+		}
 		if !isRelevant[msg.Id] {
 			uniqueShortTerm = append(uniqueShortTerm, msg)
 		}
@@ -167,6 +313,37 @@ func (s *Service) buildPrompt(ctx context.Context, sessionId string, input strin
 		}
 	}
 
+	if len(entities.Entities) > 0 {
+		sb.WriteString("\nKnown Entities:\n")
+		names := make(map[string]string, len(entities.Entities))
+		for i, entity := range entities.Entities {
+			names[entity.Id] = entity.Name
+			sb.WriteString(fmt.Sprintf("%d. %s", i+1, entity.Name))
+			if len(entity.Kind) > 0 {
+				sb.WriteString(fmt.Sprintf(" (%s)", entity.Kind))
+			}
+			if len(entity.Aliases) > 0 {
+				sb.WriteString(fmt.Sprintf(" aka %s", strings.Join(entity.Aliases, ", ")))
+			}
+			if len(entity.Attributes) > 0 {
+				attrsJSON, _ := json.Marshal(entity.Attributes)
+				sb.WriteString(" ")
+				sb.Write(attrsJSON)
+			}
+			sb.WriteString("\n")
+		}
+		if len(entities.Relations) > 0 {
+			sb.WriteString("Entity Relations:\n")
+			for _, rel := range entities.Relations {
+				source, target := names[rel.SourceId], names[rel.TargetId]
+				if len(source) == 0 || len(target) == 0 {
+					continue
+				}
+				sb.WriteString(fmt.Sprintf("- %s %s %s\n", source, rel.Type, target))
+			}
+		}
+	}
+
 	if len(longTermMsgs) > 0 {
 		sb.WriteString("\nRelevant Memories:\n")
 		for i, msg := range longTermMsgs {
@@ -208,6 +385,18 @@ func (s *Service) buildPrompt(ctx context.Context, sessionId string, input strin
 		}
 	}
 
+	if len(summaries) > 0 {
+		sb.WriteString("\nConversation Summary:\n")
+		for _, msg := range summaries {
+			for _, p := range msg.Parts {
+				if p.Type == "text" && len(p.Text) > 0 {
+					sb.WriteString(p.Text)
+					sb.WriteString("\n")
+				}
+			}
+		}
+	}
+
 	if len(uniqueShortTerm) > 0 {
 		sb.WriteString("\nConversation History:\n")
 		for i := len(uniqueShortTerm) - 1; i >= 0; i-- {
@@ -234,7 +423,6 @@ func (s *Service) buildPrompt(ctx context.Context, sessionId string, input strin
 
 	sb.WriteString("\nCurrent user message:\n")
 	sb.WriteString(strings.TrimSpace(input))
-	sb.WriteString("\n\nCompose the best possible assistant reply.\n")
 
 	return sb.String(), nil
 }
@@ -288,22 +476,9 @@ func New(
 	contextLimit int,
 	linkedMemoriesHops int,
 	systemPrompt string,
+	opts ...Option,
 ) *Service {
-	catalog := &ToolCatalog{
-		tools: map[string]toolhandler.ToolHandler{},
-		specs: map[string]toolhandler.ToolSpec{},
-		order: []string{},
-		mtx:   sync.RWMutex{},
-	}
-
-	for _, th := range toolHandlers {
-		if th == nil {
-			continue
-		}
-		if err := catalog.Register(th); err != nil {
-			continue
-		}
-	}
+	catalog := NewToolCatalog(toolHandlers...)
 
 	if contextLimit <= 0 {
 		contextLimit = 8
@@ -321,5 +496,8 @@ func New(
 		contextLimit:       contextLimit,
 		linkedMemoriesHops: linkedMemoriesHops,
 		systemPrompt:       systemPrompt,
+		options:            NewOptions(opts...),
+		usage:              map[string]Usage{},
+		mtx:                sync.RWMutex{},
 	}
 }
@@ -0,0 +1,139 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/w-h-a/agent/generator"
+	extractjson "github.com/w-h-a/agent/util/extract_json"
+	jsonschema "github.com/w-h-a/agent/util/json_schema"
+)
+
+const (
+	structuredName = "response"
+	gatheredReply  = "ready"
+)
+
+// structured replies with JSON conforming to schema. The tools or plan a
+// message needs are worked first so the reply can draw on the results
+// recorded in the session.
+func (s *Service) structured(ctx context.Context, m *meter, sessionId string, userInput string, schema map[string]any) (string, error) {
+	if err := s.gather(ctx, m, sessionId, userInput); err != nil {
+		return "", err
+	}
+
+	schemaJSON, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("invalid schema: %w", err)
+	}
+
+	base, err := s.buildContext(ctx, sessionId, userInput)
+	if err != nil {
+		return "", err
+	}
+
+	base += fmt.Sprintf("\n\nReply to the current user message with only a JSON document that conforms to this JSON Schema:\n%s\n", schemaJSON)
+
+	prompt := base
+
+	var mismatch error
+
+	for range s.options.MaxRepairs + 1 {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
+		content, err := s.generateStructured(ctx, m, prompt, schema)
+		if err != nil {
+			return "", err
+		}
+
+		doc := strings.TrimSpace(content)
+		if !json.Valid([]byte(doc)) {
+			if found, err := extractjson.Find(doc); err == nil {
+				doc = found
+			}
+		}
+
+		mismatch = jsonschema.Validate(schema, []byte(doc))
+		if mismatch == nil {
+			s.addShortTerm(ctx, sessionId, "assistant", doc, nil, map[string]any{"source": "structured"})
+			return doc, nil
+		}
+
+		prompt = base + fmt.Sprintf(
+			"\nYour previous reply was:\n%s\n\nIt does not conform to the schema:\n%v\n\nReply again with only the corrected JSON document.\n",
+			content, mismatch,
+		)
+	}
+
+	return "", fmt.Errorf("reply does not conform to the schema after %d repairs: %w", s.options.MaxRepairs, mismatch)
+}
+
+// gather runs the plan or tool calls a message needs without composing a
+// reply, since the reply is generated as JSON afterwards
+func (s *Service) gather(ctx context.Context, m *meter, sessionId string, userInput string) error {
+	if s.options.Strategy == StrategyPlanExecute {
+		p, err := s.executePlan(ctx, m, sessionId, userInput)
+		if err != nil || p != nil {
+			return err
+		}
+	}
+
+	if len(s.catalog.ListSpecs()) == 0 {
+		return nil
+	}
+
+	for range s.maxTurns {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		base, err := s.buildContext(ctx, sessionId, userInput)
+		if err != nil {
+			return err
+		}
+
+		prompt := base + fmt.Sprintf(
+			"\n\nCall a tool if the reply to the current user message needs its result. Otherwise reply with only `%s`; the reply itself is composed later.\n",
+			gatheredReply,
+		)
+
+		// the reply that calls no tool only says the tools are done
+		_, done, err := s.turn(ctx, m, sessionId, prompt)
+		if err != nil {
+			return err
+		}
+
+		if done {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("agent exceeded max turns (%d) without finishing its tool calls", s.maxTurns)
+}
+
+// generateStructured uses the provider's native JSON mode when it has one.
+// Native modes take no attachments and only some schemas, so otherwise the
+// reply relies on the prompt and validation instead.
+func (s *Service) generateStructured(ctx context.Context, m *meter, prompt string, schema map[string]any) (string, error) {
+	sg, ok := s.generator.(generator.StructuredGenerator)
+	if !ok || len(attachmentsFrom(ctx)) > 0 {
+		return s.generate(ctx, m, prompt)
+	}
+
+	content, err := sg.GenerateStructured(ctx, prompt, structuredName, schema)
+	if errors.Is(err, generator.ErrUnsupportedSchema) {
+		return s.generate(ctx, m, prompt)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	m.add(Usage{Generations: 1, PromptTokens: estimateTokens(prompt), CompletionTokens: estimateTokens(content)})
+
+	return content, nil
+}
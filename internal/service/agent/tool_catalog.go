@@ -2,6 +2,7 @@ package agent
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
 
@@ -40,6 +41,36 @@ func (c *ToolCatalog) Register(th toolhandler.ToolHandler) error {
 	return nil
 }
 
+// Set registers th, replacing any tool already registered under its name
+func (c *ToolCatalog) Set(th toolhandler.ToolHandler) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	spec := th.Spec()
+	key := strings.ToLower(strings.TrimSpace(spec.Name))
+
+	if _, ok := c.tools[key]; !ok {
+		c.order = append(c.order, key)
+	}
+
+	c.tools[key] = th
+	c.specs[key] = spec
+}
+
+func (c *ToolCatalog) Remove(name string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	key := strings.ToLower(strings.TrimSpace(name))
+	if _, ok := c.tools[key]; !ok {
+		return
+	}
+
+	delete(c.tools, key)
+	delete(c.specs, key)
+	c.order = slices.DeleteFunc(c.order, func(k string) bool { return k == key })
+}
+
 func (c *ToolCatalog) ListSpecs() []toolhandler.ToolSpec {
 	c.mtx.RLock()
 	defer c.mtx.RUnlock()
@@ -61,3 +92,25 @@ func (c *ToolCatalog) Get(name string) (toolhandler.ToolHandler, toolhandler.Too
 
 	return tp, c.specs[key], ok
 }
+
+// NewToolCatalog registers handlers in order, skipping nil ones and any
+// whose name is already taken
+func NewToolCatalog(handlers ...toolhandler.ToolHandler) *ToolCatalog {
+	catalog := &ToolCatalog{
+		tools: map[string]toolhandler.ToolHandler{},
+		specs: map[string]toolhandler.ToolSpec{},
+		order: []string{},
+		mtx:   sync.RWMutex{},
+	}
+
+	for _, th := range handlers {
+		if th == nil {
+			continue
+		}
+		if err := catalog.Register(th); err != nil {
+			continue
+		}
+	}
+
+	return catalog
+}
@@ -0,0 +1,65 @@
+package agent
+
+import (
+	"context"
+	"sync"
+)
+
+// Usage counts what a response cost. Generators do not report tokens so
+// they are estimated from the prompt and reply lengths.
+type Usage struct {
+	Generations      int `json:"generations"`
+	ToolCalls        int `json:"tool_calls"`
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		Generations:      u.Generations + other.Generations,
+		ToolCalls:        u.ToolCalls + other.ToolCalls,
+		PromptTokens:     u.PromptTokens + other.PromptTokens,
+		CompletionTokens: u.CompletionTokens + other.CompletionTokens,
+	}
+}
+
+type meter struct {
+	usage Usage
+	mtx   sync.Mutex
+}
+
+func (m *meter) add(u Usage) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.usage = m.usage.Add(u)
+}
+
+func (m *meter) total() Usage {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return m.usage
+}
+
+type meterKey struct{}
+
+func withMeter(ctx context.Context, m *meter) context.Context {
+	return context.WithValue(ctx, meterKey{}, m)
+}
+
+func meterFrom(ctx context.Context) (*meter, bool) {
+	m, ok := ctx.Value(meterKey{}).(*meter)
+	return m, ok
+}
+
+type depthKey struct{}
+
+// Depth is how many responses are running on the stack of ctx: 1 inside
+// the coordinator's tools, 2 inside the tools of a sub-agent it called
+func Depth(ctx context.Context) int {
+	depth, _ := ctx.Value(depthKey{}).(int)
+	return depth
+}
+
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
@@ -15,10 +15,17 @@ type Service struct {
 }
 
 func (s *Service) CreateSession(ctx context.Context, spaceId string) (*Session, error) {
+	return s.CreateChildSession(ctx, spaceId, "")
+}
+
+func (s *Service) CreateChildSession(ctx context.Context, spaceId string, parentId string) (*Session, error) {
 	opts := []memorymanager.CreateSessionOption{}
 	if len(spaceId) > 0 {
 		opts = append(opts, memorymanager.WithSpaceId(spaceId))
 	}
+	if len(parentId) > 0 {
+		opts = append(opts, memorymanager.WithParentId(parentId))
+	}
 	id, err := s.memory.CreateSession(ctx, opts...)
 	if err != nil {
 		return nil, err
@@ -32,8 +39,9 @@ func (s *Service) CreateSession(ctx context.Context, spaceId string) (*Session,
 	}
 
 	session := &Session{
-		id:      id,
-		spaceId: spaceId,
+		id:       id,
+		spaceId:  spaceId,
+		parentId: parentId,
 	}
 
 	s.sessions[id] = session
@@ -41,6 +49,31 @@ func (s *Service) CreateSession(ctx context.Context, spaceId string) (*Session,
 	return session, nil
 }
 
+// FindChildSession returns the latest session delegated from parentId in
+// the space, or an empty id when there is none. The memory manager is asked
+// first because it remembers children created before a restart.
+func (s *Service) FindChildSession(ctx context.Context, spaceId string, parentId string) (string, error) {
+	if lister, ok := s.memory.(memorymanager.ChildSessionLister); ok {
+		ids, err := lister.ListChildSessionIds(ctx, spaceId, parentId)
+		if err != nil {
+			return "", err
+		}
+		if len(ids) == 0 {
+			return "", nil
+		}
+		return ids[len(ids)-1], nil
+	}
+
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	for id, session := range s.sessions {
+		if session.spaceId == spaceId && session.parentId == parentId {
+			return id, nil
+		}
+	}
+	return "", nil
+}
+
 func (s *Service) ListSessionIds(ctx context.Context) ([]string, error) {
 	s.mtx.RLock()
 	defer s.mtx.RUnlock()
@@ -61,10 +94,60 @@ func (s *Service) GetSession(ctx context.Context, id string) (*Session, error) {
 	return session, nil
 }
 
-func (s *Service) DeleteSession(ctx context.Context, id string) {
+func (s *Service) ListSessionIdsBySpace(ctx context.Context, spaceId string) ([]string, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	ids := []string{}
+	for id, session := range s.sessions {
+		if session.spaceId == spaceId {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// ActiveAgent reports which agent is answering the session and whether it
+// was handed the session, in which case it keeps it until it hands it on
+func (s *Service) ActiveAgent(ctx context.Context, id string) (string, bool, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	session, ok := s.sessions[id]
+	if !ok {
+		return "", false, fmt.Errorf("session %s not found", id)
+	}
+	return session.activeAgent, session.handedOff, nil
+}
+
+func (s *Service) SetActiveAgent(ctx context.Context, id string, agent string, handedOff bool) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	session, ok := s.sessions[id]
+	if !ok {
+		return fmt.Errorf("session %s not found", id)
+	}
+	session.activeAgent = agent
+	session.handedOff = handedOff
+	return nil
+}
+
+func (s *Service) DeleteSession(ctx context.Context, id string) error {
+	if err := s.memory.DeleteSession(ctx, id); err != nil {
+		return err
+	}
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 	delete(s.sessions, id)
+	return nil
+}
+
+func (s *Service) DropSpace(ctx context.Context, spaceId string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for id, session := range s.sessions {
+		if session.spaceId == spaceId {
+			delete(s.sessions, id)
+		}
+	}
 }
 
 func New(
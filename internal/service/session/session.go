@@ -1,8 +1,13 @@
 package session
 
 type Session struct {
-	id      string
-	spaceId string
+	id       string
+	spaceId  string
+	parentId string
+	// the agent answering the session and whether it took over by handoff
+	// rather than being picked by the router
+	activeAgent string
+	handedOff   bool
 }
 
 func (s *Session) ID() string {
@@ -12,3 +17,9 @@ func (s *Session) ID() string {
 func (s *Session) SpaceId() string {
 	return s.spaceId
 }
+
+// ParentId is the session that delegated to this one, empty for sessions
+// a user started
+func (s *Session) ParentId() string {
+	return s.parentId
+}
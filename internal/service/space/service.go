@@ -57,10 +57,14 @@ func (s *Service) GetSpace(ctx context.Context, id string) (*Space, error) {
 	return space, nil
 }
 
-func (s *Service) DeleteSpace(ctx context.Context, id string) {
+func (s *Service) DeleteSpace(ctx context.Context, id string) error {
+	if err := s.memory.DeleteSpace(ctx, id); err != nil {
+		return err
+	}
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 	delete(s.spaces, id)
+	return nil
 }
 
 func New(
@@ -0,0 +1,21 @@
+package memorymanager
+
+type Entity struct {
+	Id         string         `json:"id"`
+	SpaceId    string         `json:"space_id"`
+	Name       string         `json:"name"`
+	Kind       string         `json:"kind"`
+	Aliases    []string       `json:"aliases,omitempty"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+type Relation struct {
+	SourceId string `json:"source_id"`
+	TargetId string `json:"target_id"`
+	Type     string `json:"type"`
+}
+
+type EntityGraph struct {
+	Entities  []Entity   `json:"entities"`
+	Relations []Relation `json:"relations"`
+}
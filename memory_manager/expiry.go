@@ -0,0 +1,10 @@
+package memorymanager
+
+import "context"
+
+// Expirer is implemented by memory managers whose long-term memories can
+// outlive a retention period. Expired memories are never returned by a
+// search, so deleting them only reclaims space and can run on any schedule.
+type Expirer interface {
+	DeleteExpired(ctx context.Context) (int, error)
+}
@@ -2,11 +2,45 @@ package memorymanager
 
 import (
 	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
 )
 
 type InputFile struct {
 	Name   string
 	Reader io.Reader
+	// MimeType is sniffed from the content when left empty
+	MimeType string
+}
+
+var mimeAliases = map[string]string{
+	"image/jpg":    "image/jpeg",
+	"audio/wave":   "audio/wav",
+	"audio/x-wav":  "audio/wav",
+	"audio/mp3":    "audio/mpeg",
+	"audio/x-aiff": "audio/aiff",
+	"audio/x-flac": "audio/flac",
+}
+
+// DetectMimeType sniffs a file's MIME type from its first bytes, falling
+// back to its name's extension when the content is not recognised
+func DetectMimeType(name string, head []byte) string {
+	mimeType, _, _ := strings.Cut(http.DetectContentType(head), ";")
+
+	if mimeType == "application/octet-stream" || mimeType == "text/plain" {
+		if byExtension := mime.TypeByExtension(strings.ToLower(filepath.Ext(name))); len(byExtension) > 0 {
+			mimeType, _, _ = strings.Cut(byExtension, ";")
+		}
+	}
+
+	mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+	if alias, ok := mimeAliases[mimeType]; ok {
+		return alias
+	}
+
+	return mimeType
 }
 
 type MatchingChunk struct {
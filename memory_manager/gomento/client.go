@@ -0,0 +1,489 @@
+package gomento
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	memorymanager "github.com/w-h-a/agent/memory_manager"
+)
+
+const (
+	defaultTimeout = 30 * time.Second
+	defaultBackoff = 250 * time.Millisecond
+	maxBackoff     = 10 * time.Second
+)
+
+// Client is a typed client for the whole gomento API, beyond what the
+// memory manager needs
+type Client interface {
+	CreateSpace(ctx context.Context, name string) (Space, error)
+	GetSpace(ctx context.Context, spaceId string) (Space, error)
+	ListSpaces(ctx context.Context, opts ...ListOption) (Page[Space], error)
+	DeleteSpace(ctx context.Context, spaceId string) error
+
+	CreateSession(ctx context.Context, spaceId string) (Session, error)
+	GetSession(ctx context.Context, sessionId string) (Session, error)
+	ListSessions(ctx context.Context, spaceId string, opts ...ListOption) (Page[Session], error)
+	DeleteSession(ctx context.Context, sessionId string) error
+
+	AddMessage(ctx context.Context, sessionId string, role string, parts []memorymanager.Part, files map[string]memorymanager.InputFile) error
+	ListMessages(ctx context.Context, sessionId string, opts ...ListOption) (Page[memorymanager.Message], error)
+	Distill(ctx context.Context, sessionId string) error
+
+	CreateTask(ctx context.Context, sessionId string, task memorymanager.Task) (memorymanager.Task, error)
+	GetTask(ctx context.Context, sessionId string, taskId string) (memorymanager.Task, error)
+	ListTasks(ctx context.Context, sessionId string, opts ...ListOption) (Page[memorymanager.Task], error)
+	UpdateTask(ctx context.Context, sessionId string, task memorymanager.Task) (memorymanager.Task, error)
+	DeleteTask(ctx context.Context, sessionId string, taskId string) error
+
+	CreateSkill(ctx context.Context, spaceId string, skill memorymanager.Skill) (memorymanager.Skill, error)
+	GetSkill(ctx context.Context, spaceId string, skillId string) (memorymanager.Skill, error)
+	ListSkills(ctx context.Context, spaceId string, opts ...ListOption) (Page[memorymanager.Skill], error)
+	UpdateSkill(ctx context.Context, spaceId string, skill memorymanager.Skill) (memorymanager.Skill, error)
+	DeleteSkill(ctx context.Context, spaceId string, skillId string) error
+
+	ListFiles(ctx context.Context, spaceId string, opts ...ListOption) (Page[memorymanager.File], error)
+	// DownloadFile streams the file's content and must be closed
+	DownloadFile(ctx context.Context, spaceId string, fileId string) (io.ReadCloser, error)
+
+	SearchMessages(ctx context.Context, spaceId string, query string, limit int) ([]memorymanager.Message, error)
+	SearchChunks(ctx context.Context, spaceId string, query string, limit int) ([]memorymanager.MatchingChunk, error)
+	SearchSkills(ctx context.Context, spaceId string, query string, limit int) ([]memorymanager.Skill, error)
+}
+
+type gomentoClient struct {
+	options memorymanager.Options
+	http    *http.Client
+	apiKey  string
+	retries retryConfig
+}
+
+func (c *gomentoClient) CreateSpace(ctx context.Context, name string) (Space, error) {
+	var space Space
+	err := c.do(ctx, http.MethodPost, "/api/v1/spaces", nil, CreateSpaceRequest{Name: name}, &space)
+	return space, err
+}
+
+func (c *gomentoClient) GetSpace(ctx context.Context, spaceId string) (Space, error) {
+	var space Space
+	err := c.do(ctx, http.MethodGet, path("spaces", spaceId), nil, nil, &space)
+	return space, err
+}
+
+func (c *gomentoClient) ListSpaces(ctx context.Context, opts ...ListOption) (Page[Space], error) {
+	var page Page[Space]
+	err := c.do(ctx, http.MethodGet, "/api/v1/spaces", listQuery(opts), nil, &page)
+	return page, err
+}
+
+func (c *gomentoClient) DeleteSpace(ctx context.Context, spaceId string) error {
+	return c.do(ctx, http.MethodDelete, path("spaces", spaceId), nil, nil, nil)
+}
+
+func (c *gomentoClient) CreateSession(ctx context.Context, spaceId string) (Session, error) {
+	var session Session
+	err := c.do(ctx, http.MethodPost, "/api/v1/sessions", nil, CreateSessionRequest{SpaceId: spaceId}, &session)
+	return session, err
+}
+
+func (c *gomentoClient) GetSession(ctx context.Context, sessionId string) (Session, error) {
+	var session Session
+	err := c.do(ctx, http.MethodGet, path("sessions", sessionId), nil, nil, &session)
+	return session, err
+}
+
+func (c *gomentoClient) ListSessions(ctx context.Context, spaceId string, opts ...ListOption) (Page[Session], error) {
+	query := listQuery(opts)
+	if len(spaceId) > 0 {
+		query.Set("space_id", spaceId)
+	}
+
+	var page Page[Session]
+	err := c.do(ctx, http.MethodGet, "/api/v1/sessions", query, nil, &page)
+	return page, err
+}
+
+func (c *gomentoClient) DeleteSession(ctx context.Context, sessionId string) error {
+	return c.do(ctx, http.MethodDelete, path("sessions", sessionId), nil, nil, nil)
+}
+
+func (c *gomentoClient) AddMessage(ctx context.Context, sessionId string, role string, parts []memorymanager.Part, files map[string]memorymanager.InputFile) error {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	if err := writer.WriteField("role", role); err != nil {
+		return err
+	}
+
+	partsJson, err := json.Marshal(parts)
+	if err != nil {
+		return err
+	}
+
+	if err := writer.WriteField("parts", string(partsJson)); err != nil {
+		return err
+	}
+
+	for key, file := range files {
+		partWriter, err := createFormFile(writer, key, file)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(partWriter, file.Reader); err != nil {
+			return err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return c.send(ctx, http.MethodPost, path("sessions", sessionId, "messages"), nil, body.Bytes(), writer.FormDataContentType(), nil)
+}
+
+func (c *gomentoClient) ListMessages(ctx context.Context, sessionId string, opts ...ListOption) (Page[memorymanager.Message], error) {
+	var page Page[memorymanager.Message]
+	err := c.do(ctx, http.MethodGet, path("sessions", sessionId, "messages"), listQuery(opts), nil, &page)
+	return page, err
+}
+
+func (c *gomentoClient) Distill(ctx context.Context, sessionId string) error {
+	return c.do(ctx, http.MethodPost, path("sessions", sessionId, "distill"), nil, nil, nil)
+}
+
+func (c *gomentoClient) CreateTask(ctx context.Context, sessionId string, task memorymanager.Task) (memorymanager.Task, error) {
+	var created memorymanager.Task
+	err := c.do(ctx, http.MethodPost, path("sessions", sessionId, "tasks"), nil, task, &created)
+	return created, err
+}
+
+func (c *gomentoClient) GetTask(ctx context.Context, sessionId string, taskId string) (memorymanager.Task, error) {
+	var task memorymanager.Task
+	err := c.do(ctx, http.MethodGet, path("sessions", sessionId, "tasks", taskId), nil, nil, &task)
+	return task, err
+}
+
+func (c *gomentoClient) ListTasks(ctx context.Context, sessionId string, opts ...ListOption) (Page[memorymanager.Task], error) {
+	var page Page[memorymanager.Task]
+	err := c.do(ctx, http.MethodGet, path("sessions", sessionId, "tasks"), listQuery(opts), nil, &page)
+	return page, err
+}
+
+func (c *gomentoClient) UpdateTask(ctx context.Context, sessionId string, task memorymanager.Task) (memorymanager.Task, error) {
+	var updated memorymanager.Task
+	err := c.do(ctx, http.MethodPut, path("sessions", sessionId, "tasks", task.Id), nil, task, &updated)
+	return updated, err
+}
+
+func (c *gomentoClient) DeleteTask(ctx context.Context, sessionId string, taskId string) error {
+	return c.do(ctx, http.MethodDelete, path("sessions", sessionId, "tasks", taskId), nil, nil, nil)
+}
+
+func (c *gomentoClient) CreateSkill(ctx context.Context, spaceId string, skill memorymanager.Skill) (memorymanager.Skill, error) {
+	var created memorymanager.Skill
+	err := c.do(ctx, http.MethodPost, path("spaces", spaceId, "skills"), nil, skill, &created)
+	return created, err
+}
+
+func (c *gomentoClient) GetSkill(ctx context.Context, spaceId string, skillId string) (memorymanager.Skill, error) {
+	var skill memorymanager.Skill
+	err := c.do(ctx, http.MethodGet, path("spaces", spaceId, "skills", skillId), nil, nil, &skill)
+	return skill, err
+}
+
+func (c *gomentoClient) ListSkills(ctx context.Context, spaceId string, opts ...ListOption) (Page[memorymanager.Skill], error) {
+	var page Page[memorymanager.Skill]
+	err := c.do(ctx, http.MethodGet, path("spaces", spaceId, "skills"), listQuery(opts), nil, &page)
+	return page, err
+}
+
+func (c *gomentoClient) UpdateSkill(ctx context.Context, spaceId string, skill memorymanager.Skill) (memorymanager.Skill, error) {
+	var updated memorymanager.Skill
+	err := c.do(ctx, http.MethodPut, path("spaces", spaceId, "skills", skill.Id), nil, skill, &updated)
+	return updated, err
+}
+
+func (c *gomentoClient) DeleteSkill(ctx context.Context, spaceId string, skillId string) error {
+	return c.do(ctx, http.MethodDelete, path("spaces", spaceId, "skills", skillId), nil, nil, nil)
+}
+
+func (c *gomentoClient) ListFiles(ctx context.Context, spaceId string, opts ...ListOption) (Page[memorymanager.File], error) {
+	var page Page[memorymanager.File]
+	err := c.do(ctx, http.MethodGet, path("spaces", spaceId, "files"), listQuery(opts), nil, &page)
+	return page, err
+}
+
+func (c *gomentoClient) DownloadFile(ctx context.Context, spaceId string, fileId string) (io.ReadCloser, error) {
+	rsp, err := c.roundTrip(ctx, http.MethodGet, path("spaces", spaceId, "files", fileId, "download"), nil, nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return rsp.Body, nil
+}
+
+func (c *gomentoClient) SearchMessages(ctx context.Context, spaceId string, query string, limit int) ([]memorymanager.Message, error) {
+	var msgs []memorymanager.Message
+	err := c.do(ctx, http.MethodGet, path("spaces", spaceId, "messages"), searchQuery(query, limit), nil, &msgs)
+	return msgs, err
+}
+
+func (c *gomentoClient) SearchChunks(ctx context.Context, spaceId string, query string, limit int) ([]memorymanager.MatchingChunk, error) {
+	var chunks []memorymanager.MatchingChunk
+	err := c.do(ctx, http.MethodGet, path("spaces", spaceId, "chunks"), searchQuery(query, limit), nil, &chunks)
+	return chunks, err
+}
+
+func (c *gomentoClient) SearchSkills(ctx context.Context, spaceId string, query string, limit int) ([]memorymanager.Skill, error) {
+	var skills []memorymanager.Skill
+	err := c.do(ctx, http.MethodGet, path("spaces", spaceId, "skills"), searchQuery(query, limit), nil, &skills)
+	return skills, err
+}
+
+// do sends req as JSON and decodes the response into rsp when it is not nil
+func (c *gomentoClient) do(ctx context.Context, method string, p string, query url.Values, req any, rsp any) error {
+	var body []byte
+	contentType := ""
+
+	if req != nil {
+		bs, err := json.Marshal(req)
+		if err != nil {
+			return err
+		}
+		body = bs
+		contentType = "application/json"
+	}
+
+	return c.send(ctx, method, p, query, body, contentType, rsp)
+}
+
+func (c *gomentoClient) send(ctx context.Context, method string, p string, query url.Values, body []byte, contentType string, rsp any) error {
+	response, err := c.roundTrip(ctx, method, p, query, body, contentType)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if rsp == nil {
+		io.Copy(io.Discard, response.Body)
+		return nil
+	}
+
+	return json.NewDecoder(response.Body).Decode(rsp)
+}
+
+// roundTrip returns a successful response, retrying transient failures
+func (c *gomentoClient) roundTrip(ctx context.Context, method string, p string, query url.Values, body []byte, contentType string) (*http.Response, error) {
+	u := c.options.Location + p
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	backoff := c.retries.Backoff
+
+	for attempt := 0; ; attempt++ {
+		var reader io.Reader
+		if body != nil {
+			reader = bytes.NewReader(body)
+		}
+
+		request, err := http.NewRequestWithContext(ctx, method, u, reader)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(contentType) > 0 {
+			request.Header.Set("Content-Type", contentType)
+		}
+		request.Header.Set("Accept", "application/json")
+
+		if len(c.apiKey) > 0 {
+			request.Header.Set("Authorization", "Bearer "+c.apiKey)
+		}
+
+		response, err := c.http.Do(request)
+
+		var wait time.Duration
+		retryable := false
+
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			// the request may have reached the server so only idempotent ones go again
+			retryable = method != http.MethodPost
+		case response.StatusCode < 400:
+			return response, nil
+		default:
+			apiErr := decodeError(response)
+			err = apiErr
+			wait = retryAfter(response)
+			retryable = response.StatusCode == http.StatusTooManyRequests ||
+				(method != http.MethodPost && transient(response.StatusCode))
+		}
+
+		if !retryable || attempt >= c.retries.Attempts {
+			return nil, err
+		}
+
+		if wait == 0 {
+			wait = backoff
+			backoff = min(backoff*2, maxBackoff)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+func decodeError(response *http.Response) *APIError {
+	defer response.Body.Close()
+
+	apiErr := &APIError{StatusCode: response.StatusCode}
+
+	bs, _ := io.ReadAll(io.LimitReader(response.Body, 4096))
+
+	var rsp ErrorResponse
+	if err := json.Unmarshal(bs, &rsp); err == nil && len(rsp.Error) > 0 {
+		apiErr.Message = rsp.Error
+	} else {
+		apiErr.Message = strings.TrimSpace(string(bs))
+	}
+
+	return apiErr
+}
+
+func transient(status int) bool {
+	return status == http.StatusBadGateway ||
+		status == http.StatusServiceUnavailable ||
+		status == http.StatusGatewayTimeout
+}
+
+func retryAfter(response *http.Response) time.Duration {
+	secs, err := strconv.Atoi(response.Header.Get("Retry-After"))
+	if err != nil || secs < 0 {
+		return 0
+	}
+	return min(time.Duration(secs)*time.Second, maxBackoff)
+}
+
+func path(segments ...string) string {
+	escaped := make([]string, 0, len(segments)+2)
+	escaped = append(escaped, "", "api", "v1")
+	for _, s := range segments {
+		escaped = append(escaped, url.PathEscape(s))
+	}
+	return strings.Join(escaped, "/")
+}
+
+func listQuery(opts []ListOption) url.Values {
+	options := NewListOptions(opts...)
+
+	query := url.Values{}
+	if options.Limit > 0 {
+		query.Set("limit", strconv.Itoa(options.Limit))
+	}
+	if len(options.Cursor) > 0 {
+		query.Set("cursor", options.Cursor)
+	}
+
+	return query
+}
+
+func searchQuery(q string, limit int) url.Values {
+	query := url.Values{}
+	query.Set("q", q)
+	query.Set("limit", strconv.Itoa(limit))
+	return query
+}
+
+// Collect walks a listing page by page until it runs out
+func Collect[T any](ctx context.Context, list func(ctx context.Context, opts ...ListOption) (Page[T], error), opts ...ListOption) ([]T, error) {
+	var items []T
+	cursor := ""
+
+	for {
+		page, err := list(ctx, append(opts, WithCursor(cursor))...)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, page.Items...)
+
+		if len(page.NextCursor) == 0 {
+			return items, nil
+		}
+
+		if page.NextCursor == cursor {
+			return nil, errors.New("gomento returned the same cursor twice")
+		}
+
+		cursor = page.NextCursor
+	}
+}
+
+func NewClient(opts ...memorymanager.Option) Client {
+	options := memorymanager.NewOptions(opts...)
+
+	if len(options.Location) == 0 {
+		panic("missing location for gomento client")
+	}
+
+	timeout := defaultTimeout
+	if t, ok := TimeoutFrom(options.Context); ok {
+		timeout = t
+	}
+
+	options.Location = strings.TrimRight(options.Location, "/")
+
+	c := &gomentoClient{
+		options: options,
+		http:    &http.Client{Timeout: timeout},
+		retries: retryConfig{Attempts: 2, Backoff: defaultBackoff},
+	}
+
+	if key, ok := ApiKeyFrom(options.Context); ok {
+		c.apiKey = key
+	}
+
+	if cfg, ok := RetriesFrom(options.Context); ok {
+		c.retries = cfg
+	}
+
+	if c.retries.Backoff == 0 {
+		c.retries.Backoff = defaultBackoff
+	}
+
+	return c
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// createFormFile is multipart's CreateFormFile with the file's MIME type
+// when it is known
+func createFormFile(writer *multipart.Writer, field string, file memorymanager.InputFile) (io.Writer, error) {
+	if len(file.MimeType) == 0 {
+		return writer.CreateFormFile(field, file.Name)
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, quoteEscaper.Replace(field), quoteEscaper.Replace(file.Name)))
+	header.Set("Content-Type", file.MimeType)
+
+	return writer.CreatePart(header)
+}
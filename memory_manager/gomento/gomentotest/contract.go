@@ -0,0 +1,238 @@
+package gomentotest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	memorymanager "github.com/w-h-a/agent/memory_manager"
+	"github.com/w-h-a/agent/memory_manager/gomento"
+)
+
+// RunContract walks c through every endpoint and returns the first place
+// the API disagrees with what the client expects. It creates its own space
+// and deletes it when done, so it can run against a real deployment as
+// well as the fake.
+func RunContract(ctx context.Context, c gomento.Client) error {
+	// quotes and braces must survive the round trip
+	name := `contract "space" {with} \escapes`
+
+	space, err := c.CreateSpace(ctx, name)
+	if err != nil {
+		return fmt.Errorf("create space: %w", err)
+	}
+	defer c.DeleteSpace(ctx, space.Id)
+
+	if space.Name != name || len(space.Id) == 0 {
+		return fmt.Errorf("create space: got %+v", space)
+	}
+
+	got, err := c.GetSpace(ctx, space.Id)
+	if err != nil || got.Name != name {
+		return fmt.Errorf("get space: got %+v, %v", got, err)
+	}
+
+	spaces, err := gomento.Collect(ctx, c.ListSpaces, gomento.WithLimit(1))
+	if err != nil {
+		return fmt.Errorf("list spaces: %w", err)
+	}
+	if !slices.ContainsFunc(spaces, func(s gomento.Space) bool { return s.Id == space.Id }) {
+		return errors.New("list spaces: created space missing")
+	}
+
+	if err := checkSessions(ctx, c, space.Id); err != nil {
+		return err
+	}
+
+	if err := checkSkills(ctx, c, space.Id); err != nil {
+		return err
+	}
+
+	if err := c.DeleteSpace(ctx, space.Id); err != nil {
+		return fmt.Errorf("delete space: %w", err)
+	}
+
+	if _, err := c.GetSpace(ctx, space.Id); !errors.Is(err, gomento.ErrNotFound) {
+		return fmt.Errorf("get deleted space: want not found, got %v", err)
+	}
+
+	return nil
+}
+
+func checkSessions(ctx context.Context, c gomento.Client, spaceId string) error {
+	created := map[string]struct{}{}
+	var session gomento.Session
+
+	for range 3 {
+		s, err := c.CreateSession(ctx, spaceId)
+		if err != nil {
+			return fmt.Errorf("create session: %w", err)
+		}
+		if s.SpaceId != spaceId {
+			return fmt.Errorf("create session: got space %q", s.SpaceId)
+		}
+		created[s.Id] = struct{}{}
+		session = s
+	}
+
+	sessions, err := gomento.Collect(ctx, func(ctx context.Context, opts ...gomento.ListOption) (gomento.Page[gomento.Session], error) {
+		return c.ListSessions(ctx, spaceId, opts...)
+	}, gomento.WithLimit(2))
+	if err != nil {
+		return fmt.Errorf("list sessions: %w", err)
+	}
+	if len(sessions) != len(created) {
+		return fmt.Errorf("list sessions: got %d across pages, want %d", len(sessions), len(created))
+	}
+
+	got, err := c.GetSession(ctx, session.Id)
+	if err != nil || got.SpaceId != spaceId {
+		return fmt.Errorf("get session: got %+v, %v", got, err)
+	}
+
+	parts := []memorymanager.Part{{Type: "text", Text: `the "launch" code is banana`}}
+	files := map[string]memorymanager.InputFile{
+		"notes": {Name: "notes.txt", Reader: strings.NewReader("banana bread recipe")},
+	}
+
+	if err := c.AddMessage(ctx, session.Id, "user", parts, files); err != nil {
+		return fmt.Errorf("add message: %w", err)
+	}
+
+	msgs, err := c.ListMessages(ctx, session.Id, gomento.WithLimit(10))
+	if err != nil || len(msgs.Items) != 1 || len(msgs.Items[0].Parts) != 1 || msgs.Items[0].Parts[0].Text != parts[0].Text {
+		return fmt.Errorf("list messages: got %+v, %v", msgs, err)
+	}
+
+	if err := c.Distill(ctx, session.Id); err != nil {
+		return fmt.Errorf("distill: %w", err)
+	}
+
+	if _, err := c.SearchMessages(ctx, spaceId, "banana", 5); err != nil {
+		return fmt.Errorf("search messages: %w", err)
+	}
+
+	if _, err := c.SearchChunks(ctx, spaceId, "banana", 5); err != nil {
+		return fmt.Errorf("search chunks: %w", err)
+	}
+
+	listed, err := gomento.Collect(ctx, func(ctx context.Context, opts ...gomento.ListOption) (gomento.Page[memorymanager.File], error) {
+		return c.ListFiles(ctx, spaceId, opts...)
+	})
+	if err != nil {
+		return fmt.Errorf("list files: %w", err)
+	}
+
+	for _, f := range listed {
+		if f.Filename != "notes.txt" {
+			continue
+		}
+		rc, err := c.DownloadFile(ctx, spaceId, f.Id)
+		if err != nil {
+			return fmt.Errorf("download file: %w", err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil || string(content) != "banana bread recipe" {
+			return fmt.Errorf("download file: got %q, %v", content, err)
+		}
+	}
+
+	if err := checkTasks(ctx, c, session.Id); err != nil {
+		return err
+	}
+
+	if err := c.DeleteSession(ctx, session.Id); err != nil {
+		return fmt.Errorf("delete session: %w", err)
+	}
+
+	if _, err := c.GetSession(ctx, session.Id); !errors.Is(err, gomento.ErrNotFound) {
+		return fmt.Errorf("get deleted session: want not found, got %v", err)
+	}
+
+	return nil
+}
+
+func checkTasks(ctx context.Context, c gomento.Client, sessionId string) error {
+	second, err := c.CreateTask(ctx, sessionId, memorymanager.Task{TaskOrder: 2, Status: "pending", Data: json.RawMessage(`{"step":"ship"}`)})
+	if err != nil {
+		return fmt.Errorf("create task: %w", err)
+	}
+
+	first, err := c.CreateTask(ctx, sessionId, memorymanager.Task{TaskOrder: 1, Status: "pending", Data: json.RawMessage(`{"step":"build"}`)})
+	if err != nil {
+		return fmt.Errorf("create task: %w", err)
+	}
+
+	tasks, err := gomento.Collect(ctx, func(ctx context.Context, opts ...gomento.ListOption) (gomento.Page[memorymanager.Task], error) {
+		return c.ListTasks(ctx, sessionId, opts...)
+	}, gomento.WithLimit(1))
+	if err != nil {
+		return fmt.Errorf("list tasks: %w", err)
+	}
+	if len(tasks) != 2 || tasks[0].Id != first.Id || tasks[1].Id != second.Id {
+		return fmt.Errorf("list tasks: want them in task order, got %+v", tasks)
+	}
+
+	first.Status = "done"
+	updated, err := c.UpdateTask(ctx, sessionId, first)
+	if err != nil || updated.Status != "done" {
+		return fmt.Errorf("update task: got %+v, %v", updated, err)
+	}
+
+	got, err := c.GetTask(ctx, sessionId, first.Id)
+	if err != nil || got.Status != "done" {
+		return fmt.Errorf("get task: got %+v, %v", got, err)
+	}
+
+	if err := c.DeleteTask(ctx, sessionId, second.Id); err != nil {
+		return fmt.Errorf("delete task: %w", err)
+	}
+
+	if _, err := c.GetTask(ctx, sessionId, second.Id); !errors.Is(err, gomento.ErrNotFound) {
+		return fmt.Errorf("get deleted task: want not found, got %v", err)
+	}
+
+	return nil
+}
+
+func checkSkills(ctx context.Context, c gomento.Client, spaceId string) error {
+	skill, err := c.CreateSkill(ctx, spaceId, memorymanager.Skill{Trigger: "deploy the service", SOP: "run make deploy"})
+	if err != nil || len(skill.Id) == 0 {
+		return fmt.Errorf("create skill: got %+v, %v", skill, err)
+	}
+
+	skill.SOP = "run make release"
+	updated, err := c.UpdateSkill(ctx, spaceId, skill)
+	if err != nil || updated.SOP != skill.SOP {
+		return fmt.Errorf("update skill: got %+v, %v", updated, err)
+	}
+
+	got, err := c.GetSkill(ctx, spaceId, skill.Id)
+	if err != nil || got.SOP != skill.SOP {
+		return fmt.Errorf("get skill: got %+v, %v", got, err)
+	}
+
+	skills, err := c.ListSkills(ctx, spaceId)
+	if err != nil || !slices.ContainsFunc(skills.Items, func(s memorymanager.Skill) bool { return s.Id == skill.Id }) {
+		return fmt.Errorf("list skills: got %+v, %v", skills, err)
+	}
+
+	if _, err := c.SearchSkills(ctx, spaceId, "deploy", 5); err != nil {
+		return fmt.Errorf("search skills: %w", err)
+	}
+
+	if err := c.DeleteSkill(ctx, spaceId, skill.Id); err != nil {
+		return fmt.Errorf("delete skill: %w", err)
+	}
+
+	if _, err := c.GetSkill(ctx, spaceId, skill.Id); !errors.Is(err, gomento.ErrNotFound) {
+		return fmt.Errorf("get deleted skill: want not found, got %v", err)
+	}
+
+	return nil
+}
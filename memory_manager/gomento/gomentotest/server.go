@@ -0,0 +1,645 @@
+package gomentotest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	memorymanager "github.com/w-h-a/agent/memory_manager"
+	"github.com/w-h-a/agent/memory_manager/gomento"
+)
+
+const (
+	defaultPageSize = 20
+)
+
+type file struct {
+	meta    memorymanager.File
+	spaceId string
+	content []byte
+}
+
+type message struct {
+	msg       memorymanager.Message
+	distilled bool
+}
+
+// Server is a local stand-in for the gomento API that keeps everything in
+// memory. Search matches on shared words rather than embeddings, so it
+// needs no model. Close it when done.
+type Server struct {
+	*httptest.Server
+
+	apiKey   string
+	spaces   []gomento.Space
+	sessions []gomento.Session
+	messages map[string][]*message
+	tasks    map[string][]memorymanager.Task
+	skills   map[string][]memorymanager.Skill
+	files    map[string][]*file
+	failures []int
+	mtx      sync.Mutex
+}
+
+// Fail makes the next requests answer with the given statuses, one each,
+// before the server behaves again
+func (s *Server) Fail(statuses ...int) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.failures = append(s.failures, statuses...)
+}
+
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /api/v1/spaces", s.createSpace)
+	mux.HandleFunc("GET /api/v1/spaces", s.listSpaces)
+	mux.HandleFunc("GET /api/v1/spaces/{space}", s.getSpace)
+	mux.HandleFunc("DELETE /api/v1/spaces/{space}", s.deleteSpace)
+
+	mux.HandleFunc("POST /api/v1/sessions", s.createSession)
+	mux.HandleFunc("GET /api/v1/sessions", s.listSessions)
+	mux.HandleFunc("GET /api/v1/sessions/{session}", s.getSession)
+	mux.HandleFunc("DELETE /api/v1/sessions/{session}", s.deleteSession)
+
+	mux.HandleFunc("POST /api/v1/sessions/{session}/messages", s.addMessage)
+	mux.HandleFunc("GET /api/v1/sessions/{session}/messages", s.listMessages)
+	mux.HandleFunc("POST /api/v1/sessions/{session}/distill", s.distill)
+
+	mux.HandleFunc("POST /api/v1/sessions/{session}/tasks", s.putTask)
+	mux.HandleFunc("GET /api/v1/sessions/{session}/tasks", s.listTasks)
+	mux.HandleFunc("GET /api/v1/sessions/{session}/tasks/{task}", s.getTask)
+	mux.HandleFunc("PUT /api/v1/sessions/{session}/tasks/{task}", s.putTask)
+	mux.HandleFunc("DELETE /api/v1/sessions/{session}/tasks/{task}", s.deleteTask)
+
+	mux.HandleFunc("POST /api/v1/spaces/{space}/skills", s.putSkill)
+	mux.HandleFunc("GET /api/v1/spaces/{space}/skills", s.listSkills)
+	mux.HandleFunc("GET /api/v1/spaces/{space}/skills/{skill}", s.getSkill)
+	mux.HandleFunc("PUT /api/v1/spaces/{space}/skills/{skill}", s.putSkill)
+	mux.HandleFunc("DELETE /api/v1/spaces/{space}/skills/{skill}", s.deleteSkill)
+
+	mux.HandleFunc("GET /api/v1/spaces/{space}/files", s.listFiles)
+	mux.HandleFunc("GET /api/v1/spaces/{space}/files/{file}/download", s.downloadFile)
+
+	mux.HandleFunc("GET /api/v1/spaces/{space}/messages", s.searchMessages)
+	mux.HandleFunc("GET /api/v1/spaces/{space}/chunks", s.searchChunks)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(s.apiKey) > 0 && r.Header.Get("Authorization") != "Bearer "+s.apiKey {
+			writeError(w, http.StatusUnauthorized, "invalid api key")
+			return
+		}
+
+		s.mtx.Lock()
+		if len(s.failures) > 0 {
+			status := s.failures[0]
+			s.failures = s.failures[1:]
+			s.mtx.Unlock()
+			writeError(w, status, "injected failure")
+			return
+		}
+		defer s.mtx.Unlock()
+
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) createSpace(w http.ResponseWriter, r *http.Request) {
+	var req gomento.CreateSpaceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	space := gomento.Space{Id: uuid.New().String(), Name: req.Name, CreatedAt: time.Now().UTC()}
+	s.spaces = append(s.spaces, space)
+
+	writeJSON(w, http.StatusCreated, space)
+}
+
+func (s *Server) listSpaces(w http.ResponseWriter, r *http.Request) {
+	writePage(w, r, s.spaces)
+}
+
+func (s *Server) getSpace(w http.ResponseWriter, r *http.Request) {
+	i := s.space(r.PathValue("space"))
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "space not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.spaces[i])
+}
+
+func (s *Server) deleteSpace(w http.ResponseWriter, r *http.Request) {
+	spaceId := r.PathValue("space")
+
+	i := s.space(spaceId)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "space not found")
+		return
+	}
+
+	s.spaces = slices.Delete(s.spaces, i, i+1)
+
+	for _, session := range slices.Clone(s.sessions) {
+		if session.SpaceId == spaceId {
+			s.dropSession(session.Id)
+		}
+	}
+
+	delete(s.skills, spaceId)
+	delete(s.files, spaceId)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) createSession(w http.ResponseWriter, r *http.Request) {
+	var req gomento.CreateSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if len(req.SpaceId) > 0 && s.space(req.SpaceId) < 0 {
+		writeError(w, http.StatusNotFound, "space not found")
+		return
+	}
+
+	now := time.Now().UTC()
+	session := gomento.Session{Id: uuid.New().String(), SpaceId: req.SpaceId, CreatedAt: now, UpdatedAt: now}
+	s.sessions = append(s.sessions, session)
+
+	writeJSON(w, http.StatusCreated, session)
+}
+
+func (s *Server) listSessions(w http.ResponseWriter, r *http.Request) {
+	spaceId := r.URL.Query().Get("space_id")
+
+	sessions := []gomento.Session{}
+	for _, session := range s.sessions {
+		if len(spaceId) == 0 || session.SpaceId == spaceId {
+			sessions = append(sessions, session)
+		}
+	}
+
+	writePage(w, r, sessions)
+}
+
+func (s *Server) getSession(w http.ResponseWriter, r *http.Request) {
+	i := s.session(r.PathValue("session"))
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.sessions[i])
+}
+
+func (s *Server) deleteSession(w http.ResponseWriter, r *http.Request) {
+	if !s.dropSession(r.PathValue("session")) {
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) addMessage(w http.ResponseWriter, r *http.Request) {
+	sessionId := r.PathValue("session")
+
+	i := s.session(sessionId)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	}
+
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	msg := memorymanager.Message{
+		Id:        uuid.New().String(),
+		SessionId: sessionId,
+		Role:      r.FormValue("role"),
+	}
+
+	if err := json.Unmarshal([]byte(r.FormValue("parts")), &msg.Parts); err != nil {
+		writeError(w, http.StatusBadRequest, "parts: "+err.Error())
+		return
+	}
+
+	spaceId := s.sessions[i].SpaceId
+
+	for _, headers := range r.MultipartForm.File {
+		for _, header := range headers {
+			f, err := header.Open()
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			content, err := io.ReadAll(f)
+			f.Close()
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			s.files[spaceId] = append(s.files[spaceId], &file{
+				meta:    memorymanager.File{Id: uuid.New().String(), Filename: header.Filename},
+				spaceId: spaceId,
+				content: content,
+			})
+		}
+	}
+
+	s.messages[sessionId] = append(s.messages[sessionId], &message{msg: msg})
+	s.sessions[i].UpdatedAt = time.Now().UTC()
+
+	writeJSON(w, http.StatusCreated, msg)
+}
+
+func (s *Server) listMessages(w http.ResponseWriter, r *http.Request) {
+	sessionId := r.PathValue("session")
+
+	if s.session(sessionId) < 0 {
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	}
+
+	msgs := []memorymanager.Message{}
+	for _, m := range s.messages[sessionId] {
+		msgs = append(msgs, m.msg)
+	}
+
+	writePage(w, r, msgs)
+}
+
+func (s *Server) distill(w http.ResponseWriter, r *http.Request) {
+	sessionId := r.PathValue("session")
+
+	if s.session(sessionId) < 0 {
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	}
+
+	for _, m := range s.messages[sessionId] {
+		m.distilled = true
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) putTask(w http.ResponseWriter, r *http.Request) {
+	sessionId := r.PathValue("session")
+
+	if s.session(sessionId) < 0 {
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	}
+
+	var task memorymanager.Task
+	if err := json.NewDecoder(r.Body).Decode(&task); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	task.SessionId = sessionId
+
+	tasks := s.tasks[sessionId]
+
+	if id := r.PathValue("task"); len(id) > 0 {
+		i := slices.IndexFunc(tasks, func(t memorymanager.Task) bool { return t.Id == id })
+		if i < 0 {
+			writeError(w, http.StatusNotFound, "task not found")
+			return
+		}
+		task.Id = id
+		tasks[i] = task
+		writeJSON(w, http.StatusOK, task)
+		return
+	}
+
+	if len(task.Id) == 0 {
+		task.Id = uuid.New().String()
+	}
+
+	if slices.ContainsFunc(tasks, func(t memorymanager.Task) bool { return t.Id == task.Id }) {
+		writeError(w, http.StatusConflict, "task exists")
+		return
+	}
+
+	s.tasks[sessionId] = append(tasks, task)
+
+	writeJSON(w, http.StatusCreated, task)
+}
+
+func (s *Server) listTasks(w http.ResponseWriter, r *http.Request) {
+	sessionId := r.PathValue("session")
+
+	if s.session(sessionId) < 0 {
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	}
+
+	tasks := slices.Clone(s.tasks[sessionId])
+	slices.SortStableFunc(tasks, func(a, b memorymanager.Task) int { return a.TaskOrder - b.TaskOrder })
+
+	writePage(w, r, tasks)
+}
+
+func (s *Server) getTask(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("task")
+
+	i := slices.IndexFunc(s.tasks[r.PathValue("session")], func(t memorymanager.Task) bool { return t.Id == id })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "task not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.tasks[r.PathValue("session")][i])
+}
+
+func (s *Server) deleteTask(w http.ResponseWriter, r *http.Request) {
+	sessionId := r.PathValue("session")
+	id := r.PathValue("task")
+
+	i := slices.IndexFunc(s.tasks[sessionId], func(t memorymanager.Task) bool { return t.Id == id })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "task not found")
+		return
+	}
+
+	s.tasks[sessionId] = slices.Delete(s.tasks[sessionId], i, i+1)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) putSkill(w http.ResponseWriter, r *http.Request) {
+	spaceId := r.PathValue("space")
+
+	if s.space(spaceId) < 0 {
+		writeError(w, http.StatusNotFound, "space not found")
+		return
+	}
+
+	var skill memorymanager.Skill
+	if err := json.NewDecoder(r.Body).Decode(&skill); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	skill.SpaceId = spaceId
+
+	skills := s.skills[spaceId]
+
+	if id := r.PathValue("skill"); len(id) > 0 {
+		i := slices.IndexFunc(skills, func(sk memorymanager.Skill) bool { return sk.Id == id })
+		if i < 0 {
+			writeError(w, http.StatusNotFound, "skill not found")
+			return
+		}
+		skill.Id = id
+		skills[i] = skill
+		writeJSON(w, http.StatusOK, skill)
+		return
+	}
+
+	skill.Id = uuid.New().String()
+	s.skills[spaceId] = append(skills, skill)
+
+	writeJSON(w, http.StatusCreated, skill)
+}
+
+func (s *Server) listSkills(w http.ResponseWriter, r *http.Request) {
+	spaceId := r.PathValue("space")
+
+	if s.space(spaceId) < 0 {
+		writeError(w, http.StatusNotFound, "space not found")
+		return
+	}
+
+	// with a query the same endpoint searches instead of listing
+	if r.URL.Query().Has("q") {
+		query := r.URL.Query().Get("q")
+		matches := []memorymanager.Skill{}
+		for _, skill := range s.skills[spaceId] {
+			if overlaps(query, skill.Trigger+" "+skill.SOP) {
+				matches = append(matches, skill)
+			}
+		}
+		writeJSON(w, http.StatusOK, limited(r, matches))
+		return
+	}
+
+	writePage(w, r, s.skills[spaceId])
+}
+
+func (s *Server) getSkill(w http.ResponseWriter, r *http.Request) {
+	spaceId := r.PathValue("space")
+	id := r.PathValue("skill")
+
+	i := slices.IndexFunc(s.skills[spaceId], func(sk memorymanager.Skill) bool { return sk.Id == id })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "skill not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.skills[spaceId][i])
+}
+
+func (s *Server) deleteSkill(w http.ResponseWriter, r *http.Request) {
+	spaceId := r.PathValue("space")
+	id := r.PathValue("skill")
+
+	i := slices.IndexFunc(s.skills[spaceId], func(sk memorymanager.Skill) bool { return sk.Id == id })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "skill not found")
+		return
+	}
+
+	s.skills[spaceId] = slices.Delete(s.skills[spaceId], i, i+1)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listFiles(w http.ResponseWriter, r *http.Request) {
+	spaceId := r.PathValue("space")
+
+	if s.space(spaceId) < 0 {
+		writeError(w, http.StatusNotFound, "space not found")
+		return
+	}
+
+	files := []memorymanager.File{}
+	for _, f := range s.files[spaceId] {
+		files = append(files, f.meta)
+	}
+
+	writePage(w, r, files)
+}
+
+func (s *Server) downloadFile(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("file")
+
+	for _, f := range s.files[r.PathValue("space")] {
+		if f.meta.Id == id {
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Content-Disposition", `attachment; filename="`+strings.ReplaceAll(f.meta.Filename, `"`, "")+`"`)
+			w.Write(f.content)
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, "file not found")
+}
+
+func (s *Server) searchMessages(w http.ResponseWriter, r *http.Request) {
+	spaceId := r.PathValue("space")
+	query := r.URL.Query().Get("q")
+
+	matches := []memorymanager.Message{}
+	for _, session := range s.sessions {
+		if session.SpaceId != spaceId {
+			continue
+		}
+		for _, m := range s.messages[session.Id] {
+			if m.distilled && overlaps(query, partsText(m.msg.Parts)) {
+				matches = append(matches, m.msg)
+			}
+		}
+	}
+
+	writeJSON(w, http.StatusOK, limited(r, matches))
+}
+
+func (s *Server) searchChunks(w http.ResponseWriter, r *http.Request) {
+	spaceId := r.PathValue("space")
+	query := r.URL.Query().Get("q")
+
+	// each file is a single chunk
+	matches := []memorymanager.MatchingChunk{}
+	for _, f := range s.files[spaceId] {
+		if overlaps(query, string(f.content)) {
+			matches = append(matches, memorymanager.MatchingChunk{
+				File: f.meta,
+				Chunk: memorymanager.FileChunk{
+					Id:      f.meta.Id,
+					FileId:  f.meta.Id,
+					Content: string(f.content),
+				},
+				Score: 1,
+			})
+		}
+	}
+
+	writeJSON(w, http.StatusOK, limited(r, matches))
+}
+
+func (s *Server) space(id string) int {
+	return slices.IndexFunc(s.spaces, func(sp gomento.Space) bool { return sp.Id == id })
+}
+
+func (s *Server) session(id string) int {
+	return slices.IndexFunc(s.sessions, func(se gomento.Session) bool { return se.Id == id })
+}
+
+func (s *Server) dropSession(id string) bool {
+	i := s.session(id)
+	if i < 0 {
+		return false
+	}
+
+	s.sessions = slices.Delete(s.sessions, i, i+1)
+	delete(s.messages, id)
+	delete(s.tasks, id)
+
+	return true
+}
+
+// writePage pages items with the offset as the cursor
+func writePage[T any](w http.ResponseWriter, r *http.Request, items []T) {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit < 1 {
+		limit = defaultPageSize
+	}
+
+	offset := 0
+	if cursor := r.URL.Query().Get("cursor"); len(cursor) > 0 {
+		offset, err = strconv.Atoi(cursor)
+		if err != nil || offset < 0 {
+			writeError(w, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+	}
+
+	offset = min(offset, len(items))
+	end := min(offset+limit, len(items))
+
+	page := gomento.Page[T]{Items: append([]T{}, items[offset:end]...)}
+	if end < len(items) {
+		page.NextCursor = strconv.Itoa(end)
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
+
+func limited[T any](r *http.Request, items []T) []T {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit < 1 || limit >= len(items) {
+		return items
+	}
+	return items[:limit]
+}
+
+func overlaps(query string, text string) bool {
+	words := strings.Fields(strings.ToLower(text))
+	for _, q := range strings.Fields(strings.ToLower(query)) {
+		if slices.Contains(words, q) {
+			return true
+		}
+	}
+	return false
+}
+
+func partsText(parts []memorymanager.Part) string {
+	texts := make([]string, 0, len(parts))
+	for _, p := range parts {
+		texts = append(texts, p.Text)
+	}
+	return strings.Join(texts, " ")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, gomento.ErrorResponse{Error: msg})
+}
+
+// NewServer starts the fake. Requests must carry apiKey as a bearer token
+// unless it is empty.
+func NewServer(apiKey string) *Server {
+	s := &Server{
+		apiKey:   apiKey,
+		messages: map[string][]*message{},
+		tasks:    map[string][]memorymanager.Task{},
+		skills:   map[string][]memorymanager.Skill{},
+		files:    map[string][]*file{},
+	}
+
+	s.Server = httptest.NewServer(s.routes())
+
+	return s
+}
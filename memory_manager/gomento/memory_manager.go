@@ -1,14 +1,9 @@
 package gomento
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"mime/multipart"
-	"net/http"
-	"net/url"
 	"sync"
 
 	memorymanager "github.com/w-h-a/agent/memory_manager"
@@ -16,228 +11,81 @@ import (
 
 type gomentoMemoryManager struct {
 	options       memorymanager.Options
-	client        *http.Client
+	client        Client
 	sessionSpaces map[string]string
 	mtx           sync.RWMutex
 }
 
 func (m *gomentoMemoryManager) CreateSpace(ctx context.Context, name string) (string, error) {
-	bs := []byte(fmt.Sprintf(`{"name": "%s"}`, name))
-
-	req, err := http.NewRequestWithContext(
-		ctx,
-		http.MethodPost,
-		fmt.Sprintf("%s/api/v1/spaces", m.options.Location),
-		bytes.NewReader(bs),
-	)
+	space, err := m.client.CreateSpace(ctx, name)
 	if err != nil {
 		return "", err
 	}
 
-	req.Header.Add("Content-Type", "application/json")
-
-	rsp, err := m.client.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer rsp.Body.Close()
-
-	if rsp.StatusCode >= 400 {
-		return "", fmt.Errorf("status: %s", rsp.Status)
-	}
-
-	var res struct {
-		Id string `json:"id"`
-	}
-
-	if err := json.NewDecoder(rsp.Body).Decode(&res); err != nil {
-		return "", err
-	}
-
-	return res.Id, nil
+	return space.Id, nil
 }
 
 func (m *gomentoMemoryManager) CreateSession(ctx context.Context, opts ...memorymanager.CreateSessionOption) (string, error) {
 	options := memorymanager.NewCreateSessionOptions(opts...)
 
-	bs := []byte(fmt.Sprintf(`{"space_id": "%s"}`, options.SpaceId))
-
-	req, err := http.NewRequestWithContext(
-		ctx,
-		http.MethodPost,
-		fmt.Sprintf("%s/api/v1/sessions", m.options.Location),
-		bytes.NewReader(bs),
-	)
-	if err != nil {
-		return "", err
-	}
-
-	req.Header.Add("Content-Type", "application/json")
-
-	rsp, err := m.client.Do(req)
+	session, err := m.client.CreateSession(ctx, options.SpaceId)
 	if err != nil {
 		return "", err
 	}
-	defer rsp.Body.Close()
-
-	if rsp.StatusCode >= 400 {
-		return "", fmt.Errorf("status: %s", rsp.Status)
-	}
-
-	var res struct {
-		Id string `json:"id"`
-	}
-
-	if err := json.NewDecoder(rsp.Body).Decode(&res); err != nil {
-		return "", err
-	}
 
 	m.mtx.Lock()
-	m.sessionSpaces[res.Id] = options.SpaceId
+	m.sessionSpaces[session.Id] = options.SpaceId
 	m.mtx.Unlock()
 
-	return res.Id, nil
+	return session.Id, nil
 }
 
 func (m *gomentoMemoryManager) AddShortTerm(ctx context.Context, sessionId string, role string, parts []memorymanager.Part, opts ...memorymanager.AddToShortTermOption) error {
 	options := memorymanager.NewAddToShortTermOptions(opts...)
 
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	if err := writer.WriteField("role", role); err != nil {
-		return err
-	}
-
-	partsJson, err := json.Marshal(parts)
-	if err != nil {
-		return err
-	}
-
-	if err := writer.WriteField("parts", string(partsJson)); err != nil {
-		return err
-	}
-
-	for key, file := range options.Files {
-		partWriter, err := writer.CreateFormFile(key, file.Name)
-		if err != nil {
-			return err
-		}
-		if _, err := io.Copy(partWriter, file.Reader); err != nil {
-			return err
-		}
-	}
-
-	if err := writer.Close(); err != nil {
-		return err
-	}
-
-	msgReq, err := http.NewRequestWithContext(
-		ctx,
-		http.MethodPost,
-		fmt.Sprintf("%s/api/v1/sessions/%s/messages", m.options.Location, sessionId),
-		body,
-	)
-	if err != nil {
-		return err
-	}
-
-	msgReq.Header.Add("Content-Type", writer.FormDataContentType())
-
-	msgRsp, err := m.client.Do(msgReq)
-	if err != nil {
-		return err
-	}
-	defer msgRsp.Body.Close()
-
-	if msgRsp.StatusCode >= 400 {
-		return fmt.Errorf("status: %s", msgRsp.Status)
-	}
-
-	return nil
+	return m.client.AddMessage(ctx, sessionId, role, parts, options.Files)
 }
 
 func (m *gomentoMemoryManager) ListShortTerm(ctx context.Context, sessionId string, opts ...memorymanager.ListShortTermOption) ([]memorymanager.Message, []memorymanager.Task, error) {
 	options := memorymanager.NewListShortTermOptions(opts...)
 
-	msgReq, err := http.NewRequestWithContext(
-		ctx,
-		http.MethodGet,
-		fmt.Sprintf("%s/api/v1/sessions/%s/messages?limit=%d", m.options.Location, sessionId, options.Limit),
-		nil,
-	)
+	msgs, err := Collect(ctx, func(ctx context.Context, opts ...ListOption) (Page[memorymanager.Message], error) {
+		return m.client.ListMessages(ctx, sessionId, opts...)
+	})
 	if err != nil {
 		return nil, nil, err
 	}
 
-	msgRsp, err := m.client.Do(msgReq)
-	if err != nil {
-		return nil, nil, err
-	}
-	defer msgRsp.Body.Close()
-
-	if msgRsp.StatusCode >= 400 {
-		return nil, nil, fmt.Errorf("status: %s", msgRsp.Status)
-	}
-
-	var msgRes struct {
-		Items []memorymanager.Message `json:"items"`
+	// pages run oldest first but a limit asks for the most recent messages
+	if options.Limit > 0 && len(msgs) > options.Limit {
+		msgs = msgs[len(msgs)-options.Limit:]
 	}
 
-	if err := json.NewDecoder(msgRsp.Body).Decode(&msgRes); err != nil {
-		return nil, nil, err
-	}
-
-	taskReq, err := http.NewRequestWithContext(
-		ctx,
-		http.MethodGet,
-		fmt.Sprintf("%s/api/v1/sessions/%s/tasks", m.options.Location, sessionId),
-		nil,
-	)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	taskRsp, err := m.client.Do(taskReq)
+	tasks, err := Collect(ctx, func(ctx context.Context, opts ...ListOption) (Page[memorymanager.Task], error) {
+		return m.client.ListTasks(ctx, sessionId, opts...)
+	})
 	if err != nil {
 		return nil, nil, err
 	}
-	defer taskRsp.Body.Close()
 
-	if taskRsp.StatusCode >= 400 {
-		return nil, nil, fmt.Errorf("status: %s", taskRsp.Status)
-	}
+	return msgs, tasks, nil
+}
 
-	var taskRes struct {
-		Items []memorymanager.Task `json:"items"`
+func (m *gomentoMemoryManager) UpsertTask(ctx context.Context, sessionId string, task memorymanager.Task) (memorymanager.Task, error) {
+	if len(task.Id) == 0 {
+		return m.client.CreateTask(ctx, sessionId, task)
 	}
 
-	if err := json.NewDecoder(taskRsp.Body).Decode(&taskRes); err != nil {
-		return nil, nil, err
-	}
+	return m.client.UpdateTask(ctx, sessionId, task)
+}
 
-	return msgRes.Items, taskRes.Items, nil
+func (m *gomentoMemoryManager) DeleteTask(ctx context.Context, sessionId string, taskId string) error {
+	return m.client.DeleteTask(ctx, sessionId, taskId)
 }
 
 func (m *gomentoMemoryManager) FlushToLongTerm(ctx context.Context, sessionId string) error {
-	req, err := http.NewRequestWithContext(
-		ctx,
-		http.MethodPost,
-		fmt.Sprintf("%s/api/v1/sessions/%s/distill", m.options.Location, sessionId),
-		nil,
-	)
-	if err != nil {
-		return err
-	}
-
-	rsp, err := m.client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer rsp.Body.Close()
-
-	if rsp.StatusCode >= 400 {
-		return fmt.Errorf("distill status: %s", rsp.Status)
+	if err := m.client.Distill(ctx, sessionId); err != nil {
+		return fmt.Errorf("failed to distill: %w", err)
 	}
 
 	return nil
@@ -249,12 +97,12 @@ func (m *gomentoMemoryManager) SearchLongTerm(ctx context.Context, sessionId str
 	m.mtx.Lock()
 	spaceId, exists := m.sessionSpaces[sessionId]
 	if !exists {
-		fetchedSpaceId, err := m.fetchSessionSpaceId(ctx, sessionId)
+		session, err := m.client.GetSession(ctx, sessionId)
 		if err != nil {
 			m.mtx.Unlock()
 			return nil, nil, nil, fmt.Errorf("failed to resolve session space: %w", err)
 		}
-		spaceId = fetchedSpaceId
+		spaceId = session.SpaceId
 		m.sessionSpaces[sessionId] = spaceId
 	}
 	m.mtx.Unlock()
@@ -263,17 +111,17 @@ func (m *gomentoMemoryManager) SearchLongTerm(ctx context.Context, sessionId str
 		return []memorymanager.Message{}, []memorymanager.MatchingChunk{}, []memorymanager.Skill{}, nil
 	}
 
-	msgs, err := m.searchSpaceForMessages(ctx, spaceId, query, options.Limit)
+	msgs, err := m.client.SearchMessages(ctx, spaceId, query, options.Limit)
 	if err != nil {
 		return nil, nil, nil, err
 	}
 
-	chunks, err := m.searchSpaceForChunks(ctx, spaceId, query, options.Limit)
+	chunks, err := m.client.SearchChunks(ctx, spaceId, query, options.Limit)
 	if err != nil {
 		return nil, nil, nil, err
 	}
 
-	skills, err := m.searchSpaceForSkills(ctx, spaceId, query, options.Limit)
+	skills, err := m.client.SearchSkills(ctx, spaceId, query, options.Limit)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -281,139 +129,55 @@ func (m *gomentoMemoryManager) SearchLongTerm(ctx context.Context, sessionId str
 	return msgs, chunks, skills, nil
 }
 
-func (m *gomentoMemoryManager) fetchSessionSpaceId(ctx context.Context, sessionId string) (string, error) {
-	req, err := http.NewRequestWithContext(
-		ctx,
-		http.MethodGet,
-		fmt.Sprintf("%s/api/v1/sessions/%s", m.options.Location, sessionId),
-		nil,
-	)
-	if err != nil {
-		return "", err
-	}
-
-	rsp, err := m.client.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer rsp.Body.Close()
-
-	if rsp.StatusCode >= 400 {
-		return "", fmt.Errorf("status: %s", rsp.Status)
-	}
-
-	var res struct {
-		Id      string `json:"id"`
-		SpaceId string `json:"space_id"`
-	}
-
-	if err := json.NewDecoder(rsp.Body).Decode(&res); err != nil {
-		return "", err
-	}
-
-	return res.SpaceId, nil
-}
-
-func (m *gomentoMemoryManager) searchSpaceForMessages(ctx context.Context, spaceId string, query string, limit int) ([]memorymanager.Message, error) {
-	params := url.Values{}
-	params.Add("q", query)
-	params.Add("limit", fmt.Sprintf("%d", limit))
-
-	msgReq, err := http.NewRequestWithContext(
-		ctx,
-		http.MethodGet,
-		fmt.Sprintf("%s/api/v1/spaces/%s/messages?%s", m.options.Location, spaceId, params.Encode()),
-		nil,
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	msgRsp, err := m.client.Do(msgReq)
+func (m *gomentoMemoryManager) ListSessionIds(ctx context.Context, spaceId string) ([]string, error) {
+	sessions, err := Collect(ctx, func(ctx context.Context, opts ...ListOption) (Page[Session], error) {
+		return m.client.ListSessions(ctx, spaceId, opts...)
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer msgRsp.Body.Close()
 
-	if msgRsp.StatusCode >= 400 {
-		return nil, fmt.Errorf("status: %s", msgRsp.Status)
+	ids := make([]string, 0, len(sessions))
+	for _, session := range sessions {
+		ids = append(ids, session.Id)
 	}
 
-	var msgRes []memorymanager.Message
-
-	if err := json.NewDecoder(msgRsp.Body).Decode(&msgRes); err != nil {
-		return nil, err
-	}
-
-	return msgRes, nil
+	return ids, nil
 }
 
-func (m *gomentoMemoryManager) searchSpaceForChunks(ctx context.Context, spaceId string, query string, limit int) ([]memorymanager.MatchingChunk, error) {
-	params := url.Values{}
-	params.Add("q", query)
-	params.Add("limit", fmt.Sprintf("%d", limit))
-
-	msgReq, err := http.NewRequestWithContext(
-		ctx,
-		http.MethodGet,
-		fmt.Sprintf("%s/api/v1/spaces/%s/chunks?%s", m.options.Location, spaceId, params.Encode()),
-		nil,
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	msgRsp, err := m.client.Do(msgReq)
-	if err != nil {
-		return nil, err
-	}
-	defer msgRsp.Body.Close()
-
-	if msgRsp.StatusCode >= 400 {
-		return nil, fmt.Errorf("status: %s", msgRsp.Status)
+func (m *gomentoMemoryManager) DeleteSession(ctx context.Context, sessionId string) error {
+	// already gone is as good as deleted
+	if err := m.client.DeleteSession(ctx, sessionId); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
 	}
 
-	var msgRes []memorymanager.MatchingChunk
-
-	if err := json.NewDecoder(msgRsp.Body).Decode(&msgRes); err != nil {
-		return nil, err
-	}
+	m.mtx.Lock()
+	delete(m.sessionSpaces, sessionId)
+	m.mtx.Unlock()
 
-	return msgRes, nil
+	return nil
 }
 
-func (m *gomentoMemoryManager) searchSpaceForSkills(ctx context.Context, spaceId string, query string, limit int) ([]memorymanager.Skill, error) {
-	params := url.Values{}
-	params.Add("q", query)
-	params.Add("limit", fmt.Sprintf("%d", limit))
-
-	skillReq, err := http.NewRequestWithContext(
-		ctx,
-		http.MethodGet,
-		fmt.Sprintf("%s/api/v1/spaces/%s/skills?%s", m.options.Location, spaceId, params.Encode()),
-		nil,
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	skillRsp, err := m.client.Do(skillReq)
-	if err != nil {
-		return nil, err
+func (m *gomentoMemoryManager) DeleteSpace(ctx context.Context, spaceId string) error {
+	if err := m.client.DeleteSpace(ctx, spaceId); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
 	}
-	defer skillRsp.Body.Close()
 
-	if skillRsp.StatusCode >= 400 {
-		return nil, fmt.Errorf("status: %s", skillRsp.Status)
+	m.mtx.Lock()
+	for sessionId, id := range m.sessionSpaces {
+		if id == spaceId {
+			delete(m.sessionSpaces, sessionId)
+		}
 	}
+	m.mtx.Unlock()
 
-	var skillRes []memorymanager.Skill
-
-	if err := json.NewDecoder(skillRsp.Body).Decode(&skillRes); err != nil {
-		return nil, err
-	}
+	return nil
+}
 
-	return skillRes, nil
+// Client exposes the underlying API client for the spaces, sessions, tasks,
+// skills and files the memory manager interface does not cover
+func (m *gomentoMemoryManager) Client() Client {
+	return m.client
 }
 
 func NewMemoryManager(opts ...memorymanager.Option) memorymanager.MemoryManager {
@@ -421,13 +185,10 @@ func NewMemoryManager(opts ...memorymanager.Option) memorymanager.MemoryManager
 
 	r := &gomentoMemoryManager{
 		options:       options,
+		client:        NewClient(opts...),
 		sessionSpaces: map[string]string{},
 		mtx:           sync.RWMutex{},
 	}
 
-	client := http.DefaultClient
-
-	r.client = client
-
 	return r
 }
@@ -0,0 +1,36 @@
+package gomento_test
+
+import (
+	"testing"
+
+	memorymanager "github.com/w-h-a/agent/memory_manager"
+	"github.com/w-h-a/agent/memory_manager/gomento"
+	"github.com/w-h-a/agent/memory_manager/gomento/gomentotest"
+	"github.com/w-h-a/agent/memory_manager/memorymanagertest"
+)
+
+const apiKey = "test"
+
+func TestConformance(t *testing.T) {
+	srv := gomentotest.NewServer(apiKey)
+	defer srv.Close()
+
+	memorymanagertest.Run(t, gomento.NewMemoryManager(
+		memorymanager.WithLocation(srv.URL),
+		gomento.WithApiKey(apiKey),
+	))
+}
+
+func TestContract(t *testing.T) {
+	srv := gomentotest.NewServer(apiKey)
+	defer srv.Close()
+
+	client := gomento.NewClient(
+		memorymanager.WithLocation(srv.URL),
+		gomento.WithApiKey(apiKey),
+	)
+
+	if err := gomentotest.RunContract(t.Context(), client); err != nil {
+		t.Fatal(err)
+	}
+}
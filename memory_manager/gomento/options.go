@@ -0,0 +1,95 @@
+package gomento
+
+import (
+	"context"
+	"time"
+
+	memorymanager "github.com/w-h-a/agent/memory_manager"
+)
+
+type apiKeyKey struct{}
+
+type timeoutKey struct{}
+
+type retryKey struct{}
+
+type retryConfig struct {
+	Attempts int
+	Backoff  time.Duration
+}
+
+// WithApiKey sends key as a bearer token on every request
+func WithApiKey(key string) memorymanager.Option {
+	return func(o *memorymanager.Options) {
+		o.Context = context.WithValue(o.Context, apiKeyKey{}, key)
+	}
+}
+
+func ApiKeyFrom(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(apiKeyKey{}).(string)
+	return key, ok && len(key) > 0
+}
+
+// WithTimeout bounds each attempt at a request
+func WithTimeout(timeout time.Duration) memorymanager.Option {
+	return func(o *memorymanager.Options) {
+		o.Context = context.WithValue(o.Context, timeoutKey{}, timeout)
+	}
+}
+
+func TimeoutFrom(ctx context.Context) (time.Duration, bool) {
+	timeout, ok := ctx.Value(timeoutKey{}).(time.Duration)
+	return timeout, ok && timeout > 0
+}
+
+// WithRetries retries a request that hit a network error, 429 or a
+// transient 5xx up to attempts more times, doubling backoff between each.
+// Creates are only retried on 429 since the server may have acted on them.
+func WithRetries(attempts int, backoff time.Duration) memorymanager.Option {
+	return func(o *memorymanager.Options) {
+		o.Context = context.WithValue(o.Context, retryKey{}, retryConfig{Attempts: attempts, Backoff: backoff})
+	}
+}
+
+func RetriesFrom(ctx context.Context) (retryConfig, bool) {
+	cfg, ok := ctx.Value(retryKey{}).(retryConfig)
+	if !ok {
+		return retryConfig{}, false
+	}
+
+	cfg.Attempts = max(cfg.Attempts, 0)
+	cfg.Backoff = max(cfg.Backoff, 0)
+
+	return cfg, true
+}
+
+type ListOption func(*ListOptions)
+
+type ListOptions struct {
+	Limit   int
+	Cursor  string
+	Context context.Context
+}
+
+func WithLimit(limit int) ListOption {
+	return func(o *ListOptions) {
+		o.Limit = limit
+	}
+}
+
+// WithCursor continues a listing from the NextCursor of an earlier page
+func WithCursor(cursor string) ListOption {
+	return func(o *ListOptions) {
+		o.Cursor = cursor
+	}
+}
+
+func NewListOptions(opts ...ListOption) ListOptions {
+	options := ListOptions{
+		Context: context.Background(),
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
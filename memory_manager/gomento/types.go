@@ -0,0 +1,69 @@
+package gomento
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	memorymanager "github.com/w-h-a/agent/memory_manager"
+)
+
+var (
+	ErrNotFound = errors.New("not found")
+)
+
+type Space struct {
+	Id        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Session struct {
+	Id        string    `json:"id"`
+	SpaceId   string    `json:"space_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Page is one page of a listing. NextCursor is empty on the last page.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type CreateSpaceRequest struct {
+	Name string `json:"name"`
+}
+
+type CreateSessionRequest struct {
+	SpaceId string `json:"space_id"`
+}
+
+// AddMessageRequest is the multipart form a message is posted as. Files
+// ride along as form files keyed by the field a part names.
+type AddMessageRequest struct {
+	Role  string               `json:"role"`
+	Parts []memorymanager.Part `json:"parts"`
+}
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// APIError is returned for any response with an error status
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if len(e.Message) == 0 {
+		return fmt.Sprintf("gomento: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("gomento: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+func (e *APIError) Is(target error) bool {
+	return target == ErrNotFound && e.StatusCode == http.StatusNotFound
+}
@@ -9,4 +9,6 @@ type MemoryManager interface {
 	ListShortTerm(ctx context.Context, sessionId string, opts ...ListShortTermOption) ([]Message, []Task, error)
 	FlushToLongTerm(ctx context.Context, sessionId string) error
 	SearchLongTerm(ctx context.Context, sessionId string, query string, opts ...SearchLongTermOption) ([]Message, []MatchingChunk, []Skill, error)
+	DeleteSession(ctx context.Context, sessionId string) error
+	DeleteSpace(ctx context.Context, spaceId string) error
 }
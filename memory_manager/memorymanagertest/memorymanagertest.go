@@ -0,0 +1,394 @@
+package memorymanagertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	memorymanager "github.com/w-h-a/agent/memory_manager"
+)
+
+// Run checks m against what every memory manager must do. Each case works
+// in spaces of its own and deletes them when done.
+func Run(t *testing.T, m memorymanager.MemoryManager) {
+	for _, c := range []struct {
+		name string
+		run  func(ctx context.Context, m memorymanager.MemoryManager) error
+	}{
+		{"sessions", sessions},
+		{"child sessions", childSessions},
+		{"short term ordering", shortTermOrdering},
+		{"unknown session", unknownSession},
+		{"space isolation", spaceIsolation},
+		{"search limit", searchLimit},
+		{"delete session", deleteSession},
+		{"delete space", deleteSpace},
+		{"concurrent writes", concurrentWrites},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.run(t.Context(), m); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func newSession(ctx context.Context, m memorymanager.MemoryManager) (string, string, func(), error) {
+	spaceId, err := m.CreateSpace(ctx, "conformance "+uuid.New().String())
+	if err != nil {
+		return "", "", nil, fmt.Errorf("create space: %w", err)
+	}
+
+	cleanup := func() { m.DeleteSpace(context.WithoutCancel(ctx), spaceId) }
+
+	sessionId, err := m.CreateSession(ctx, memorymanager.WithSpaceId(spaceId))
+	if err != nil {
+		cleanup()
+		return "", "", nil, fmt.Errorf("create session: %w", err)
+	}
+
+	return spaceId, sessionId, cleanup, nil
+}
+
+func sessions(ctx context.Context, m memorymanager.MemoryManager) error {
+	spaceId, first, cleanup, err := newSession(ctx, m)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	if len(spaceId) == 0 || len(first) == 0 {
+		return fmt.Errorf("create: got space %q and session %q", spaceId, first)
+	}
+
+	second, err := m.CreateSession(ctx, memorymanager.WithSpaceId(spaceId))
+	if err != nil {
+		return fmt.Errorf("create session: %w", err)
+	}
+
+	if second == first {
+		return errors.New("create session: two sessions share an id")
+	}
+
+	msgs, _, err := m.ListShortTerm(ctx, second)
+	if err != nil {
+		return fmt.Errorf("list short term: %w", err)
+	}
+
+	if len(msgs) != 0 {
+		return fmt.Errorf("list short term: new session has %d messages", len(msgs))
+	}
+
+	return nil
+}
+
+func childSessions(ctx context.Context, m memorymanager.MemoryManager) error {
+	lister, ok := m.(memorymanager.ChildSessionLister)
+	if !ok {
+		return nil
+	}
+
+	spaceId, parentId, cleanup, err := newSession(ctx, m)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	childId, err := m.CreateSession(ctx, memorymanager.WithSpaceId(spaceId), memorymanager.WithParentId(parentId))
+	if err != nil {
+		return fmt.Errorf("create child session: %w", err)
+	}
+
+	ids, err := lister.ListChildSessionIds(ctx, spaceId, parentId)
+	if err != nil {
+		return fmt.Errorf("list child sessions: %w", err)
+	}
+
+	if len(ids) != 1 || ids[0] != childId {
+		return fmt.Errorf("list child sessions: want [%s], got %v", childId, ids)
+	}
+
+	if err := m.DeleteSession(ctx, childId); err != nil {
+		return fmt.Errorf("delete session: %w", err)
+	}
+
+	ids, err = lister.ListChildSessionIds(ctx, spaceId, parentId)
+	if err != nil {
+		return fmt.Errorf("list child sessions: %w", err)
+	}
+
+	if len(ids) != 0 {
+		return fmt.Errorf("list child sessions: deleted child remains, got %v", ids)
+	}
+
+	return nil
+}
+
+func shortTermOrdering(ctx context.Context, m memorymanager.MemoryManager) error {
+	_, sessionId, cleanup, err := newSession(ctx, m)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	var want []string
+
+	for i := range 5 {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		text := fmt.Sprintf("message %d", i)
+		if err := m.AddShortTerm(ctx, sessionId, role, text2parts(text)); err != nil {
+			return fmt.Errorf("add short term: %w", err)
+		}
+		want = append(want, text)
+	}
+
+	msgs, _, err := m.ListShortTerm(ctx, sessionId)
+	if err != nil {
+		return fmt.Errorf("list short term: %w", err)
+	}
+
+	if got := texts(msgs); !slices.Equal(got, want) {
+		return fmt.Errorf("list short term: want %v, got %v", want, got)
+	}
+
+	if msgs[1].Role != "assistant" {
+		return fmt.Errorf("list short term: got role %q, want assistant", msgs[1].Role)
+	}
+
+	// a limit keeps the most recent messages, still oldest first
+	msgs, _, err = m.ListShortTerm(ctx, sessionId, memorymanager.WithShortTermLimit(2))
+	if err != nil {
+		return fmt.Errorf("list short term: %w", err)
+	}
+
+	if got := texts(msgs); !slices.Equal(got, want[3:]) {
+		return fmt.Errorf("list short term with limit 2: want %v, got %v", want[3:], got)
+	}
+
+	return nil
+}
+
+func unknownSession(ctx context.Context, m memorymanager.MemoryManager) error {
+	sessionId := uuid.New().String()
+
+	if err := m.AddShortTerm(ctx, sessionId, "user", text2parts("hello")); err == nil {
+		return errors.New("add short term: want an error for an unknown session")
+	}
+
+	if _, _, err := m.ListShortTerm(ctx, sessionId); err == nil {
+		return errors.New("list short term: want an error for an unknown session")
+	}
+
+	return nil
+}
+
+func spaceIsolation(ctx context.Context, m memorymanager.MemoryManager) error {
+	_, mine, cleanupMine, err := newSession(ctx, m)
+	if err != nil {
+		return err
+	}
+	defer cleanupMine()
+
+	_, theirs, cleanupTheirs, err := newSession(ctx, m)
+	if err != nil {
+		return err
+	}
+	defer cleanupTheirs()
+
+	secret := "the launch code is banana"
+
+	if err := remember(ctx, m, mine, secret); err != nil {
+		return err
+	}
+
+	if err := remember(ctx, m, theirs, "the weather is sunny today"); err != nil {
+		return err
+	}
+
+	msgs, _, _, err := m.SearchLongTerm(ctx, mine, "launch code banana", memorymanager.WithSearchLongTermLimit(5))
+	if err != nil {
+		return fmt.Errorf("search long term: %w", err)
+	}
+
+	if !slices.ContainsFunc(texts(msgs), func(t string) bool { return strings.Contains(t, "banana") }) {
+		return fmt.Errorf("search long term: own memory not found, got %v", texts(msgs))
+	}
+
+	msgs, _, _, err = m.SearchLongTerm(ctx, theirs, "launch code banana", memorymanager.WithSearchLongTermLimit(5))
+	if err != nil {
+		return fmt.Errorf("search long term: %w", err)
+	}
+
+	if slices.ContainsFunc(texts(msgs), func(t string) bool { return strings.Contains(t, "banana") }) {
+		return fmt.Errorf("search long term: memory leaked across spaces, got %v", texts(msgs))
+	}
+
+	return nil
+}
+
+func searchLimit(ctx context.Context, m memorymanager.MemoryManager) error {
+	_, sessionId, cleanup, err := newSession(ctx, m)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	for i := range 6 {
+		if err := m.AddShortTerm(ctx, sessionId, "user", text2parts(fmt.Sprintf("banana fact number %d", i))); err != nil {
+			return fmt.Errorf("add short term: %w", err)
+		}
+	}
+
+	if err := m.FlushToLongTerm(ctx, sessionId); err != nil {
+		return fmt.Errorf("flush to long term: %w", err)
+	}
+
+	msgs, chunks, skills, err := m.SearchLongTerm(ctx, sessionId, "banana fact", memorymanager.WithSearchLongTermLimit(2))
+	if err != nil {
+		return fmt.Errorf("search long term: %w", err)
+	}
+
+	if len(msgs) > 2 || len(chunks) > 2 || len(skills) > 2 {
+		return fmt.Errorf("search long term with limit 2: got %d messages, %d chunks and %d skills", len(msgs), len(chunks), len(skills))
+	}
+
+	return nil
+}
+
+func deleteSession(ctx context.Context, m memorymanager.MemoryManager) error {
+	spaceId, sessionId, cleanup, err := newSession(ctx, m)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	if err := remember(ctx, m, sessionId, "the launch code is banana"); err != nil {
+		return err
+	}
+
+	if err := m.DeleteSession(ctx, sessionId); err != nil {
+		return fmt.Errorf("delete session: %w", err)
+	}
+
+	if msgs, _, err := m.ListShortTerm(ctx, sessionId); err == nil && len(msgs) > 0 {
+		return fmt.Errorf("list short term: deleted session still has %d messages", len(msgs))
+	}
+
+	// memories go with the session that made them
+	other, err := m.CreateSession(ctx, memorymanager.WithSpaceId(spaceId))
+	if err != nil {
+		return fmt.Errorf("create session: %w", err)
+	}
+
+	msgs, _, _, err := m.SearchLongTerm(ctx, other, "launch code banana", memorymanager.WithSearchLongTermLimit(5))
+	if err != nil {
+		return fmt.Errorf("search long term: %w", err)
+	}
+
+	if len(msgs) > 0 {
+		return fmt.Errorf("search long term: deleted session's memories remain, got %v", texts(msgs))
+	}
+
+	return nil
+}
+
+func deleteSpace(ctx context.Context, m memorymanager.MemoryManager) error {
+	spaceId, sessionId, cleanup, err := newSession(ctx, m)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	if err := remember(ctx, m, sessionId, "the launch code is banana"); err != nil {
+		return err
+	}
+
+	if err := m.DeleteSpace(ctx, spaceId); err != nil {
+		return fmt.Errorf("delete space: %w", err)
+	}
+
+	if msgs, _, err := m.ListShortTerm(ctx, sessionId); err == nil && len(msgs) > 0 {
+		return fmt.Errorf("list short term: session of deleted space still has %d messages", len(msgs))
+	}
+
+	if err := m.DeleteSpace(ctx, spaceId); err != nil {
+		return fmt.Errorf("delete space twice: %w", err)
+	}
+
+	return nil
+}
+
+func concurrentWrites(ctx context.Context, m memorymanager.MemoryManager) error {
+	_, sessionId, cleanup, err := newSession(ctx, m)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	const writers = 8
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+
+	for i := range writers {
+		wg.Go(func() {
+			if err := m.AddShortTerm(ctx, sessionId, "user", text2parts(fmt.Sprintf("writer %d", i))); err != nil {
+				errs <- err
+			}
+		})
+	}
+
+	wg.Wait()
+	close(errs)
+
+	if err := <-errs; err != nil {
+		return fmt.Errorf("concurrent add short term: %w", err)
+	}
+
+	msgs, _, err := m.ListShortTerm(ctx, sessionId)
+	if err != nil {
+		return fmt.Errorf("list short term: %w", err)
+	}
+
+	if len(msgs) != writers {
+		return fmt.Errorf("list short term: got %d messages, want %d", len(msgs), writers)
+	}
+
+	return nil
+}
+
+func remember(ctx context.Context, m memorymanager.MemoryManager, sessionId string, text string) error {
+	if err := m.AddShortTerm(ctx, sessionId, "user", text2parts(text)); err != nil {
+		return fmt.Errorf("add short term: %w", err)
+	}
+
+	if err := m.FlushToLongTerm(ctx, sessionId); err != nil {
+		return fmt.Errorf("flush to long term: %w", err)
+	}
+
+	return nil
+}
+
+func text2parts(text string) []memorymanager.Part {
+	return []memorymanager.Part{{Type: "text", Text: text}}
+}
+
+func texts(msgs []memorymanager.Message) []string {
+	out := make([]string, 0, len(msgs))
+	for _, msg := range msgs {
+		var b strings.Builder
+		for _, part := range msg.Parts {
+			b.WriteString(part.Text)
+		}
+		out = append(out, b.String())
+	}
+	return out
+}
@@ -14,3 +14,17 @@ type Part struct {
 	FileField string         `json:"file_field,omitempty"`
 	Meta      map[string]any `json:"meta,omitempty"`
 }
+
+// SourceReflection marks the critiques and superseded drafts of an
+// answer's review. They belong in long-term memory under this tag but
+// not in the conversation window.
+const SourceReflection = "reflection"
+
+func (m Message) IsReflection() bool {
+	for _, p := range m.Parts {
+		if source, _ := p.Meta["source"].(string); source == SourceReflection {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package munin
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/w-h-a/agent/generator"
+	memorymanager "github.com/w-h-a/agent/memory_manager"
+	"github.com/w-h-a/agent/memory_manager/providers/buffer"
+)
+
+type compactor struct {
+	generator    generator.Generator
+	maxMessages  int
+	maxTokens    int
+	keepRecent   int
+	maxSummaries int
+}
+
+func (c *compactor) due(messages []memorymanager.Message) bool {
+	if len(messages) <= c.keepRecent {
+		return false
+	}
+
+	if len(messages) > c.maxMessages {
+		return true
+	}
+
+	tokens := 0
+	for _, msg := range messages {
+		tokens += estimateTokens(messageText(msg))
+	}
+
+	return c.maxTokens > 0 && tokens > c.maxTokens
+}
+
+func (c *compactor) compact(ctx context.Context, summaries []buffer.Summary, oldest []memorymanager.Message) ([]buffer.Summary, error) {
+	lines := make([]string, 0, len(oldest))
+	for _, msg := range oldest {
+		if text := strings.TrimSpace(messageText(msg)); len(text) > 0 {
+			lines = append(lines, fmt.Sprintf("%s: %s", msg.Role, text))
+		}
+	}
+
+	if len(lines) == 0 {
+		return summaries, nil
+	}
+
+	text, err := c.summarize(ctx, 0, lines)
+	if err != nil {
+		return nil, err
+	}
+
+	compacted := append(append([]buffer.Summary(nil), summaries...), buffer.Summary{
+		Level:     0,
+		Text:      text,
+		CreatedAt: time.Now().UTC(),
+	})
+
+	// once a level fills up its summaries are summarised one level higher
+	for level := 0; ; level++ {
+		var same, rest []buffer.Summary
+		for _, s := range compacted {
+			if s.Level == level {
+				same = append(same, s)
+			} else {
+				rest = append(rest, s)
+			}
+		}
+
+		if len(same) == 0 {
+			break
+		}
+
+		if len(same) <= c.maxSummaries {
+			continue
+		}
+
+		texts := make([]string, 0, len(same))
+		for _, s := range same {
+			texts = append(texts, s.Text)
+		}
+
+		text, err := c.summarize(ctx, level+1, texts)
+		if err != nil {
+			return nil, err
+		}
+
+		compacted = append(rest, buffer.Summary{
+			Level:     level + 1,
+			Text:      text,
+			CreatedAt: same[0].CreatedAt,
+		})
+	}
+
+	return compacted, nil
+}
+
+func (c *compactor) summarize(ctx context.Context, level int, lines []string) (string, error) {
+	var sb strings.Builder
+	if level == 0 {
+		sb.WriteString("Summarise the following conversation excerpt for the assistant's future reference.\n")
+	} else {
+		sb.WriteString("Combine the following summaries of earlier conversation into a single summary for the assistant's future reference.\n")
+	}
+	sb.WriteString("Keep facts, decisions, open questions, commitments, names and exact identifiers. Drop pleasantries. Reply with the summary only.\n\n")
+	for _, line := range lines {
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+
+	rsp, err := c.generator.Generate(ctx, sb.String())
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(rsp), nil
+}
+
+func pinnedSummaries(summaries []buffer.Summary) []memorymanager.Message {
+	ordered := append([]buffer.Summary(nil), summaries...)
+
+	// broadest and oldest first
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].Level != ordered[j].Level {
+			return ordered[i].Level > ordered[j].Level
+		}
+		return ordered[i].CreatedAt.Before(ordered[j].CreatedAt)
+	})
+
+	msgs := make([]memorymanager.Message, 0, len(ordered))
+	for _, s := range ordered {
+		msgs = append(msgs, memorymanager.Message{
+			Role: "summary",
+			Parts: []memorymanager.Part{
+				{
+					Type: "text",
+					Text: s.Text,
+					Meta: map[string]any{"summary_level": s.Level},
+				},
+			},
+		})
+	}
+
+	return msgs
+}
+
+func messageText(msg memorymanager.Message) string {
+	var sb strings.Builder
+	for _, p := range msg.Parts {
+		sb.WriteString(p.Text)
+	}
+	return sb.String()
+}
+
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
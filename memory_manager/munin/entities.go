@@ -0,0 +1,264 @@
+package munin
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/w-h-a/agent/generator"
+	memorymanager "github.com/w-h-a/agent/memory_manager"
+	"github.com/w-h-a/agent/memory_manager/providers/embedder"
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+	extractjson "github.com/w-h-a/agent/util/extract_json"
+)
+
+const (
+	entityCandidates = 3
+)
+
+type entityKeeper struct {
+	storer     storer.EntityStorer
+	embedder   embedder.Embedder
+	generator  generator.Generator
+	similarity float64
+}
+
+type extractedEntity struct {
+	Name       string         `json:"name"`
+	Kind       string         `json:"kind"`
+	Aliases    []string       `json:"aliases"`
+	Attributes map[string]any `json:"attributes"`
+}
+
+type extractedRelation struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Type   string `json:"type"`
+}
+
+func (k *entityKeeper) extract(ctx context.Context, spaceId string, transcript []string) error {
+	if len(transcript) == 0 {
+		return nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Extract the named entities (people, projects, services, organizations, tools, places) from the conversation below.\n\n")
+	sb.WriteString("Conversation:\n")
+	for _, line := range transcript {
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\nFor every entity give its canonical name, a lowercase kind, any aliases used for it and the attributes stated about it.\n")
+	sb.WriteString("Also list the relations stated between the entities using short uppercase verbs such as WORKS_ON, OWNS, DEPENDS_ON or PART_OF.\n")
+	sb.WriteString("Reply with only JSON of the form ")
+	sb.WriteString(`{"entities": [{"name": "Ada", "kind": "person", "aliases": ["ada l"], "attributes": {"role": "engineer"}}], "relations": [{"source": "Ada", "target": "payments", "type": "WORKS_ON"}]}`)
+	sb.WriteString(".\n")
+
+	rsp, err := k.generator.Generate(ctx, sb.String())
+	if err != nil {
+		return err
+	}
+
+	var extracted struct {
+		Entities  []extractedEntity   `json:"entities"`
+		Relations []extractedRelation `json:"relations"`
+	}
+
+	if err := extractjson.Into(rsp, &extracted); err != nil {
+		slog.WarnContext(ctx, "failed to parse extracted entities", "error", err)
+		return nil
+	}
+
+	ids := map[string]string{}
+
+	for _, e := range extracted.Entities {
+		e.Name = strings.TrimSpace(e.Name)
+		if len(e.Name) == 0 {
+			continue
+		}
+
+		id, err := k.resolve(ctx, spaceId, e)
+		if err != nil {
+			return err
+		}
+
+		ids[entityKey(e.Name)] = id
+		for _, alias := range e.Aliases {
+			if key := entityKey(alias); len(key) > 0 {
+				ids[key] = id
+			}
+		}
+	}
+
+	for _, rel := range extracted.Relations {
+		// relations may mention entities the model did not list
+		for _, name := range []string{rel.Source, rel.Target} {
+			key := entityKey(name)
+			if _, ok := ids[key]; ok || len(key) == 0 {
+				continue
+			}
+			id, err := k.resolve(ctx, spaceId, extractedEntity{Name: strings.TrimSpace(name)})
+			if err != nil {
+				return err
+			}
+			ids[key] = id
+		}
+
+		sourceId, ok := ids[entityKey(rel.Source)]
+		if !ok {
+			continue
+		}
+		targetId, ok := ids[entityKey(rel.Target)]
+		if !ok || sourceId == targetId {
+			continue
+		}
+
+		if err := k.storer.Relate(ctx, spaceId, storer.Relation{
+			SourceId: sourceId,
+			TargetId: targetId,
+			Type:     rel.Type,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (k *entityKeeper) resolve(ctx context.Context, spaceId string, e extractedEntity) (string, error) {
+	vec, err := k.embedder.Embed(ctx, EntityText(e.Name, e.Kind, e.Aliases))
+	if err != nil {
+		return "", err
+	}
+
+	candidates, err := k.storer.SearchEntities(ctx, spaceId, vec, entityCandidates)
+	if err != nil {
+		return "", err
+	}
+
+	for _, cand := range candidates {
+		if !k.same(e, cand, vec) {
+			continue
+		}
+
+		// fold the new mention into the entity we already know
+		if !strings.EqualFold(cand.Name, e.Name) && !containsFold(cand.Aliases, e.Name) {
+			cand.Aliases = append(cand.Aliases, e.Name)
+		}
+		for _, alias := range e.Aliases {
+			if !strings.EqualFold(cand.Name, alias) && !containsFold(cand.Aliases, alias) {
+				cand.Aliases = append(cand.Aliases, alias)
+			}
+		}
+		if len(cand.Kind) == 0 {
+			cand.Kind = e.Kind
+		}
+		if cand.Attributes == nil {
+			cand.Attributes = map[string]any{}
+		}
+		maps.Copy(cand.Attributes, e.Attributes)
+
+		if len(cand.Embedding) == 0 {
+			cand.Embedding = vec
+		}
+
+		return k.storer.UpsertEntity(ctx, spaceId, cand)
+	}
+
+	return k.storer.UpsertEntity(ctx, spaceId, storer.Entity{
+		Name:       e.Name,
+		Kind:       strings.ToLower(strings.TrimSpace(e.Kind)),
+		Aliases:    e.Aliases,
+		Attributes: e.Attributes,
+		Embedding:  vec,
+	})
+}
+
+func (k *entityKeeper) same(e extractedEntity, cand storer.Entity, vec []float32) bool {
+	if len(e.Kind) > 0 && len(cand.Kind) > 0 && !strings.EqualFold(e.Kind, cand.Kind) {
+		return false
+	}
+
+	names := append([]string{e.Name}, e.Aliases...)
+	for _, name := range names {
+		if strings.EqualFold(name, cand.Name) || containsFold(cand.Aliases, name) {
+			return true
+		}
+	}
+
+	sim := float64(cand.Score)
+	if len(cand.Embedding) > 0 {
+		sim = memorymanager.CosineSimilarity(vec, cand.Embedding)
+	}
+
+	return sim >= k.similarity
+}
+
+func (k *entityKeeper) search(ctx context.Context, spaceId string, vec []float32, limit int, hops int, linkedLimit int) (memorymanager.EntityGraph, error) {
+	graph := memorymanager.EntityGraph{}
+
+	seeds, err := k.storer.SearchEntities(ctx, spaceId, vec, limit)
+	if err != nil {
+		return graph, err
+	}
+
+	if len(seeds) == 0 {
+		return graph, nil
+	}
+
+	seedIds := make([]string, 0, len(seeds))
+	for _, seed := range seeds {
+		seedIds = append(seedIds, seed.Id)
+	}
+
+	entities, relations, err := k.storer.SearchEntityGraph(ctx, spaceId, seedIds, hops, limit+linkedLimit)
+	if err != nil {
+		return graph, err
+	}
+
+	for _, entity := range entities {
+		graph.Entities = append(graph.Entities, memorymanager.Entity{
+			Id:         entity.Id,
+			SpaceId:    entity.SpaceId,
+			Name:       entity.Name,
+			Kind:       entity.Kind,
+			Aliases:    entity.Aliases,
+			Attributes: entity.Attributes,
+		})
+	}
+
+	for _, rel := range relations {
+		graph.Relations = append(graph.Relations, memorymanager.Relation{
+			SourceId: rel.SourceId,
+			TargetId: rel.TargetId,
+			Type:     rel.Type,
+		})
+	}
+
+	return graph, nil
+}
+
+// EntityText is what an entity's embedding is computed from
+func EntityText(name string, kind string, aliases []string) string {
+	text := name
+	if len(kind) > 0 {
+		text = fmt.Sprintf("%s (%s)", name, kind)
+	}
+	if len(aliases) > 0 {
+		text = fmt.Sprintf("%s also known as %s", text, strings.Join(aliases, ", "))
+	}
+	return text
+}
+
+func entityKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func containsFold(values []string, v string) bool {
+	return slices.ContainsFunc(values, func(s string) bool {
+		return strings.EqualFold(s, v)
+	})
+}
@@ -0,0 +1,133 @@
+package munin
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/w-h-a/agent/generator"
+	memorymanager "github.com/w-h-a/agent/memory_manager"
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+	extractjson "github.com/w-h-a/agent/util/extract_json"
+)
+
+const (
+	EdgeFollows     = "FOLLOWS"
+	EdgeRepliesTo   = "REPLIES_TO"
+	EdgeSimilarTo   = "SIMILAR_TO"
+	EdgeAbout       = "ABOUT"
+	EdgeContradicts = "CONTRADICTS"
+	EdgeSupports    = "SUPPORTS"
+)
+
+var extractedEdgeTypes = map[string]struct{}{
+	EdgeAbout:       {},
+	EdgeContradicts: {},
+	EdgeSupports:    {},
+}
+
+type linker struct {
+	generator  generator.Generator
+	similarity float64
+}
+
+type linkInput struct {
+	role         string
+	content      string
+	vector       []float32
+	candidates   []storer.Record
+	previousId   string
+	previousRole string
+}
+
+func (l *linker) link(ctx context.Context, in linkInput) []map[string]string {
+	edges := []map[string]string{}
+
+	// temporal adjacency within the session
+	if len(in.previousId) > 0 {
+		t := EdgeFollows
+		if in.previousRole == "user" && in.role == "assistant" {
+			t = EdgeRepliesTo
+		}
+		edges = append(edges, newEdge(in.previousId, t))
+	}
+
+	// semantic similarity to prior memories
+	related := make([]storer.Record, 0, len(in.candidates))
+	for _, cand := range in.candidates {
+		if cand.Id == in.previousId {
+			continue
+		}
+		sim := float64(cand.Score)
+		if len(cand.Embedding) > 0 {
+			sim = memorymanager.CosineSimilarity(in.vector, cand.Embedding)
+		}
+		if sim >= l.similarity {
+			edges = append(edges, newEdge(cand.Id, EdgeSimilarTo))
+		}
+		related = append(related, cand)
+	}
+
+	// entity relations judged by the model
+	if l.generator != nil && len(related) > 0 {
+		extracted, err := l.extract(ctx, in.content, related)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to extract memory relations", "error", err)
+		}
+		edges = append(edges, extracted...)
+	}
+
+	return edges
+}
+
+func (l *linker) extract(ctx context.Context, content string, candidates []storer.Record) ([]map[string]string, error) {
+	var sb strings.Builder
+	sb.WriteString("You link a new memory to existing memories in a knowledge graph.\n\n")
+	sb.WriteString("New memory:\n")
+	sb.WriteString(content)
+	sb.WriteString("\n\nExisting memories:\n")
+	for i, cand := range candidates {
+		sb.WriteString(fmt.Sprintf("%d. %s\n", i, cand.Content))
+	}
+	sb.WriteString("\nFor each existing memory that shares an entity or subject with the new memory, pick one relation:\n")
+	sb.WriteString("- ABOUT: the new memory discusses the same person, project, service or topic\n")
+	sb.WriteString("- CONTRADICTS: the new memory conflicts with or corrects the existing memory\n")
+	sb.WriteString("- SUPPORTS: the new memory confirms or adds evidence to the existing memory\n")
+	sb.WriteString("Reply with only a JSON array such as [{\"index\": 0, \"type\": \"ABOUT\"}]. Reply with [] if none apply.\n")
+
+	rsp, err := l.generator.Generate(ctx, sb.String())
+	if err != nil {
+		return nil, err
+	}
+
+	var relations []struct {
+		Index int    `json:"index"`
+		Type  string `json:"type"`
+	}
+
+	if err := extractjson.Into(rsp, &relations); err != nil {
+		return nil, err
+	}
+
+	edges := []map[string]string{}
+	for _, rel := range relations {
+		if rel.Index < 0 || rel.Index >= len(candidates) {
+			continue
+		}
+		t := storer.SanitizeType(rel.Type)
+		if _, ok := extractedEdgeTypes[t]; !ok {
+			continue
+		}
+		edges = append(edges, newEdge(candidates[rel.Index].Id, t))
+	}
+
+	return edges, nil
+}
+
+func newEdge(target string, t string) map[string]string {
+	return map[string]string{
+		"target": target,
+		"type":   t,
+	}
+}
@@ -1,32 +1,53 @@
 package munin
 
 import (
+	"cmp"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"maps"
 	"math"
 	"sort"
 	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 
+	"github.com/google/uuid"
 	memorymanager "github.com/w-h-a/agent/memory_manager"
+	"github.com/w-h-a/agent/memory_manager/providers/buffer"
+	memorybuffer "github.com/w-h-a/agent/memory_manager/providers/buffer/memory"
+	"github.com/w-h-a/agent/memory_manager/providers/embedder"
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+)
+
+const (
+	linkCandidates = 5
+	rrfK           = 60
 )
 
 type muninMemoryManager struct {
-	options        memorymanager.Options
-	spaceCounter   atomic.Uint64
-	sessionCounter atomic.Uint64
-	shortTerm      map[string]*sessionBuffer
-	mtx            sync.RWMutex
+	options    memorymanager.Options
+	buffer     buffer.Buffer
+	linker     *linker
+	entities   *entityKeeper
+	compactor  *compactor
+	compacting map[string]struct{}
+	mtx        sync.Mutex
+	// spaces moved onto another embedder than the default
+	embedders map[string]embedder.Embedder
+	// registered embedders by the model they embed with
+	models   map[string]embedder.Embedder
+	embedMtx sync.RWMutex
 }
 
 func (m *muninMemoryManager) CreateSpace(ctx context.Context, name string) (string, error) {
-	id := fmt.Sprintf("space-%d", m.spaceCounter.Add(1))
+	id := uuid.New().String()
 
-	m.mtx.Lock()
-	defer m.mtx.Unlock()
+	if err := m.buffer.CreateSpace(ctx, buffer.Space{Id: id, Name: name}); err != nil {
+		return "", err
+	}
 
 	return id, nil
 }
@@ -34,34 +55,154 @@ func (m *muninMemoryManager) CreateSpace(ctx context.Context, name string) (stri
 func (m *muninMemoryManager) CreateSession(ctx context.Context, opts ...memorymanager.CreateSessionOption) (string, error) {
 	options := memorymanager.NewCreateSessionOptions(opts...)
 
-	id := fmt.Sprintf("session-%s-%d", options.SpaceId, m.sessionCounter.Add(1))
-
-	m.mtx.Lock()
-	defer m.mtx.Unlock()
+	id := uuid.New().String()
 
-	m.shortTerm[id] = &sessionBuffer{
-		spaceId:  options.SpaceId,
-		messages: []memorymanager.Message{},
+	if err := m.buffer.CreateSession(ctx, buffer.Session{Id: id, SpaceId: options.SpaceId, ParentId: options.ParentId}); err != nil {
+		return "", err
 	}
 
 	return id, nil
 }
 
 func (m *muninMemoryManager) AddShortTerm(ctx context.Context, sessionId string, role string, parts []memorymanager.Part, opts ...memorymanager.AddToShortTermOption) error {
+	if _, err := m.session(ctx, sessionId); err != nil {
+		return err
+	}
+
+	msg := memorymanager.Message{
+		Id:        uuid.New().String(),
+		SessionId: sessionId,
+		Role:      role,
+		Parts:     parts,
+	}
+
+	// reflection is kept for the record rather than the conversation, so it
+	// goes straight to long-term memory and never takes a place in the window
+	if msg.IsReflection() {
+		return m.archive(ctx, sessionId, []memorymanager.Message{msg})
+	}
+
+	raw, err := json.Marshal(parts)
+	if err != nil {
+		return err
+	}
+
+	if err := m.buffer.AddMessage(ctx, buffer.Message{
+		Id:        msg.Id,
+		SessionId: sessionId,
+		Role:      role,
+		Parts:     raw,
+	}); err != nil {
+		return err
+	}
+
+	// the message is stored, so keeping the window in shape cannot fail the add
+	oldest := m.dueForCompaction(ctx, sessionId)
+
+	if err := m.buffer.TrimMessages(ctx, sessionId, m.options.SessionWindowSize); err != nil {
+		slog.WarnContext(ctx, "failed to trim session window", "session", sessionId, "error", err)
+	}
+
+	if len(oldest) == 0 {
+		return nil
+	}
+
+	// summarising calls out to the model, so it runs apart from the add and
+	// outlives the request that triggered it
+	go func(ctx context.Context) {
+		defer func() {
+			m.mtx.Lock()
+			delete(m.compacting, sessionId)
+			m.mtx.Unlock()
+		}()
+
+		if err := m.compact(ctx, sessionId, oldest); err != nil {
+			slog.ErrorContext(ctx, "failed to compact session", "session", sessionId, "error", err)
+		}
+	}(context.WithoutCancel(ctx))
+
+	return nil
+}
+
+// dueForCompaction returns the messages to fold into the session's
+// summaries, marking the session as compacting until they are. It returns
+// nothing when the session needs no compaction or is already compacting.
+// The trim that follows may drop them from the window, but compacting
+// still archives them.
+func (m *muninMemoryManager) dueForCompaction(ctx context.Context, sessionId string) []memorymanager.Message {
+	if m.compactor == nil {
+		return nil
+	}
+
 	m.mtx.Lock()
 	defer m.mtx.Unlock()
 
-	buffer, exists := m.shortTerm[sessionId]
-	if !exists {
-		return fmt.Errorf("session %s not found", sessionId)
+	if _, ok := m.compacting[sessionId]; ok {
+		return nil
 	}
 
-	buffer.messages = append(buffer.messages, memorymanager.Message{
-		SessionId: sessionId, Role: role, Parts: parts,
-	})
+	stored, err := m.buffer.ListMessages(ctx, sessionId, 0)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to check session for compaction", "session", sessionId, "error", err)
+		return nil
+	}
+
+	messages, err := fromBufferMessages(stored)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to check session for compaction", "session", sessionId, "error", err)
+		return nil
+	}
+
+	if !m.compactor.due(messages) {
+		return nil
+	}
+
+	m.compacting[sessionId] = struct{}{}
+
+	keep := min(m.compactor.keepRecent, len(messages))
+
+	return messages[:len(messages)-keep]
+}
+
+func (m *muninMemoryManager) compact(ctx context.Context, sessionId string, oldest []memorymanager.Message) error {
+	session, err := m.session(ctx, sessionId)
+	if err != nil {
+		return err
+	}
+
+	compacted, err := m.compactor.compact(ctx, session.Summaries, oldest)
+	if err == nil {
+		// re-read so a concurrent flush does not lose its place in the chain
+		session, err = m.session(ctx, sessionId)
+		if err != nil {
+			return err
+		}
+
+		session.Summaries = compacted
+
+		if err := m.buffer.UpdateSession(ctx, session); err != nil {
+			return err
+		}
+
+		ids := make([]string, 0, len(oldest))
+		for _, msg := range oldest {
+			ids = append(ids, msg.Id)
+		}
+
+		if err := m.buffer.DeleteMessages(ctx, sessionId, ids); err != nil {
+			return err
+		}
+	} else if trimErr := m.buffer.TrimMessages(ctx, sessionId, m.options.SessionWindowSize); trimErr != nil {
+		return trimErr
+	}
+
+	// the originals live on in long-term memory even if summarising failed
+	if archiveErr := m.archive(ctx, sessionId, oldest); archiveErr != nil {
+		return archiveErr
+	}
 
-	if len(buffer.messages) > m.options.SessionWindowSize {
-		buffer.messages = buffer.messages[len(buffer.messages)-m.options.SessionWindowSize:]
+	if err != nil {
+		return fmt.Errorf("failed to compact session %s: %w", sessionId, err)
 	}
 
 	return nil
@@ -70,64 +211,181 @@ func (m *muninMemoryManager) AddShortTerm(ctx context.Context, sessionId string,
 func (m *muninMemoryManager) ListShortTerm(ctx context.Context, sessionId string, opts ...memorymanager.ListShortTermOption) ([]memorymanager.Message, []memorymanager.Task, error) {
 	options := memorymanager.NewListShortTermOptions(opts...)
 
-	m.mtx.RLock()
-	defer m.mtx.RUnlock()
+	session, err := m.session(ctx, sessionId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stored, err := m.buffer.ListMessages(ctx, sessionId, options.Limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	messages, err := fromBufferMessages(stored)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// summaries of compacted history stay pinned ahead of the recent messages
+	if len(session.Summaries) > 0 {
+		messages = append(pinnedSummaries(session.Summaries), messages...)
+	}
+
+	storedTasks, err := m.buffer.ListTasks(ctx, sessionId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tasks := make([]memorymanager.Task, 0, len(storedTasks))
+	for _, t := range storedTasks {
+		tasks = append(tasks, memorymanager.Task{
+			Id:        t.Id,
+			SessionId: t.SessionId,
+			TaskOrder: t.TaskOrder,
+			Data:      t.Data,
+			Status:    t.Status,
+		})
+	}
+
+	return messages, tasks, nil
+}
+
+func (m *muninMemoryManager) UpsertTask(ctx context.Context, sessionId string, task memorymanager.Task) (memorymanager.Task, error) {
+	if _, err := m.session(ctx, sessionId); err != nil {
+		return memorymanager.Task{}, err
+	}
 
-	buffer, exists := m.shortTerm[sessionId]
-	if !exists {
-		return nil, nil, fmt.Errorf("session %s not found", sessionId)
+	if len(task.Id) == 0 {
+		task.Id = uuid.New().String()
 	}
 
-	copied := make([]memorymanager.Message, len(buffer.messages))
-	copy(copied, buffer.messages)
+	task.SessionId = sessionId
 
-	if len(copied) > options.Limit {
-		copied = copied[len(copied)-options.Limit:]
+	if err := m.buffer.UpsertTask(ctx, buffer.Task{
+		Id:        task.Id,
+		SessionId: task.SessionId,
+		TaskOrder: task.TaskOrder,
+		Data:      task.Data,
+		Status:    task.Status,
+	}); err != nil {
+		return memorymanager.Task{}, err
 	}
 
-	return copied, nil, nil
+	return task, nil
+}
+
+func (m *muninMemoryManager) DeleteTask(ctx context.Context, sessionId string, taskId string) error {
+	if _, err := m.session(ctx, sessionId); err != nil {
+		return err
+	}
+
+	return m.buffer.DeleteTask(ctx, sessionId, taskId)
 }
 
 func (m *muninMemoryManager) FlushToLongTerm(ctx context.Context, sessionId string) error {
-	m.mtx.RLock()
-	buffer, exists := m.shortTerm[sessionId]
-	var history []memorymanager.Message
-	var spaceId string
-	if exists {
-		history = buffer.messages
-		spaceId = buffer.spaceId
+	if _, err := m.session(ctx, sessionId); err != nil {
+		return err
+	}
+
+	stored, err := m.buffer.ListMessages(ctx, sessionId, 0)
+	if err != nil {
+		return err
+	}
+
+	history, err := fromBufferMessages(stored)
+	if err != nil {
+		return err
+	}
+
+	return m.archive(ctx, sessionId, history)
+}
+
+func (m *muninMemoryManager) DeleteExpired(ctx context.Context) (int, error) {
+	expired, err := m.options.Storer.DeleteExpired(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if expired > 0 {
+		slog.InfoContext(ctx, "expired memories", "count", expired)
 	}
-	m.mtx.RUnlock()
 
-	if !exists {
-		return fmt.Errorf("session %s not found", sessionId)
+	return expired, nil
+}
+
+func (m *muninMemoryManager) archive(ctx context.Context, sessionId string, history []memorymanager.Message) error {
+	session, err := m.session(ctx, sessionId)
+	if err != nil {
+		return err
 	}
 
 	if len(history) == 0 {
 		return nil
 	}
 
-	for _, msg := range history {
-		var sb strings.Builder
-		for _, p := range msg.Parts {
-			sb.WriteString(p.Text)
+	spaceId := session.SpaceId
+	previousId := session.LastRecordId
+	previousRole := session.LastRole
+
+	defer func() {
+		if previousId == session.LastRecordId && previousRole == session.LastRole {
+			return
+		}
+		// remember where the chain ended so the next flush links onto it
+		if session, err := m.session(ctx, sessionId); err == nil {
+			session.LastRecordId = previousId
+			session.LastRole = previousRole
+			if err := m.buffer.UpdateSession(ctx, session); err != nil {
+				slog.WarnContext(ctx, "failed to record last archived memory", "session", sessionId, "error", err)
+			}
 		}
-		raw := sb.String()
+	}()
+
+	saved := []string{}
+
+	m.embedMtx.RLock()
+	defer m.embedMtx.RUnlock()
+
+	emb, err := m.embedderFor(ctx, spaceId)
+	if err != nil {
+		return err
+	}
+
+	scope := scoped(nil, emb.Model())
+
+	msgs := []memorymanager.Message{}
+	contents := []string{}
+
+	for _, msg := range history {
+		raw := messageText(msg)
 		if len(strings.TrimSpace(raw)) == 0 {
 			continue
 		}
+		msgs = append(msgs, msg)
+		contents = append(contents, fmt.Sprintf("%s: %s", msg.Role, raw))
+	}
 
-		content := fmt.Sprintf("%s: %s", msg.Role, raw)
+	if len(contents) == 0 {
+		return nil
+	}
 
-		vec, err := m.options.Embedder.Embed(ctx, content)
-		if err != nil {
-			return err
-		}
+	// one round-trip for the whole flush
+	vectors, err := emb.EmbedBatch(ctx, contents)
+	if err != nil {
+		return err
+	}
+
+	for i, msg := range msgs {
+		content := contents[i]
+		vec := vectors[i]
+
+		// reflection is remembered beside the conversation, not in its chain
+		reflection := msg.IsReflection()
 
 		// no matter what the similarity score is from storer
 		// check cosinesimilarity and skip if we already have good matches
 		// unless the current best match is old
-		candidates, _ := m.options.Storer.Search(ctx, spaceId, vec, 1)
+		candidates, _ := m.options.Storer.Search(ctx, spaceId, vec, linkCandidates, scope)
 		shouldSave := true
 
 		if len(candidates) > 0 {
@@ -142,6 +400,11 @@ func (m *muninMemoryManager) FlushToLongTerm(ctx context.Context, sessionId stri
 		}
 
 		if !shouldSave {
+			// keep the session chain intact through the memory we already have
+			if !reflection {
+				previousId = candidates[0].Id
+				previousRole = msg.Role
+			}
 			continue
 		}
 
@@ -151,8 +414,41 @@ func (m *muninMemoryManager) FlushToLongTerm(ctx context.Context, sessionId stri
 		for _, p := range msg.Parts {
 			maps.Copy(meta, p.Meta)
 		}
+		meta[storer.FieldEmbeddingModel] = emb.Model()
+
+		input := linkInput{
+			role:       msg.Role,
+			content:    content,
+			vector:     vec,
+			candidates: candidates,
+		}
+		if !reflection {
+			input.previousId = previousId
+			input.previousRole = previousRole
+		}
+
+		edges := m.linker.link(ctx, input)
+		if len(edges) > 0 {
+			meta["edges"] = edges
+		}
+
+		id, err := m.options.Storer.Store(ctx, spaceId, sessionId, content, meta, vec)
+		if err != nil {
+			return err
+		}
+
+		if reflection {
+			continue
+		}
+
+		previousId = id
+		previousRole = msg.Role
+
+		saved = append(saved, content)
+	}
 
-		if err := m.options.Storer.Store(ctx, spaceId, sessionId, content, meta, vec); err != nil {
+	if m.entities != nil && m.entities.generator != nil {
+		if err := m.entities.extract(ctx, spaceId, saved); err != nil {
 			return err
 		}
 	}
@@ -163,28 +459,69 @@ func (m *muninMemoryManager) FlushToLongTerm(ctx context.Context, sessionId stri
 func (m *muninMemoryManager) SearchLongTerm(ctx context.Context, sessionId string, query string, opts ...memorymanager.SearchLongTermOption) ([]memorymanager.Message, []memorymanager.MatchingChunk, []memorymanager.Skill, error) {
 	options := memorymanager.NewSearchOptions(opts...)
 
-	m.mtx.RLock()
-	buffer, exists := m.shortTerm[sessionId]
-	var spaceId string
-	if exists {
-		spaceId = buffer.spaceId
+	session, err := m.session(ctx, sessionId)
+	if err != nil {
+		return nil, nil, nil, err
 	}
-	m.mtx.RUnlock()
 
-	if !exists {
-		return nil, nil, nil, fmt.Errorf("session %s not found", sessionId)
+	spaceId := session.SpaceId
+
+	m.embedMtx.RLock()
+	defer m.embedMtx.RUnlock()
+
+	emb, err := m.embedderFor(ctx, spaceId)
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
-	vec, err := m.options.Embedder.Embed(ctx, query)
+	vec, err := emb.Embed(ctx, query)
 	if err != nil {
 		return nil, nil, nil, err
 	}
 
-	candidates, err := m.options.Storer.Search(ctx, spaceId, vec, options.Limit*4)
+	// vectors from another model are never compared with the query
+	filter := scoped(options.Filter, emb.Model())
+
+	candidates, err := m.options.Storer.Search(ctx, spaceId, vec, options.Limit*4, filter)
 	if err != nil {
 		return nil, nil, nil, err
 	}
 
+	// exact identifiers are often missed by embeddings so keyword hits are fused in
+	if ls, ok := m.options.Storer.(storer.LexicalSearcher); ok {
+		lexical, err := ls.SearchLexical(ctx, spaceId, query, options.Limit*4, filter)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if len(lexical) > 0 {
+			// fusion picks and orders the candidates but its scores are ranks, so
+			// they are rescored on the cosine scale linked neighbors are blended on
+			candidates = memorymanager.FuseReciprocalRank(rrfK, candidates, lexical)
+			if len(candidates) > options.Limit*4 {
+				candidates = candidates[:options.Limit*4]
+			}
+			for i := range candidates {
+				candidates[i].Score = similarity(vec, candidates[i])
+			}
+		}
+	}
+
+	if options.EntityGraph != nil && m.entities != nil {
+		// entities are only ever embedded by the default embedder
+		entityVec := vec
+		if emb != m.options.Embedder {
+			entityVec, err = m.options.Embedder.Embed(ctx, query)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+		}
+		graph, err := m.entities.search(ctx, spaceId, entityVec, options.Limit, options.LinkedMemoriesHops, options.LinkedMemoriesLimit)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		*options.EntityGraph = graph
+	}
+
 	seen := map[string]int{}
 	seedIds := make([]string, 0, len(candidates))
 
@@ -193,33 +530,38 @@ func (m *muninMemoryManager) SearchLongTerm(ctx context.Context, sessionId strin
 		seedIds = append(seedIds, rec.Id)
 	}
 
-	neighbors, err := m.options.Storer.SearchNeighborhood(ctx, seedIds, options.LinkedMemoriesLimit, options.LinkedMemoriesLimit)
+	neighbors, err := m.options.Storer.SearchNeighborhood(ctx, seedIds, options.LinkedMemoriesHops, options.LinkedMemoriesLimit, filter)
 	if err != nil {
 		return nil, nil, nil, err
 	}
 
 	for _, rec := range neighbors {
 		if i, exists := seen[rec.Id]; exists {
-			if candidates[i].Metadata == nil {
-				candidates[i].Metadata = map[string]any{}
-			}
-			candidates[i].Metadata["_linked"] = true
+			candidates[i].Metadata = withMeta(candidates[i].Metadata, "_linked", true)
 			continue
 		}
-		if len(rec.Embedding) > 0 {
-			rec.Score = float32(memorymanager.CosineSimilarity(vec, rec.Embedding))
-		} else {
-			rec.Score = 0.5
-		}
-		if rec.Metadata == nil {
-			rec.Metadata = map[string]any{}
-			rec.Metadata["_linked"] = true
-		}
+		rec.Score = similarity(vec, rec)
+		rec.Metadata = withMeta(rec.Metadata, "_linked", true)
 		candidates = append(candidates, rec)
 		seen[rec.Id] = len(candidates) - 1
 	}
 
-	sim, rec := memorymanager.NormalizeWeights(m.options.Weights)
+	var reranked []float64
+	if m.options.Reranker != nil && len(candidates) > 0 {
+		scores, err := m.options.Reranker.Rerank(ctx, query, candidates)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to rerank memories", "error", err)
+		} else if len(scores) == len(candidates) {
+			reranked = scores
+		}
+	}
+
+	weights := m.options.Weights
+	if reranked == nil {
+		weights.Rerank = 0
+	}
+
+	sim, rec, rr := memorymanager.NormalizeWeights(weights)
 	now := time.Now().UTC()
 
 	for i := range candidates {
@@ -231,6 +573,21 @@ func (m *muninMemoryManager) SearchLongTerm(ctx context.Context, sessionId strin
 		recency := math.Pow(0.5, age.Hours()/m.options.Thresholds.HalfLife.Hours())
 
 		weighted := (sim * score) + (rec * recency)
+
+		// keep the parts of the score so it is clear why a memory was chosen
+		breakdown := map[string]float64{
+			"retrieval": score,
+			"recency":   recency,
+		}
+
+		if reranked != nil {
+			weighted += rr * reranked[i]
+			breakdown["rerank"] = reranked[i]
+		}
+
+		breakdown["final"] = weighted
+
+		record.Metadata = withMeta(record.Metadata, "_scores", breakdown)
 		record.Score = float32(weighted)
 	}
 
@@ -265,13 +622,187 @@ func (m *muninMemoryManager) SearchLongTerm(ctx context.Context, sessionId strin
 	return messages, nil, nil, nil
 }
 
+// similarity scores a record found other than by vector search against the
+// query, assuming a middling match when it carries no embedding
+func similarity(vec []float32, rec storer.Record) float32 {
+	if len(rec.Embedding) == 0 {
+		return 0.5
+	}
+	return float32(memorymanager.CosineSimilarity(vec, rec.Embedding))
+}
+
+func (m *muninMemoryManager) DeleteSession(ctx context.Context, sessionId string) error {
+	if _, err := m.session(ctx, sessionId); err != nil {
+		return err
+	}
+
+	deleted, err := m.options.Storer.DeleteBySession(ctx, sessionId)
+	if err != nil {
+		return err
+	}
+
+	if err := m.buffer.DeleteSession(ctx, sessionId); err != nil {
+		return err
+	}
+
+	m.mtx.Lock()
+	delete(m.compacting, sessionId)
+	m.mtx.Unlock()
+
+	slog.InfoContext(ctx, "deleted session", "session", sessionId, "memories", deleted)
+
+	return nil
+}
+
+func (m *muninMemoryManager) ListSessionIds(ctx context.Context, spaceId string) ([]string, error) {
+	sessions, err := m.buffer.ListSessions(ctx, spaceId)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(sessions))
+	for _, session := range sessions {
+		ids = append(ids, session.Id)
+	}
+
+	return ids, nil
+}
+
+func (m *muninMemoryManager) ListChildSessionIds(ctx context.Context, spaceId string, parentId string) ([]string, error) {
+	sessions, err := m.buffer.ListSessions(ctx, spaceId)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []string{}
+	for _, session := range sessions {
+		if session.ParentId == parentId {
+			ids = append(ids, session.Id)
+		}
+	}
+
+	return ids, nil
+}
+
+func (m *muninMemoryManager) DeleteSpace(ctx context.Context, spaceId string) error {
+	deleted, err := m.options.Storer.DeleteBySpace(ctx, spaceId)
+	if err != nil {
+		return err
+	}
+
+	sessions, err := m.buffer.ListSessions(ctx, spaceId)
+	if err != nil {
+		return err
+	}
+
+	if err := m.buffer.DeleteSpace(ctx, spaceId); err != nil {
+		return err
+	}
+
+	m.mtx.Lock()
+	for _, session := range sessions {
+		delete(m.compacting, session.Id)
+	}
+	m.mtx.Unlock()
+
+	m.embedMtx.Lock()
+	delete(m.embedders, spaceId)
+	m.embedMtx.Unlock()
+
+	slog.InfoContext(ctx, "deleted space", "space", spaceId, "sessions", len(sessions), "memories", deleted)
+
+	return nil
+}
+
+func (m *muninMemoryManager) session(ctx context.Context, sessionId string) (buffer.Session, error) {
+	session, err := m.buffer.GetSession(ctx, sessionId)
+	if errors.Is(err, buffer.ErrNotFound) {
+		return buffer.Session{}, fmt.Errorf("session %s not found", sessionId)
+	}
+	return session, err
+}
+
+func withMeta(metadata map[string]any, key string, value any) map[string]any {
+	// storers may hand back shared maps so annotate a copy
+	annotated := maps.Clone(metadata)
+	if annotated == nil {
+		annotated = map[string]any{}
+	}
+	annotated[key] = value
+	return annotated
+}
+
+func fromBufferMessages(stored []buffer.Message) ([]memorymanager.Message, error) {
+	messages := make([]memorymanager.Message, 0, len(stored))
+	for _, msg := range stored {
+		var parts []memorymanager.Part
+		if len(msg.Parts) > 0 {
+			if err := json.Unmarshal(msg.Parts, &parts); err != nil {
+				return nil, fmt.Errorf("failed to decode message %s: %w", msg.Id, err)
+			}
+		}
+		messages = append(messages, memorymanager.Message{
+			Id:        msg.Id,
+			SessionId: msg.SessionId,
+			Role:      msg.Role,
+			Parts:     parts,
+		})
+	}
+	return messages, nil
+}
+
 func NewMemoryManager(opts ...memorymanager.Option) memorymanager.MemoryManager {
 	options := memorymanager.NewOptions(opts...)
 
+	buf := options.Buffer
+	if buf == nil {
+		buf = memorybuffer.NewBuffer()
+	}
+
 	m := &muninMemoryManager{
-		options:   options,
-		shortTerm: map[string]*sessionBuffer{},
-		mtx:       sync.RWMutex{},
+		options: options,
+		buffer:  buf,
+		linker: &linker{
+			similarity: options.Thresholds.LinkSimilarity,
+		},
+		compacting: map[string]struct{}{},
+		mtx:        sync.Mutex{},
+		embedders:  map[string]embedder.Embedder{},
+		models:     map[string]embedder.Embedder{},
+	}
+
+	embedders, _ := EmbeddersFrom(options.Context)
+	for _, emb := range embedders {
+		if len(emb.Model()) == 0 {
+			panic("munin embedders must name their model")
+		}
+		m.models[emb.Model()] = emb
+	}
+
+	gen, _ := GeneratorFrom(options.Context)
+
+	m.linker.generator = gen
+
+	if compaction, ok := CompactionFrom(options.Context); ok {
+		if gen == nil {
+			panic("munin compaction requires a generator")
+		}
+		m.compactor = &compactor{
+			generator:    gen,
+			maxMessages:  options.SessionWindowSize,
+			maxTokens:    cmp.Or(compaction.MaxTokens, 4000), // rough budget for verbatim messages
+			keepRecent:   cmp.Or(compaction.KeepRecent, 10),  // messages left verbatim after compacting
+			maxSummaries: cmp.Or(compaction.MaxSummaries, 4), // summaries per level before rolling them up
+		}
+	}
+
+	if es, ok := options.Storer.(storer.EntityStorer); ok {
+		m.entities = &entityKeeper{
+			storer:     es,
+			embedder:   options.Embedder,
+			generator:  gen,
+			similarity: options.Thresholds.EntitySimilarity,
+		}
 	}
 
 	return m
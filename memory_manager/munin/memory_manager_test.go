@@ -0,0 +1,34 @@
+package munin_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	memorymanager "github.com/w-h-a/agent/memory_manager"
+	"github.com/w-h-a/agent/memory_manager/memorymanagertest"
+	"github.com/w-h-a/agent/memory_manager/munin"
+	"github.com/w-h-a/agent/memory_manager/providers/buffer"
+	sqlitebuffer "github.com/w-h-a/agent/memory_manager/providers/buffer/sqlite"
+	"github.com/w-h-a/agent/memory_manager/providers/embedder/embeddertest"
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+	memorystorer "github.com/w-h-a/agent/memory_manager/providers/storer/memory"
+	sqlitestorer "github.com/w-h-a/agent/memory_manager/providers/storer/sqlite"
+)
+
+func TestConformance(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		memorymanagertest.Run(t, munin.NewMemoryManager(
+			memorymanager.WithStorer(memorystorer.NewStorer()),
+			memorymanager.WithEmbedder(embeddertest.NewEmbedder()),
+		))
+	})
+
+	t.Run("sqlite", func(t *testing.T) {
+		dir := t.TempDir()
+		memorymanagertest.Run(t, munin.NewMemoryManager(
+			memorymanager.WithBuffer(sqlitebuffer.NewBuffer(buffer.WithLocation(filepath.Join(dir, "buffer.db")))),
+			memorymanager.WithStorer(sqlitestorer.NewStorer(storer.WithLocation(filepath.Join(dir, "storer.db")))),
+			memorymanager.WithEmbedder(embeddertest.NewEmbedder()),
+		))
+	})
+}
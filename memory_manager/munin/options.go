@@ -0,0 +1,63 @@
+package munin
+
+import (
+	"context"
+
+	"github.com/w-h-a/agent/generator"
+	memorymanager "github.com/w-h-a/agent/memory_manager"
+	"github.com/w-h-a/agent/memory_manager/providers/embedder"
+)
+
+type generatorKey struct{}
+
+func WithGenerator(gen generator.Generator) memorymanager.Option {
+	return func(o *memorymanager.Options) {
+		o.Context = context.WithValue(o.Context, generatorKey{}, gen)
+	}
+}
+
+func GeneratorFrom(ctx context.Context) (generator.Generator, bool) {
+	gen, ok := ctx.Value(generatorKey{}).(generator.Generator)
+	return gen, ok
+}
+
+type compactionKey struct{}
+
+// Compaction bounds a session's verbatim messages. Fields left zero take
+// the defaults.
+type Compaction struct {
+	MaxTokens    int
+	KeepRecent   int
+	MaxSummaries int
+}
+
+// WithCompaction summarizes a session's oldest messages once it outgrows
+// the window instead of dropping them. Summarizing happens in the
+// background, so a slow or failing model never holds up or fails an add.
+// It needs WithGenerator.
+func WithCompaction(compaction Compaction) memorymanager.Option {
+	return func(o *memorymanager.Options) {
+		o.Context = context.WithValue(o.Context, compactionKey{}, compaction)
+	}
+}
+
+func CompactionFrom(ctx context.Context) (Compaction, bool) {
+	compaction, ok := ctx.Value(compactionKey{}).(Compaction)
+	return compaction, ok
+}
+
+type embeddersKey struct{}
+
+// WithEmbedders registers embedders besides the default one. A space whose
+// memories were re-embedded onto one of their models, whether by this
+// process or another, is served by it rather than refused.
+func WithEmbedders(embedders ...embedder.Embedder) memorymanager.Option {
+	return func(o *memorymanager.Options) {
+		o.Context = context.WithValue(o.Context, embeddersKey{}, embedders)
+	}
+}
+
+func EmbeddersFrom(ctx context.Context) ([]embedder.Embedder, bool) {
+	embedders, ok := ctx.Value(embeddersKey{}).([]embedder.Embedder)
+	return embedders, ok
+}
@@ -0,0 +1,206 @@
+package munin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	memorymanager "github.com/w-h-a/agent/memory_manager"
+	"github.com/w-h-a/agent/memory_manager/providers/embedder"
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+)
+
+type reembedJob struct {
+	progress memorymanager.ReembedProgress
+	err      error
+	done     chan struct{}
+	cancel   context.CancelFunc
+	mtx      sync.Mutex
+}
+
+func (j *reembedJob) Stop() {
+	j.cancel()
+}
+
+func (j *reembedJob) Progress() memorymanager.ReembedProgress {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+	return j.progress
+}
+
+func (j *reembedJob) Wait() (memorymanager.ReembedProgress, error) {
+	<-j.done
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+	return j.progress, j.err
+}
+
+func (j *reembedJob) update(fn func(p *memorymanager.ReembedProgress)) {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+	fn(&j.progress)
+}
+
+// Reembed stages a vector from next for every memory in the space while
+// searches keep using the live ones, then swaps them in and moves the
+// space's queries onto next in one step. Entities keep the default
+// embedder. Register next with WithEmbedders so the space is still served
+// after a restart.
+func (m *muninMemoryManager) Reembed(ctx context.Context, spaceId string, next embedder.Embedder, opts ...memorymanager.ReembedOption) (memorymanager.ReembedJob, error) {
+	options := memorymanager.NewReembedOptions(opts...)
+
+	rs, ok := m.options.Storer.(storer.Reembedder)
+	if !ok {
+		return nil, errors.New("storer cannot re-embed memories")
+	}
+
+	model := next.Model()
+	if len(model) == 0 {
+		return nil, errors.New("embedder does not name its model")
+	}
+
+	// the job outlives the request that started it and ends with Stop
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+
+	job := &reembedJob{
+		progress: memorymanager.ReembedProgress{SpaceId: spaceId, Model: model},
+		done:     make(chan struct{}),
+		cancel:   cancel,
+	}
+
+	go func() {
+		defer close(job.done)
+		defer cancel()
+
+		err := m.reembed(ctx, rs, spaceId, next, max(options.BatchSize, 1), job)
+		if errors.Is(err, context.Canceled) {
+			slog.InfoContext(ctx, "stopped re-embedding space", "space", spaceId, "model", model)
+		} else if err != nil {
+			slog.ErrorContext(ctx, "failed to re-embed space", "space", spaceId, "model", model, "error", err)
+		}
+
+		job.mtx.Lock()
+		job.err = err
+		job.mtx.Unlock()
+	}()
+
+	return job, nil
+}
+
+func (m *muninMemoryManager) reembed(ctx context.Context, rs storer.Reembedder, spaceId string, next embedder.Embedder, batch int, job *reembedJob) error {
+	if err := m.stage(ctx, rs, spaceId, next, batch, job); err != nil {
+		return err
+	}
+
+	// hold off writes and searches so nothing lands between the last batch and the swap
+	m.embedMtx.Lock()
+	defer m.embedMtx.Unlock()
+
+	if err := m.stage(ctx, rs, spaceId, next, batch, job); err != nil {
+		return err
+	}
+
+	promoted, err := rs.PromoteEmbeddings(ctx, spaceId, next.Model())
+	if err != nil {
+		return fmt.Errorf("failed to promote embeddings: %w", err)
+	}
+
+	m.embedders[spaceId] = next
+
+	job.update(func(p *memorymanager.ReembedProgress) {
+		p.Promoted = promoted
+		p.Done = true
+	})
+
+	return nil
+}
+
+func (m *muninMemoryManager) stage(ctx context.Context, rs storer.Reembedder, spaceId string, next embedder.Embedder, batch int, job *reembedJob) error {
+	model := next.Model()
+
+	for {
+		pending, err := rs.PendingEmbeddings(ctx, spaceId, model, batch)
+		if err != nil {
+			return err
+		}
+
+		if len(pending) == 0 {
+			return nil
+		}
+
+		contents := make([]string, len(pending))
+		for i, rec := range pending {
+			contents[i] = rec.Content
+		}
+
+		batched, err := next.EmbedBatch(ctx, contents)
+		if err != nil {
+			return err
+		}
+
+		vectors := make(map[string][]float32, len(pending))
+		for i, rec := range pending {
+			vectors[rec.Id] = batched[i]
+		}
+
+		if err := rs.StageEmbeddings(ctx, model, vectors); err != nil {
+			return err
+		}
+
+		job.update(func(p *memorymanager.ReembedProgress) {
+			p.Staged += len(vectors)
+		})
+	}
+}
+
+// embedderFor is the embedder the space's memories were stored with and
+// must be called with embedMtx held. The storer has the final say because
+// the space may have been re-embedded before a restart or by another
+// process, and searching with the wrong model would quietly find nothing.
+func (m *muninMemoryManager) embedderFor(ctx context.Context, spaceId string) (embedder.Embedder, error) {
+	emb, ok := m.embedders[spaceId]
+	if !ok {
+		emb = m.options.Embedder
+	}
+
+	rs, ok := m.options.Storer.(storer.Reembedder)
+	if !ok {
+		return emb, nil
+	}
+
+	other, err := rs.OtherEmbeddingModel(ctx, spaceId, emb.Model())
+	if err != nil {
+		return nil, fmt.Errorf("failed to check the embedding model of space %s: %w", spaceId, err)
+	}
+
+	if len(other) == 0 {
+		return emb, nil
+	}
+
+	registered, ok := m.models[other]
+	if !ok {
+		return nil, fmt.Errorf("space %s has memories embedded with %q but the embedder is %q: register an embedder for %q or re-embed the space", spaceId, other, emb.Model(), other)
+	}
+
+	// a space part way between models is served by neither
+	mixed, err := rs.OtherEmbeddingModel(ctx, spaceId, other)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check the embedding model of space %s: %w", spaceId, err)
+	}
+
+	if len(mixed) > 0 {
+		return nil, fmt.Errorf("space %s has memories embedded with both %q and %q: re-embed the space", spaceId, other, mixed)
+	}
+
+	return registered, nil
+}
+
+// scoped keeps a search to memories embedded by model
+func scoped(filter *storer.Filter, model string) *storer.Filter {
+	if filter == nil {
+		return storer.ModelScope(model)
+	}
+	return storer.And(storer.ModelScope(model), filter)
+}
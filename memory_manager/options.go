@@ -4,7 +4,9 @@ import (
 	"context"
 	"time"
 
+	"github.com/w-h-a/agent/memory_manager/providers/buffer"
 	"github.com/w-h-a/agent/memory_manager/providers/embedder"
+	"github.com/w-h-a/agent/memory_manager/providers/reranker"
 	"github.com/w-h-a/agent/memory_manager/providers/storer"
 )
 
@@ -12,8 +14,10 @@ type Option func(*Options)
 
 type Options struct {
 	Location          string
+	Buffer            buffer.Buffer
 	Storer            storer.Storer
 	Embedder          embedder.Embedder
+	Reranker          reranker.Reranker
 	SessionWindowSize int
 	Weights           Weights
 	Thresholds        Thresholds
@@ -23,12 +27,15 @@ type Options struct {
 type Weights struct {
 	Similarity float64
 	Recency    float64
+	Rerank     float64
 }
 
 type Thresholds struct {
 	Relevance           float64
 	HalfLife            time.Duration
 	RejectionSimilarity float64
+	LinkSimilarity      float64
+	EntitySimilarity    float64
 }
 
 func WithLocation(loc string) Option {
@@ -37,6 +44,12 @@ func WithLocation(loc string) Option {
 	}
 }
 
+func WithBuffer(buffer buffer.Buffer) Option {
+	return func(o *Options) {
+		o.Buffer = buffer
+	}
+}
+
 func WithStorer(storer storer.Storer) Option {
 	return func(o *Options) {
 		o.Storer = storer
@@ -49,6 +62,12 @@ func WithEmbedder(embedder embedder.Embedder) Option {
 	}
 }
 
+func WithReranker(reranker reranker.Reranker) Option {
+	return func(o *Options) {
+		o.Reranker = reranker
+	}
+}
+
 func WithWeights(weights Weights) Option {
 	return func(o *Options) {
 		o.Weights = weights
@@ -67,11 +86,14 @@ func NewOptions(opts ...Option) Options {
 		Weights: Weights{
 			Similarity: 1.0, // exact semantic match
 			Recency:    0.5, // medium bias for recency
+			Rerank:     1.0, // trust the reranker as much as retrieval
 		},
 		Thresholds: Thresholds{
 			Relevance:           0.7,            // mild diversity
 			HalfLife:            72 * time.Hour, // 3 days
 			RejectionSimilarity: 0.97,           // strong bias against duplicates
+			LinkSimilarity:      0.8,            // only link closely related memories
+			EntitySimilarity:    0.9,            // merge entities that are near identical
 		},
 		Context: context.Background(),
 	}
@@ -84,8 +106,9 @@ func NewOptions(opts ...Option) Options {
 type CreateSessionOption func(*CreateSessionOptions)
 
 type CreateSessionOptions struct {
-	SpaceId string
-	Context context.Context
+	SpaceId  string
+	ParentId string
+	Context  context.Context
 }
 
 func WithSpaceId(spaceId string) CreateSessionOption {
@@ -94,6 +117,13 @@ func WithSpaceId(spaceId string) CreateSessionOption {
 	}
 }
 
+// WithParentId records the session that delegated to the new one
+func WithParentId(parentId string) CreateSessionOption {
+	return func(o *CreateSessionOptions) {
+		o.ParentId = parentId
+	}
+}
+
 func NewCreateSessionOptions(opts ...CreateSessionOption) CreateSessionOptions {
 	options := CreateSessionOptions{
 		Context: context.Background(),
@@ -157,6 +187,8 @@ type SearchLongTermOptions struct {
 	Limit               int
 	LinkedMemoriesLimit int
 	LinkedMemoriesHops  int
+	EntityGraph         *EntityGraph
+	Filter              *storer.Filter
 	Context             context.Context
 }
 
@@ -178,6 +210,19 @@ func WithSearchLongTermLinkedMemoriesHops(hops int) SearchLongTermOption {
 	}
 }
 
+// WithSearchLongTermEntityGraph asks the memory manager to fill graph with the entities relevant to the query
+func WithSearchLongTermEntityGraph(graph *EntityGraph) SearchLongTermOption {
+	return func(o *SearchLongTermOptions) {
+		o.EntityGraph = graph
+	}
+}
+
+func WithSearchLongTermFilter(filter *storer.Filter) SearchLongTermOption {
+	return func(o *SearchLongTermOptions) {
+		o.Filter = filter
+	}
+}
+
 func NewSearchOptions(opts ...SearchLongTermOption) SearchLongTermOptions {
 	options := SearchLongTermOptions{
 		Limit:               5,
@@ -190,3 +235,27 @@ func NewSearchOptions(opts ...SearchLongTermOption) SearchLongTermOptions {
 	}
 	return options
 }
+
+type ReembedOption func(*ReembedOptions)
+
+type ReembedOptions struct {
+	BatchSize int
+	Context   context.Context
+}
+
+func WithReembedBatchSize(size int) ReembedOption {
+	return func(o *ReembedOptions) {
+		o.BatchSize = size
+	}
+}
+
+func NewReembedOptions(opts ...ReembedOption) ReembedOptions {
+	options := ReembedOptions{
+		BatchSize: 64,
+		Context:   context.Background(),
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
@@ -0,0 +1,27 @@
+package buffer
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrNotFound = errors.New("not found")
+
+type Buffer interface {
+	CreateSpace(ctx context.Context, space Space) error
+	GetSpace(ctx context.Context, id string) (Space, error)
+	ListSpaces(ctx context.Context) ([]Space, error)
+	DeleteSpace(ctx context.Context, id string) error
+	CreateSession(ctx context.Context, session Session) error
+	GetSession(ctx context.Context, id string) (Session, error)
+	ListSessions(ctx context.Context, spaceId string) ([]Session, error)
+	UpdateSession(ctx context.Context, session Session) error
+	DeleteSession(ctx context.Context, id string) error
+	AddMessage(ctx context.Context, msg Message) error
+	ListMessages(ctx context.Context, sessionId string, limit int) ([]Message, error)
+	DeleteMessages(ctx context.Context, sessionId string, ids []string) error
+	TrimMessages(ctx context.Context, sessionId string, keep int) error
+	UpsertTask(ctx context.Context, task Task) error
+	ListTasks(ctx context.Context, sessionId string) ([]Task, error)
+	DeleteTask(ctx context.Context, sessionId string, id string) error
+}
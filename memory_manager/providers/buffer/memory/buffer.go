@@ -0,0 +1,284 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/w-h-a/agent/memory_manager/providers/buffer"
+)
+
+type memoryBuffer struct {
+	options  buffer.Options
+	spaces   map[string]buffer.Space
+	sessions map[string]buffer.Session
+	messages map[string][]buffer.Message
+	tasks    map[string][]buffer.Task
+	mtx      sync.RWMutex
+}
+
+func (b *memoryBuffer) CreateSpace(ctx context.Context, space buffer.Space) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if space.CreatedAt.IsZero() {
+		space.CreatedAt = time.Now().UTC()
+	}
+
+	b.spaces[space.Id] = space
+
+	return nil
+}
+
+func (b *memoryBuffer) GetSpace(ctx context.Context, id string) (buffer.Space, error) {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	space, ok := b.spaces[id]
+	if !ok {
+		return buffer.Space{}, fmt.Errorf("space %s: %w", id, buffer.ErrNotFound)
+	}
+
+	return space, nil
+}
+
+func (b *memoryBuffer) ListSpaces(ctx context.Context) ([]buffer.Space, error) {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	spaces := make([]buffer.Space, 0, len(b.spaces))
+	for _, space := range b.spaces {
+		spaces = append(spaces, space)
+	}
+
+	sort.Slice(spaces, func(i, j int) bool {
+		return spaces[i].CreatedAt.Before(spaces[j].CreatedAt)
+	})
+
+	return spaces, nil
+}
+
+func (b *memoryBuffer) DeleteSpace(ctx context.Context, id string) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	delete(b.spaces, id)
+
+	for sessionId, session := range b.sessions {
+		if session.SpaceId == id {
+			b.deleteSession(sessionId)
+		}
+	}
+
+	return nil
+}
+
+func (b *memoryBuffer) CreateSession(ctx context.Context, session buffer.Session) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	now := time.Now().UTC()
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = now
+	}
+	session.UpdatedAt = now
+
+	b.sessions[session.Id] = session
+
+	return nil
+}
+
+func (b *memoryBuffer) GetSession(ctx context.Context, id string) (buffer.Session, error) {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	session, ok := b.sessions[id]
+	if !ok {
+		return buffer.Session{}, fmt.Errorf("session %s: %w", id, buffer.ErrNotFound)
+	}
+
+	session.Summaries = slices.Clone(session.Summaries)
+
+	return session, nil
+}
+
+func (b *memoryBuffer) ListSessions(ctx context.Context, spaceId string) ([]buffer.Session, error) {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	sessions := make([]buffer.Session, 0, len(b.sessions))
+	for _, session := range b.sessions {
+		if len(spaceId) > 0 && session.SpaceId != spaceId {
+			continue
+		}
+		session.Summaries = slices.Clone(session.Summaries)
+		sessions = append(sessions, session)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+	})
+
+	return sessions, nil
+}
+
+func (b *memoryBuffer) UpdateSession(ctx context.Context, session buffer.Session) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	existing, ok := b.sessions[session.Id]
+	if !ok {
+		return fmt.Errorf("session %s: %w", session.Id, buffer.ErrNotFound)
+	}
+
+	session.ParentId = existing.ParentId
+	session.CreatedAt = existing.CreatedAt
+	session.UpdatedAt = time.Now().UTC()
+	session.Summaries = slices.Clone(session.Summaries)
+
+	b.sessions[session.Id] = session
+
+	return nil
+}
+
+func (b *memoryBuffer) DeleteSession(ctx context.Context, id string) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	b.deleteSession(id)
+
+	return nil
+}
+
+func (b *memoryBuffer) deleteSession(id string) {
+	delete(b.sessions, id)
+	delete(b.messages, id)
+	delete(b.tasks, id)
+}
+
+func (b *memoryBuffer) AddMessage(ctx context.Context, msg buffer.Message) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if _, ok := b.sessions[msg.SessionId]; !ok {
+		return fmt.Errorf("session %s: %w", msg.SessionId, buffer.ErrNotFound)
+	}
+
+	if msg.CreatedAt.IsZero() {
+		msg.CreatedAt = time.Now().UTC()
+	}
+
+	b.messages[msg.SessionId] = append(b.messages[msg.SessionId], msg)
+
+	return nil
+}
+
+func (b *memoryBuffer) ListMessages(ctx context.Context, sessionId string, limit int) ([]buffer.Message, error) {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	msgs := b.messages[sessionId]
+	if limit > 0 && len(msgs) > limit {
+		msgs = msgs[len(msgs)-limit:]
+	}
+
+	return slices.Clone(msgs), nil
+}
+
+func (b *memoryBuffer) DeleteMessages(ctx context.Context, sessionId string, ids []string) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	remove := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		remove[id] = struct{}{}
+	}
+
+	b.messages[sessionId] = slices.DeleteFunc(b.messages[sessionId], func(msg buffer.Message) bool {
+		_, ok := remove[msg.Id]
+		return ok
+	})
+
+	return nil
+}
+
+func (b *memoryBuffer) TrimMessages(ctx context.Context, sessionId string, keep int) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	msgs := b.messages[sessionId]
+	if len(msgs) > keep {
+		b.messages[sessionId] = slices.Clone(msgs[len(msgs)-keep:])
+	}
+
+	return nil
+}
+
+func (b *memoryBuffer) UpsertTask(ctx context.Context, task buffer.Task) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if _, ok := b.sessions[task.SessionId]; !ok {
+		return fmt.Errorf("session %s: %w", task.SessionId, buffer.ErrNotFound)
+	}
+
+	now := time.Now().UTC()
+	task.UpdatedAt = now
+
+	tasks := b.tasks[task.SessionId]
+	idx := slices.IndexFunc(tasks, func(existing buffer.Task) bool {
+		return existing.Id == task.Id
+	})
+
+	if idx >= 0 {
+		task.CreatedAt = tasks[idx].CreatedAt
+		tasks[idx] = task
+	} else {
+		task.CreatedAt = now
+		tasks = append(tasks, task)
+	}
+
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].TaskOrder < tasks[j].TaskOrder
+	})
+
+	b.tasks[task.SessionId] = tasks
+
+	return nil
+}
+
+func (b *memoryBuffer) ListTasks(ctx context.Context, sessionId string) ([]buffer.Task, error) {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	return slices.Clone(b.tasks[sessionId]), nil
+}
+
+func (b *memoryBuffer) DeleteTask(ctx context.Context, sessionId string, id string) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	b.tasks[sessionId] = slices.DeleteFunc(b.tasks[sessionId], func(task buffer.Task) bool {
+		return task.Id == id
+	})
+
+	return nil
+}
+
+func NewBuffer(opts ...buffer.Option) buffer.Buffer {
+	options := buffer.NewOptions(opts...)
+
+	b := &memoryBuffer{
+		options:  options,
+		spaces:   map[string]buffer.Space{},
+		sessions: map[string]buffer.Session{},
+		messages: map[string][]buffer.Message{},
+		tasks:    map[string][]buffer.Task{},
+		mtx:      sync.RWMutex{},
+	}
+
+	return b
+}
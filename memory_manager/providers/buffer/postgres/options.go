@@ -0,0 +1,21 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/w-h-a/agent/memory_manager/providers/buffer"
+)
+
+type autoMigrateKey struct{}
+
+// WithAutoMigrate runs Migrate from NewBuffer
+func WithAutoMigrate() buffer.Option {
+	return func(o *buffer.Options) {
+		o.Context = context.WithValue(o.Context, autoMigrateKey{}, true)
+	}
+}
+
+func AutoMigrateFrom(ctx context.Context) bool {
+	enabled, _ := ctx.Value(autoMigrateKey{}).(bool)
+	return enabled
+}
@@ -0,0 +1,490 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/w-h-a/agent/memory_manager/providers/buffer"
+	_ "modernc.org/sqlite"
+)
+
+const (
+	schema = `
+		CREATE TABLE IF NOT EXISTS spaces (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP NOT NULL
+		);
+
+		CREATE TABLE IF NOT EXISTS sessions (
+			id TEXT PRIMARY KEY,
+			space_id TEXT NOT NULL DEFAULT '',
+			parent_id TEXT NOT NULL DEFAULT '',
+			summaries TEXT NOT NULL DEFAULT '[]',
+			last_record_id TEXT NOT NULL DEFAULT '',
+			last_role TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS sessions_space_idx ON sessions (space_id);
+
+		CREATE TABLE IF NOT EXISTS session_messages (
+			seq INTEGER PRIMARY KEY AUTOINCREMENT,
+			id TEXT NOT NULL UNIQUE,
+			session_id TEXT NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+			role TEXT NOT NULL,
+			parts TEXT NOT NULL DEFAULT '[]',
+			created_at TIMESTAMP NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS session_messages_session_idx ON session_messages (session_id, seq);
+
+		CREATE TABLE IF NOT EXISTS session_tasks (
+			id TEXT NOT NULL,
+			session_id TEXT NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+			task_order INTEGER NOT NULL DEFAULT 0,
+			data TEXT NOT NULL DEFAULT 'null',
+			status TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (session_id, id)
+		);
+	`
+)
+
+type sqliteBuffer struct {
+	options buffer.Options
+	conn    *sql.DB
+}
+
+func (b *sqliteBuffer) CreateSpace(ctx context.Context, space buffer.Space) error {
+	if space.CreatedAt.IsZero() {
+		space.CreatedAt = time.Now().UTC()
+	}
+
+	_, err := b.conn.ExecContext(
+		ctx,
+		`INSERT INTO spaces (id, name, created_at) VALUES (?, ?, ?) ON CONFLICT (id) DO UPDATE SET name = excluded.name`,
+		space.Id,
+		space.Name,
+		space.CreatedAt,
+	)
+
+	return err
+}
+
+func (b *sqliteBuffer) GetSpace(ctx context.Context, id string) (buffer.Space, error) {
+	var space buffer.Space
+
+	err := b.conn.QueryRowContext(
+		ctx,
+		`SELECT id, name, created_at FROM spaces WHERE id = ?`,
+		id,
+	).Scan(&space.Id, &space.Name, &space.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return buffer.Space{}, fmt.Errorf("space %s: %w", id, buffer.ErrNotFound)
+	}
+
+	return space, err
+}
+
+func (b *sqliteBuffer) ListSpaces(ctx context.Context) ([]buffer.Space, error) {
+	rows, err := b.conn.QueryContext(ctx, `SELECT id, name, created_at FROM spaces ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	spaces := []buffer.Space{}
+	for rows.Next() {
+		var space buffer.Space
+		if err := rows.Scan(&space.Id, &space.Name, &space.CreatedAt); err != nil {
+			return nil, err
+		}
+		spaces = append(spaces, space)
+	}
+
+	return spaces, rows.Err()
+}
+
+func (b *sqliteBuffer) DeleteSpace(ctx context.Context, id string) error {
+	tx, err := b.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM sessions WHERE space_id = ?`, id); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM spaces WHERE id = ?`, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (b *sqliteBuffer) CreateSession(ctx context.Context, session buffer.Session) error {
+	now := time.Now().UTC()
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = now
+	}
+
+	summaries, err := json.Marshal(session.Summaries)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.conn.ExecContext(
+		ctx,
+		`INSERT INTO sessions (id, space_id, parent_id, summaries, last_record_id, last_role, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		session.Id,
+		session.SpaceId,
+		session.ParentId,
+		string(summaries),
+		session.LastRecordId,
+		session.LastRole,
+		session.CreatedAt,
+		now,
+	)
+
+	return err
+}
+
+func (b *sqliteBuffer) GetSession(ctx context.Context, id string) (buffer.Session, error) {
+	row := b.conn.QueryRowContext(
+		ctx,
+		`SELECT id, space_id, parent_id, summaries, last_record_id, last_role, created_at, updated_at FROM sessions WHERE id = ?`,
+		id,
+	)
+
+	session, err := scanSession(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return buffer.Session{}, fmt.Errorf("session %s: %w", id, buffer.ErrNotFound)
+	}
+
+	return session, err
+}
+
+func (b *sqliteBuffer) ListSessions(ctx context.Context, spaceId string) ([]buffer.Session, error) {
+	rows, err := b.conn.QueryContext(
+		ctx,
+		`SELECT id, space_id, parent_id, summaries, last_record_id, last_role, created_at, updated_at FROM sessions WHERE ? = '' OR space_id = ? ORDER BY created_at`,
+		spaceId,
+		spaceId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []buffer.Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (b *sqliteBuffer) UpdateSession(ctx context.Context, session buffer.Session) error {
+	summaries, err := json.Marshal(session.Summaries)
+	if err != nil {
+		return err
+	}
+
+	res, err := b.conn.ExecContext(
+		ctx,
+		`UPDATE sessions SET space_id = ?, summaries = ?, last_record_id = ?, last_role = ?, updated_at = ? WHERE id = ?`,
+		session.SpaceId,
+		string(summaries),
+		session.LastRecordId,
+		session.LastRole,
+		time.Now().UTC(),
+		session.Id,
+	)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("session %s: %w", session.Id, buffer.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (b *sqliteBuffer) DeleteSession(ctx context.Context, id string) error {
+	_, err := b.conn.ExecContext(ctx, `DELETE FROM sessions WHERE id = ?`, id)
+	return err
+}
+
+func (b *sqliteBuffer) AddMessage(ctx context.Context, msg buffer.Message) error {
+	if msg.CreatedAt.IsZero() {
+		msg.CreatedAt = time.Now().UTC()
+	}
+
+	res, err := b.conn.ExecContext(
+		ctx,
+		`INSERT INTO session_messages (id, session_id, role, parts, created_at) SELECT ?, id, ?, ?, ? FROM sessions WHERE id = ?`,
+		msg.Id,
+		msg.Role,
+		string(msg.Parts),
+		msg.CreatedAt,
+		msg.SessionId,
+	)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("session %s: %w", msg.SessionId, buffer.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (b *sqliteBuffer) ListMessages(ctx context.Context, sessionId string, limit int) ([]buffer.Message, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+
+	rows, err := b.conn.QueryContext(
+		ctx,
+		`SELECT id, session_id, role, parts, created_at FROM (
+			SELECT seq, id, session_id, role, parts, created_at
+			FROM session_messages
+			WHERE session_id = ?
+			ORDER BY seq DESC
+			LIMIT ?
+		) ORDER BY seq`,
+		sessionId,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	msgs := []buffer.Message{}
+	for rows.Next() {
+		var msg buffer.Message
+		var parts string
+		if err := rows.Scan(&msg.Id, &msg.SessionId, &msg.Role, &parts, &msg.CreatedAt); err != nil {
+			return nil, err
+		}
+		msg.Parts = json.RawMessage(parts)
+		msgs = append(msgs, msg)
+	}
+
+	return msgs, rows.Err()
+}
+
+func (b *sqliteBuffer) DeleteMessages(ctx context.Context, sessionId string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	args := make([]any, 0, len(ids)+1)
+	args = append(args, sessionId)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+
+	_, err := b.conn.ExecContext(
+		ctx,
+		fmt.Sprintf(`DELETE FROM session_messages WHERE session_id = ? AND id IN (%s)`, placeholders),
+		args...,
+	)
+
+	return err
+}
+
+func (b *sqliteBuffer) TrimMessages(ctx context.Context, sessionId string, keep int) error {
+	_, err := b.conn.ExecContext(
+		ctx,
+		`DELETE FROM session_messages
+		WHERE session_id = ? AND seq NOT IN (
+			SELECT seq FROM session_messages WHERE session_id = ? ORDER BY seq DESC LIMIT ?
+		)`,
+		sessionId,
+		sessionId,
+		keep,
+	)
+
+	return err
+}
+
+func (b *sqliteBuffer) UpsertTask(ctx context.Context, task buffer.Task) error {
+	now := time.Now().UTC()
+
+	data := string(task.Data)
+	if len(data) == 0 {
+		data = "null"
+	}
+
+	res, err := b.conn.ExecContext(
+		ctx,
+		`INSERT INTO session_tasks (id, session_id, task_order, data, status, created_at, updated_at)
+		SELECT ?, id, ?, ?, ?, ?, ? FROM sessions WHERE id = ?
+		ON CONFLICT (session_id, id) DO UPDATE SET
+			task_order = excluded.task_order,
+			data = excluded.data,
+			status = excluded.status,
+			updated_at = excluded.updated_at`,
+		task.Id,
+		task.TaskOrder,
+		data,
+		task.Status,
+		now,
+		now,
+		task.SessionId,
+	)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("session %s: %w", task.SessionId, buffer.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (b *sqliteBuffer) ListTasks(ctx context.Context, sessionId string) ([]buffer.Task, error) {
+	rows, err := b.conn.QueryContext(
+		ctx,
+		`SELECT id, session_id, task_order, data, status, created_at, updated_at FROM session_tasks WHERE session_id = ? ORDER BY task_order, created_at`,
+		sessionId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []buffer.Task{}
+	for rows.Next() {
+		var task buffer.Task
+		var data string
+		if err := rows.Scan(&task.Id, &task.SessionId, &task.TaskOrder, &data, &task.Status, &task.CreatedAt, &task.UpdatedAt); err != nil {
+			return nil, err
+		}
+		task.Data = json.RawMessage(data)
+		tasks = append(tasks, task)
+	}
+
+	return tasks, rows.Err()
+}
+
+func (b *sqliteBuffer) DeleteTask(ctx context.Context, sessionId string, id string) error {
+	_, err := b.conn.ExecContext(ctx, `DELETE FROM session_tasks WHERE session_id = ? AND id = ?`, sessionId, id)
+	return err
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSession(row scanner) (buffer.Session, error) {
+	var session buffer.Session
+	var summaries string
+
+	if err := row.Scan(
+		&session.Id,
+		&session.SpaceId,
+		&session.ParentId,
+		&summaries,
+		&session.LastRecordId,
+		&session.LastRole,
+		&session.CreatedAt,
+		&session.UpdatedAt,
+	); err != nil {
+		return buffer.Session{}, err
+	}
+
+	if err := json.Unmarshal([]byte(summaries), &session.Summaries); err != nil {
+		session.Summaries = nil
+	}
+
+	return session, nil
+}
+
+// addParentColumn upgrades files created before sessions kept their parent
+func addParentColumn(ctx context.Context, conn *sql.DB) error {
+	var exists bool
+	if err := conn.QueryRowContext(
+		ctx,
+		`SELECT EXISTS (SELECT 1 FROM pragma_table_info('sessions') WHERE name = 'parent_id')`,
+	).Scan(&exists); err != nil {
+		return err
+	}
+
+	if !exists {
+		if _, err := conn.ExecContext(ctx, `ALTER TABLE sessions ADD COLUMN parent_id TEXT NOT NULL DEFAULT ''`); err != nil {
+			return err
+		}
+	}
+
+	_, err := conn.ExecContext(ctx, `CREATE INDEX IF NOT EXISTS sessions_parent_idx ON sessions (space_id, parent_id)`)
+
+	return err
+}
+
+func dsn(location string) string {
+	if len(location) == 0 {
+		location = "agent.db"
+	}
+
+	pragmas := "_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)"
+
+	if strings.Contains(location, "?") {
+		return location + "&" + pragmas
+	}
+
+	return location + "?" + pragmas
+}
+
+func NewBuffer(opts ...buffer.Option) buffer.Buffer {
+	options := buffer.NewOptions(opts...)
+
+	b := &sqliteBuffer{
+		options: options,
+	}
+
+	// agent.db or file:/path/to/agent.db
+	conn, err := sql.Open("sqlite", dsn(options.Location))
+	if err != nil {
+		detail := "failed to open sqlite buffer"
+		slog.ErrorContext(context.Background(), detail, "error", err)
+		panic(detail)
+	}
+
+	if _, err := conn.Exec(schema); err != nil {
+		detail := "failed to create sqlite buffer schema"
+		slog.ErrorContext(context.Background(), detail, "error", err)
+		panic(detail)
+	}
+
+	if err := addParentColumn(context.Background(), conn); err != nil {
+		detail := "failed to add parent column to sqlite buffer"
+		slog.ErrorContext(context.Background(), detail, "error", err)
+		panic(detail)
+	}
+
+	b.conn = conn
+
+	return b
+}
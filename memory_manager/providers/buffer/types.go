@@ -0,0 +1,47 @@
+package buffer
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type Space struct {
+	Id        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Session struct {
+	Id           string    `json:"id"`
+	SpaceId      string    `json:"space_id"`
+	ParentId     string    `json:"parent_id,omitempty"` // the session that delegated to this one
+	Summaries    []Summary `json:"summaries,omitempty"`
+	LastRecordId string    `json:"last_record_id,omitempty"`
+	LastRole     string    `json:"last_role,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type Summary struct {
+	Level     int       `json:"level"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Message struct {
+	Id        string          `json:"id"`
+	SessionId string          `json:"session_id"`
+	Role      string          `json:"role"`
+	Parts     json.RawMessage `json:"parts"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type Task struct {
+	Id        string          `json:"id"`
+	SessionId string          `json:"session_id"`
+	TaskOrder int             `json:"task_order"`
+	Data      json.RawMessage `json:"data"`
+	Status    string          `json:"status"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
@@ -0,0 +1,119 @@
+package cached
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+
+	"github.com/w-h-a/agent/memory_manager/providers/embedder"
+	embeddingcache "github.com/w-h-a/agent/memory_manager/providers/embedding_cache"
+	"github.com/w-h-a/agent/memory_manager/providers/embedding_cache/lru"
+)
+
+type cachedEmbedder struct {
+	options  embedder.Options
+	embedder embedder.Embedder
+	cache    embeddingcache.Cache
+}
+
+func (e *cachedEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	vectors, err := e.EmbedBatch(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+
+	return vectors[0], nil
+}
+
+func (e *cachedEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	keys := make([]string, len(texts))
+	for i, text := range texts {
+		keys[i] = e.key(text)
+	}
+
+	// a broken cache slows embedding down but never fails it
+	hits, err := e.cache.Get(ctx, keys)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to read embedding cache", "error", err)
+		hits = map[string][]float32{}
+	}
+
+	var missing []string
+	missed := map[string]struct{}{}
+
+	for i, key := range keys {
+		if _, ok := hits[key]; ok {
+			continue
+		}
+		if _, ok := missed[key]; ok {
+			continue
+		}
+		missed[key] = struct{}{}
+		missing = append(missing, texts[i])
+	}
+
+	if len(missing) > 0 {
+		vectors, err := e.embedder.EmbedBatch(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(vectors) != len(missing) {
+			return nil, fmt.Errorf("embedder returned %d vectors for %d texts", len(vectors), len(missing))
+		}
+
+		computed := make(map[string][]float32, len(missing))
+		for i, text := range missing {
+			computed[e.key(text)] = vectors[i]
+		}
+
+		if err := e.cache.Put(ctx, computed); err != nil {
+			slog.WarnContext(ctx, "failed to write embedding cache", "error", err)
+		}
+
+		for key, vec := range computed {
+			hits[key] = vec
+		}
+	}
+
+	vectors := make([][]float32, len(keys))
+	for i, key := range keys {
+		vectors[i] = hits[key]
+	}
+
+	return vectors, nil
+}
+
+func (e *cachedEmbedder) Model() string {
+	return e.embedder.Model()
+}
+
+// key hashes the content with the model so vectors never leak across models
+func (e *cachedEmbedder) key(text string) string {
+	sum := sha256.Sum256([]byte(e.embedder.Model() + "\x00" + text))
+	return hex.EncodeToString(sum[:])
+}
+
+func NewEmbedder(opts ...embedder.Option) embedder.Embedder {
+	options := embedder.NewOptions(opts...)
+
+	emb, ok := EmbedderFrom(options.Context)
+	if !ok || emb == nil {
+		panic("missing embedder for cached embedder")
+	}
+
+	c, ok := CacheFrom(options.Context)
+	if !ok {
+		c = lru.NewCache()
+	}
+
+	e := &cachedEmbedder{
+		options:  options,
+		embedder: emb,
+		cache:    c,
+	}
+
+	return e
+}
@@ -0,0 +1,12 @@
+package cached_test
+
+import (
+	"testing"
+
+	"github.com/w-h-a/agent/memory_manager/providers/embedder/cached"
+	"github.com/w-h-a/agent/memory_manager/providers/embedder/embeddertest"
+)
+
+func TestConformance(t *testing.T) {
+	embeddertest.Run(t, cached.NewEmbedder(cached.WithEmbedder(embeddertest.NewEmbedder())))
+}
@@ -0,0 +1,35 @@
+package cached
+
+import (
+	"context"
+
+	"github.com/w-h-a/agent/memory_manager/providers/embedder"
+	embeddingcache "github.com/w-h-a/agent/memory_manager/providers/embedding_cache"
+)
+
+type embedderKey struct{}
+
+// WithEmbedder sets the embedder that computes the vectors the cache misses
+func WithEmbedder(emb embedder.Embedder) embedder.Option {
+	return func(o *embedder.Options) {
+		o.Context = context.WithValue(o.Context, embedderKey{}, emb)
+	}
+}
+
+func EmbedderFrom(ctx context.Context) (embedder.Embedder, bool) {
+	emb, ok := ctx.Value(embedderKey{}).(embedder.Embedder)
+	return emb, ok
+}
+
+type cacheKey struct{}
+
+func WithCache(c embeddingcache.Cache) embedder.Option {
+	return func(o *embedder.Options) {
+		o.Context = context.WithValue(o.Context, cacheKey{}, c)
+	}
+}
+
+func CacheFrom(ctx context.Context) (embeddingcache.Cache, bool) {
+	c, ok := ctx.Value(cacheKey{}).(embeddingcache.Cache)
+	return c, ok
+}
@@ -4,4 +4,9 @@ import "context"
 
 type Embedder interface {
 	Embed(ctx context.Context, text string) ([]float32, error)
+	// EmbedBatch returns one vector per text in the same order
+	EmbedBatch(ctx context.Context, texts []string) ([][]float32, error)
+	// Model names the model behind the vectors so they are never compared
+	// with vectors from another one
+	Model() string
 }
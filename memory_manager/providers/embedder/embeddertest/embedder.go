@@ -0,0 +1,88 @@
+package embeddertest
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+
+	"github.com/w-h-a/agent/memory_manager/providers/embedder"
+)
+
+const defaultSize = 64
+
+type hashEmbedder struct {
+	options embedder.Options
+	size    int
+}
+
+func (e *hashEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	vec := make([]float32, e.size)
+
+	// each word lands in a bucket so texts sharing words end up close
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		h := fnv.New32a()
+		h.Write([]byte(strings.Trim(word, ".,!?;:\"'")))
+		vec[int(h.Sum32())%e.size]++
+	}
+
+	var norm float64
+	for _, v := range vec {
+		norm += float64(v * v)
+	}
+
+	if norm == 0 {
+		vec[0] = 1
+		return vec, nil
+	}
+
+	scale := float32(1 / math.Sqrt(norm))
+	for i := range vec {
+		vec[i] *= scale
+	}
+
+	return vec, nil
+}
+
+func (e *hashEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+
+	for _, text := range texts {
+		vec, err := e.Embed(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, vec)
+	}
+
+	return vectors, nil
+}
+
+func (e *hashEmbedder) Model() string {
+	return e.options.Model
+}
+
+// NewEmbedder returns a deterministic embedder that hashes words into a
+// bag of words vector. It needs no network or key, which makes it a stand
+// in for a real model wherever only the shape of the vectors matters.
+func NewEmbedder(opts ...embedder.Option) embedder.Embedder {
+	options := embedder.NewOptions(opts...)
+
+	if len(options.Model) == 0 {
+		options.Model = "hash"
+	}
+
+	size, ok := SizeFrom(options.Context)
+	if !ok || size < 1 {
+		size = defaultSize
+	}
+
+	return &hashEmbedder{
+		options: options,
+		size:    size,
+	}
+}
@@ -0,0 +1,11 @@
+package embeddertest_test
+
+import (
+	"testing"
+
+	"github.com/w-h-a/agent/memory_manager/providers/embedder/embeddertest"
+)
+
+func TestConformance(t *testing.T) {
+	embeddertest.Run(t, embeddertest.NewEmbedder())
+}
@@ -0,0 +1,177 @@
+package embeddertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	memorymanager "github.com/w-h-a/agent/memory_manager"
+	"github.com/w-h-a/agent/memory_manager/providers/embedder"
+)
+
+// hosted models are not bit for bit reproducible across calls
+const sameVector = 0.99
+
+var texts = []string{
+	"the launch code is banana",
+	"deploy the service with make release",
+	"",
+	"the launch code is banana",
+	"Ünïcödé and emoji 🚀 survive",
+}
+
+// Run checks e against what every embedder must do
+func Run(t *testing.T, e embedder.Embedder) {
+	for _, c := range []struct {
+		name string
+		run  func(ctx context.Context, e embedder.Embedder) error
+	}{
+		{"embed", embed},
+		{"model", func(ctx context.Context, e embedder.Embedder) error { return model(e) }},
+		{"batch matches embed", batch},
+		{"empty batch", emptyBatch},
+		{"deterministic", deterministic},
+		{"concurrent", concurrent},
+		{"cancelled", cancelled},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.run(t.Context(), e); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func embed(ctx context.Context, e embedder.Embedder) error {
+	vec, err := e.Embed(ctx, texts[0])
+	if err != nil {
+		return fmt.Errorf("embed: %w", err)
+	}
+
+	if len(vec) == 0 {
+		return errors.New("embed: empty vector")
+	}
+
+	for _, v := range vec {
+		if v != 0 {
+			return nil
+		}
+	}
+
+	return errors.New("embed: all zero vector")
+}
+
+func model(e embedder.Embedder) error {
+	if len(e.Model()) == 0 {
+		return errors.New("model: empty name")
+	}
+	return nil
+}
+
+func batch(ctx context.Context, e embedder.Embedder) error {
+	// the empty text is left out since hosted models reject it
+	inputs := []string{texts[0], texts[1], texts[4]}
+
+	vectors, err := e.EmbedBatch(ctx, inputs)
+	if err != nil {
+		return fmt.Errorf("embed batch: %w", err)
+	}
+
+	if len(vectors) != len(inputs) {
+		return fmt.Errorf("embed batch: got %d vectors for %d texts", len(vectors), len(inputs))
+	}
+
+	for i, text := range inputs {
+		vec, err := e.Embed(ctx, text)
+		if err != nil {
+			return fmt.Errorf("embed: %w", err)
+		}
+		if len(vectors[i]) != len(vec) {
+			return fmt.Errorf("embed batch: vector %d has size %d, embed gives %d", i, len(vectors[i]), len(vec))
+		}
+		if sim := memorymanager.CosineSimilarity(vec, vectors[i]); sim < sameVector {
+			return fmt.Errorf("embed batch: vector %d is out of order or differs from embed, similarity %.3f", i, sim)
+		}
+	}
+
+	return nil
+}
+
+func emptyBatch(ctx context.Context, e embedder.Embedder) error {
+	vectors, err := e.EmbedBatch(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("embed batch: %w", err)
+	}
+
+	if len(vectors) != 0 {
+		return fmt.Errorf("embed batch: got %d vectors for no texts", len(vectors))
+	}
+
+	return nil
+}
+
+func deterministic(ctx context.Context, e embedder.Embedder) error {
+	first, err := e.Embed(ctx, texts[0])
+	if err != nil {
+		return fmt.Errorf("embed: %w", err)
+	}
+
+	second, err := e.Embed(ctx, texts[3])
+	if err != nil {
+		return fmt.Errorf("embed: %w", err)
+	}
+
+	if sim := memorymanager.CosineSimilarity(first, second); sim < sameVector {
+		return fmt.Errorf("embed: the same text gave vectors with similarity %.3f", sim)
+	}
+
+	other, err := e.Embed(ctx, texts[1])
+	if err != nil {
+		return fmt.Errorf("embed: %w", err)
+	}
+
+	if sim := memorymanager.CosineSimilarity(first, other); sim >= sameVector {
+		return fmt.Errorf("embed: different texts gave vectors with similarity %.3f", sim)
+	}
+
+	return nil
+}
+
+func concurrent(ctx context.Context, e embedder.Embedder) error {
+	const callers = 8
+
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+
+	for i := range callers {
+		wg.Go(func() {
+			if _, err := e.Embed(ctx, fmt.Sprintf("caller %d asks about %s", i, texts[1])); err != nil {
+				errs <- err
+			}
+		})
+	}
+
+	wg.Wait()
+	close(errs)
+
+	if err := <-errs; err != nil {
+		return fmt.Errorf("concurrent embed: %w", err)
+	}
+
+	return nil
+}
+
+func cancelled(ctx context.Context, e embedder.Embedder) error {
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	// a unique text so a cache in front of the model cannot answer
+	if _, err := e.Embed(ctx, fmt.Sprintf("%s %d", texts[1], time.Now().UnixNano())); err == nil {
+		return errors.New("embed: want an error for a cancelled context")
+	}
+
+	return nil
+}
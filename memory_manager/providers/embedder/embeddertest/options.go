@@ -0,0 +1,20 @@
+package embeddertest
+
+import (
+	"context"
+
+	"github.com/w-h-a/agent/memory_manager/providers/embedder"
+)
+
+type sizeKey struct{}
+
+func WithSize(size int) embedder.Option {
+	return func(o *embedder.Options) {
+		o.Context = context.WithValue(o.Context, sizeKey{}, size)
+	}
+}
+
+func SizeFrom(ctx context.Context) (int, bool) {
+	size, ok := ctx.Value(sizeKey{}).(int)
+	return size, ok
+}
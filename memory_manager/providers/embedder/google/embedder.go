@@ -3,12 +3,18 @@ package google
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/google/generative-ai-go/genai"
 	"github.com/w-h-a/agent/memory_manager/providers/embedder"
 	genaiopt "google.golang.org/api/option"
 )
 
+const (
+	// batchEmbedContents takes at most this many requests per call
+	maxBatchSize = 100
+)
+
 type googleEmbedder struct {
 	options embedder.Options
 	client  *genai.Client
@@ -28,6 +34,43 @@ func (e *googleEmbedder) Embed(ctx context.Context, text string) ([]float32, err
 	return rsp.Embedding.Values, nil
 }
 
+func (e *googleEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	model := e.client.EmbeddingModel(e.options.Model)
+
+	vectors := make([][]float32, 0, len(texts))
+
+	for start := 0; start < len(texts); start += maxBatchSize {
+		end := min(start+maxBatchSize, len(texts))
+
+		batch := model.NewBatch()
+		for _, text := range texts[start:end] {
+			batch.AddContent(genai.Text(text))
+		}
+
+		rsp, err := model.BatchEmbedContents(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+
+		if rsp == nil || len(rsp.Embeddings) != end-start {
+			return nil, errors.New("no response from Google")
+		}
+
+		for i, emb := range rsp.Embeddings {
+			if emb == nil || len(emb.Values) == 0 {
+				return nil, fmt.Errorf("no embedding from Google for input %d", start+i)
+			}
+			vectors = append(vectors, emb.Values)
+		}
+	}
+
+	return vectors, nil
+}
+
+func (e *googleEmbedder) Model() string {
+	return e.options.Model
+}
+
 func NewEmbedder(opts ...embedder.Option) embedder.Embedder {
 	options := embedder.NewOptions(opts...)
 
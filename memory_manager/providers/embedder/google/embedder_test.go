@@ -0,0 +1,27 @@
+package google_test
+
+import (
+	"cmp"
+	"os"
+	"testing"
+
+	"github.com/w-h-a/agent/memory_manager/providers/embedder"
+	"github.com/w-h-a/agent/memory_manager/providers/embedder/embeddertest"
+	"github.com/w-h-a/agent/memory_manager/providers/embedder/google"
+)
+
+func TestConformance(t *testing.T) {
+	if os.Getenv("INTEGRATION") != "1" {
+		t.Skip("set INTEGRATION=1 to run against the hosted model")
+	}
+
+	apiKey := os.Getenv("GOOGLE_API_KEY")
+	if len(apiKey) == 0 {
+		t.Skip("set GOOGLE_API_KEY to run against the hosted model")
+	}
+
+	embeddertest.Run(t, google.NewEmbedder(
+		embedder.WithApiKey(apiKey),
+		embedder.WithModel(cmp.Or(os.Getenv("GOOGLE_EMBEDDING_MODEL"), "text-embedding-004")),
+	))
+}
@@ -3,11 +3,17 @@ package openai
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/sashabaranov/go-openai"
 	"github.com/w-h-a/agent/memory_manager/providers/embedder"
 )
 
+const (
+	// the embeddings endpoint takes at most this many inputs per request
+	maxBatchSize = 2048
+)
+
 type openAIEmbedder struct {
 	options embedder.Options
 	client  *openai.Client
@@ -29,6 +35,41 @@ func (e *openAIEmbedder) Embed(ctx context.Context, text string) ([]float32, err
 	return rsp.Data[0].Embedding, nil
 }
 
+func (e *openAIEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+
+	for start := 0; start < len(texts); start += maxBatchSize {
+		end := min(start+maxBatchSize, len(texts))
+
+		rsp, err := e.client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+			Input: texts[start:end],
+			Model: openai.EmbeddingModel(e.options.Model),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, data := range rsp.Data {
+			if data.Index < 0 || start+data.Index >= end {
+				return nil, fmt.Errorf("unexpected embedding index %d from OpenAI", data.Index)
+			}
+			vectors[start+data.Index] = data.Embedding
+		}
+	}
+
+	for i, vec := range vectors {
+		if len(vec) == 0 {
+			return nil, fmt.Errorf("no embedding from OpenAI for input %d", i)
+		}
+	}
+
+	return vectors, nil
+}
+
+func (e *openAIEmbedder) Model() string {
+	return e.options.Model
+}
+
 func NewEmbedder(opts ...embedder.Option) embedder.Embedder {
 	options := embedder.NewOptions(opts...)
 
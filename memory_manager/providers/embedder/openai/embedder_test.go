@@ -0,0 +1,27 @@
+package openai_test
+
+import (
+	"cmp"
+	"os"
+	"testing"
+
+	"github.com/w-h-a/agent/memory_manager/providers/embedder"
+	"github.com/w-h-a/agent/memory_manager/providers/embedder/embeddertest"
+	"github.com/w-h-a/agent/memory_manager/providers/embedder/openai"
+)
+
+func TestConformance(t *testing.T) {
+	if os.Getenv("INTEGRATION") != "1" {
+		t.Skip("set INTEGRATION=1 to run against the hosted model")
+	}
+
+	apiKey := os.Getenv("OPENAI_API_KEY")
+	if len(apiKey) == 0 {
+		t.Skip("set OPENAI_API_KEY to run against the hosted model")
+	}
+
+	embeddertest.Run(t, openai.NewEmbedder(
+		embedder.WithApiKey(apiKey),
+		embedder.WithModel(cmp.Or(os.Getenv("OPENAI_EMBEDDING_MODEL"), "text-embedding-3-small")),
+	))
+}
@@ -0,0 +1,11 @@
+package embeddingcache
+
+import "context"
+
+// Cache holds vectors by key. Keys name both the model and the text so a
+// cache can be shared between embedders.
+type Cache interface {
+	// Get returns the vectors it holds and leaves misses out
+	Get(ctx context.Context, keys []string) (map[string][]float32, error)
+	Put(ctx context.Context, entries map[string][]float32) error
+}
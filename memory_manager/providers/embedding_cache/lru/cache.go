@@ -0,0 +1,85 @@
+package lru
+
+import (
+	"container/list"
+	"context"
+	"sync"
+
+	embeddingcache "github.com/w-h-a/agent/memory_manager/providers/embedding_cache"
+)
+
+const (
+	defaultSize = 10000
+)
+
+type entry struct {
+	key    string
+	vector []float32
+}
+
+type lruCache struct {
+	options embeddingcache.Options
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+	mtx     sync.Mutex
+}
+
+func (c *lruCache) Get(ctx context.Context, keys []string) (map[string][]float32, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	hits := map[string][]float32{}
+
+	for _, key := range keys {
+		el, ok := c.entries[key]
+		if !ok {
+			continue
+		}
+		c.order.MoveToFront(el)
+		hits[key] = el.Value.(*entry).vector
+	}
+
+	return hits, nil
+}
+
+func (c *lruCache) Put(ctx context.Context, entries map[string][]float32) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for key, vector := range entries {
+		if el, ok := c.entries[key]; ok {
+			el.Value.(*entry).vector = vector
+			c.order.MoveToFront(el)
+			continue
+		}
+
+		c.entries[key] = c.order.PushFront(&entry{key: key, vector: vector})
+
+		if c.order.Len() > c.size {
+			oldest := c.order.Back()
+			c.order.Remove(oldest)
+			delete(c.entries, oldest.Value.(*entry).key)
+		}
+	}
+
+	return nil
+}
+
+func NewCache(opts ...embeddingcache.Option) embeddingcache.Cache {
+	options := embeddingcache.NewOptions(opts...)
+
+	c := &lruCache{
+		options: options,
+		size:    options.Size,
+		order:   list.New(),
+		entries: map[string]*list.Element{},
+		mtx:     sync.Mutex{},
+	}
+
+	if c.size < 1 {
+		c.size = defaultSize
+	}
+
+	return c
+}
@@ -0,0 +1,34 @@
+package embeddingcache
+
+import "context"
+
+type Option func(*Options)
+
+type Options struct {
+	Location string
+	Size     int
+	Context  context.Context
+}
+
+func WithLocation(loc string) Option {
+	return func(o *Options) {
+		o.Location = loc
+	}
+}
+
+// WithSize caps the number of vectors kept, evicting the least recently used
+func WithSize(size int) Option {
+	return func(o *Options) {
+		o.Size = size
+	}
+}
+
+func NewOptions(opts ...Option) Options {
+	options := Options{
+		Context: context.Background(),
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
@@ -0,0 +1,174 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+
+	embeddingcache "github.com/w-h-a/agent/memory_manager/providers/embedding_cache"
+	_ "modernc.org/sqlite"
+)
+
+const (
+	schema = `
+CREATE TABLE IF NOT EXISTS embeddings (
+	key     TEXT PRIMARY KEY,
+	vector  BLOB NOT NULL,
+	used_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS embeddings_used_at_idx ON embeddings (used_at);
+`
+	// stays well under sqlite's limit on bound parameters
+	maxKeysPerQuery = 500
+)
+
+type sqliteCache struct {
+	options embeddingcache.Options
+	conn    *sql.DB
+}
+
+func (c *sqliteCache) Get(ctx context.Context, keys []string) (map[string][]float32, error) {
+	hits := map[string][]float32{}
+
+	for start := 0; start < len(keys); start += maxKeysPerQuery {
+		chunk := keys[start:min(start+maxKeysPerQuery, len(keys))]
+
+		args := make([]any, 0, len(chunk))
+		for _, key := range chunk {
+			args = append(args, key)
+		}
+
+		in := "?" + strings.Repeat(",?", len(chunk)-1)
+
+		rows, err := c.conn.QueryContext(ctx, `SELECT key, vector FROM embeddings WHERE key IN (`+in+`)`, args...)
+		if err != nil {
+			return nil, err
+		}
+
+		for rows.Next() {
+			var key string
+			var bs []byte
+			if err := rows.Scan(&key, &bs); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			hits[key] = decodeVector(bs)
+		}
+
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		// eviction goes by last use so hits are touched
+		if c.options.Size > 0 && len(hits) > 0 {
+			if _, err := c.conn.ExecContext(
+				ctx,
+				`UPDATE embeddings SET used_at = ? WHERE key IN (`+in+`)`,
+				append([]any{time.Now().UnixNano()}, args...)...,
+			); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return hits, nil
+}
+
+func (c *sqliteCache) Put(ctx context.Context, entries map[string][]float32) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	tx, err := c.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UnixNano()
+
+	for key, vector := range entries {
+		if _, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO embeddings (key, vector, used_at) VALUES (?, ?, ?)
+			ON CONFLICT (key) DO UPDATE SET vector = excluded.vector, used_at = excluded.used_at`,
+			key, encodeVector(vector), now,
+		); err != nil {
+			return err
+		}
+	}
+
+	if c.options.Size > 0 {
+		if _, err := tx.ExecContext(
+			ctx,
+			`DELETE FROM embeddings WHERE key NOT IN (SELECT key FROM embeddings ORDER BY used_at DESC LIMIT ?)`,
+			c.options.Size,
+		); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func encodeVector(vector []float32) []byte {
+	bs := make([]byte, 4*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(bs[i*4:], math.Float32bits(v))
+	}
+	return bs
+}
+
+func decodeVector(bs []byte) []float32 {
+	vector := make([]float32, len(bs)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(bs[i*4:]))
+	}
+	return vector
+}
+
+func dsn(location string) string {
+	if len(location) == 0 {
+		location = "embeddings.db"
+	}
+
+	pragmas := "_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)"
+
+	if strings.Contains(location, "?") {
+		return location + "&" + pragmas
+	}
+
+	return location + "?" + pragmas
+}
+
+func NewCache(opts ...embeddingcache.Option) embeddingcache.Cache {
+	options := embeddingcache.NewOptions(opts...)
+
+	c := &sqliteCache{
+		options: options,
+	}
+
+	// embeddings.db or file:/path/to/embeddings.db
+	conn, err := sql.Open("sqlite", dsn(options.Location))
+	if err != nil {
+		detail := "failed to open sqlite embedding cache"
+		slog.ErrorContext(context.Background(), detail, "error", err)
+		panic(detail)
+	}
+
+	if _, err := conn.Exec(schema); err != nil {
+		detail := "failed to create sqlite embedding cache schema"
+		slog.ErrorContext(context.Background(), detail, "error", err)
+		panic(detail)
+	}
+
+	c.conn = conn
+
+	return c
+}
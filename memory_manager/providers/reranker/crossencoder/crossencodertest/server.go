@@ -0,0 +1,51 @@
+package crossencodertest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/w-h-a/agent/memory_manager/providers/reranker/crossencoder"
+	"github.com/w-h-a/agent/memory_manager/providers/reranker/heuristic"
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+)
+
+// NewServer starts a local stand-in for a cross-encoder rerank endpoint. It
+// speaks the same API as the real thing but scores with the heuristic
+// reranker, so it needs no model. Close it when done.
+func NewServer() *httptest.Server {
+	scorer := heuristic.NewReranker()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req crossencoder.Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		records := make([]storer.Record, 0, len(req.Documents))
+		for _, doc := range req.Documents {
+			records = append(records, storer.Record{Content: doc})
+		}
+
+		scores, err := scorer.Rerank(context.Background(), req.Query, records)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		rsp := crossencoder.Response{Results: make([]crossencoder.Result, 0, len(scores))}
+		for i, score := range scores {
+			rsp.Results = append(rsp.Results, crossencoder.Result{Index: i, RelevanceScore: score})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(rsp)
+	}))
+}
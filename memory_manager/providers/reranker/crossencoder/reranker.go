@@ -0,0 +1,121 @@
+package crossencoder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/w-h-a/agent/memory_manager/providers/reranker"
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+)
+
+// Request and Response follow the rerank API shared by Cohere, Jina and
+// most self-hosted cross-encoder servers.
+type Request struct {
+	Model     string   `json:"model,omitempty"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+}
+
+type Response struct {
+	Results []Result `json:"results"`
+}
+
+type Result struct {
+	Index          int     `json:"index"`
+	RelevanceScore float64 `json:"relevance_score"`
+}
+
+type crossEncoderReranker struct {
+	options reranker.Options
+	client  *http.Client
+}
+
+func (r *crossEncoderReranker) Rerank(ctx context.Context, query string, records []storer.Record) ([]float64, error) {
+	scores := make([]float64, len(records))
+
+	if len(records) == 0 {
+		return scores, nil
+	}
+
+	documents := make([]string, 0, len(records))
+	for _, rec := range records {
+		documents = append(documents, rec.Content)
+	}
+
+	bs, err := json.Marshal(Request{
+		Model:     r.options.Model,
+		Query:     query,
+		Documents: documents,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.options.Location, bytes.NewReader(bs))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if len(r.options.ApiKey) > 0 {
+		req.Header.Set("Authorization", "Bearer "+r.options.ApiKey)
+	}
+
+	rsp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode >= 400 {
+		body, _ := io.ReadAll(rsp.Body)
+		return nil, fmt.Errorf("cross-encoder http %d: %s", rsp.StatusCode, string(body))
+	}
+
+	var res Response
+
+	if err := json.NewDecoder(rsp.Body).Decode(&res); err != nil {
+		return nil, err
+	}
+
+	for _, result := range res.Results {
+		if result.Index < 0 || result.Index >= len(records) {
+			continue
+		}
+		scores[result.Index] = normalize(result.RelevanceScore)
+	}
+
+	return scores, nil
+}
+
+// some servers return raw logits rather than probabilities
+func normalize(score float64) float64 {
+	if score >= 0 && score <= 1 {
+		return score
+	}
+	return 1 / (1 + math.Exp(-score))
+}
+
+func NewReranker(opts ...reranker.Option) reranker.Reranker {
+	options := reranker.NewOptions(opts...)
+
+	if len(options.Location) == 0 {
+		panic("missing location for cross-encoder reranker")
+	}
+
+	r := &crossEncoderReranker{
+		options: options,
+		client: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+	}
+
+	return r
+}
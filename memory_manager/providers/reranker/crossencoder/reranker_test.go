@@ -0,0 +1,56 @@
+package crossencoder_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/w-h-a/agent/memory_manager/providers/reranker"
+	"github.com/w-h-a/agent/memory_manager/providers/reranker/crossencoder"
+	"github.com/w-h-a/agent/memory_manager/providers/reranker/crossencoder/crossencodertest"
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+)
+
+func TestRerank(t *testing.T) {
+	srv := crossencodertest.NewServer()
+	defer srv.Close()
+
+	r := crossencoder.NewReranker(reranker.WithLocation(srv.URL))
+
+	records := []storer.Record{
+		{Content: "the weather in paris is mild"},
+		{Content: "ticket ABC-123 was closed yesterday"},
+		{Content: ""},
+	}
+
+	scores, err := r.Rerank(context.Background(), "what happened to ticket ABC-123", records)
+	if err != nil {
+		t.Fatalf("rerank: %v", err)
+	}
+
+	if len(scores) != len(records) {
+		t.Fatalf("want %d scores, got %d", len(records), len(scores))
+	}
+
+	for i, score := range scores {
+		if score < 0 || score > 1 {
+			t.Fatalf("score %d out of range: %v", i, score)
+		}
+	}
+
+	if scores[1] <= scores[0] || scores[1] <= scores[2] {
+		t.Fatalf("want the matching record ranked first, got %v", scores)
+	}
+}
+
+func TestRerankEmpty(t *testing.T) {
+	srv := crossencodertest.NewServer()
+	defer srv.Close()
+
+	scores, err := crossencoder.NewReranker(reranker.WithLocation(srv.URL)).Rerank(context.Background(), "query", nil)
+	if err != nil {
+		t.Fatalf("rerank: %v", err)
+	}
+	if len(scores) != 0 {
+		t.Fatalf("want no scores, got %v", scores)
+	}
+}
@@ -0,0 +1,113 @@
+package heuristic
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/w-h-a/agent/memory_manager/providers/reranker"
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+)
+
+type heuristicReranker struct {
+	options reranker.Options
+}
+
+func (r *heuristicReranker) Rerank(ctx context.Context, query string, records []storer.Record) ([]float64, error) {
+	scores := make([]float64, len(records))
+
+	queryTokens := unique(storer.Tokenize(query))
+	if len(queryTokens) == 0 {
+		return scores, nil
+	}
+
+	queryBigrams := bigrams(storer.Tokenize(query))
+
+	var identifiers []string
+	for _, token := range queryTokens {
+		if isIdentifier(token) {
+			identifiers = append(identifiers, token)
+		}
+	}
+
+	for i, rec := range records {
+		tokens := storer.Tokenize(rec.Content)
+		present := map[string]struct{}{}
+		for _, token := range tokens {
+			present[token] = struct{}{}
+		}
+
+		coverage := overlap(queryTokens, present)
+		phrase := overlap(queryBigrams, toSet(bigrams(tokens)))
+
+		// exact identifiers such as ticket numbers outweigh ordinary words
+		if len(identifiers) > 0 {
+			scores[i] = 0.4*coverage + 0.2*phrase + 0.4*overlap(identifiers, present)
+		} else {
+			scores[i] = 0.7*coverage + 0.3*phrase
+		}
+	}
+
+	return scores, nil
+}
+
+func overlap(want []string, present map[string]struct{}) float64 {
+	if len(want) == 0 {
+		return 0
+	}
+	hits := 0
+	for _, w := range want {
+		if _, ok := present[w]; ok {
+			hits++
+		}
+	}
+	return float64(hits) / float64(len(want))
+}
+
+func unique(tokens []string) []string {
+	seen := map[string]struct{}{}
+	out := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		if _, ok := seen[token]; ok {
+			continue
+		}
+		seen[token] = struct{}{}
+		out = append(out, token)
+	}
+	return out
+}
+
+func bigrams(tokens []string) []string {
+	if len(tokens) < 2 {
+		return nil
+	}
+	out := make([]string, 0, len(tokens)-1)
+	for i := 0; i+1 < len(tokens); i++ {
+		out = append(out, tokens[i]+" "+tokens[i+1])
+	}
+	return unique(out)
+}
+
+func toSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return set
+}
+
+func isIdentifier(token string) bool {
+	return strings.ContainsFunc(token, func(r rune) bool {
+		return unicode.IsDigit(r) || r == '-' || r == '.' || r == '_'
+	})
+}
+
+func NewReranker(opts ...reranker.Option) reranker.Reranker {
+	options := reranker.NewOptions(opts...)
+
+	r := &heuristicReranker{
+		options: options,
+	}
+
+	return r
+}
@@ -0,0 +1,21 @@
+package llm
+
+import (
+	"context"
+
+	"github.com/w-h-a/agent/generator"
+	"github.com/w-h-a/agent/memory_manager/providers/reranker"
+)
+
+type generatorKey struct{}
+
+func WithGenerator(gen generator.Generator) reranker.Option {
+	return func(o *reranker.Options) {
+		o.Context = context.WithValue(o.Context, generatorKey{}, gen)
+	}
+}
+
+func GeneratorFrom(ctx context.Context) (generator.Generator, bool) {
+	gen, ok := ctx.Value(generatorKey{}).(generator.Generator)
+	return gen, ok
+}
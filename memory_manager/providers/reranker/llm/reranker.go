@@ -0,0 +1,84 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/w-h-a/agent/generator"
+	"github.com/w-h-a/agent/memory_manager/providers/reranker"
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+	extractjson "github.com/w-h-a/agent/util/extract_json"
+)
+
+const (
+	maxDocumentChars = 600
+)
+
+type llmReranker struct {
+	options   reranker.Options
+	generator generator.Generator
+}
+
+func (r *llmReranker) Rerank(ctx context.Context, query string, records []storer.Record) ([]float64, error) {
+	scores := make([]float64, len(records))
+
+	if len(records) == 0 {
+		return scores, nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("You judge how useful each memory is for answering a query.\n\n")
+	sb.WriteString("Query:\n")
+	sb.WriteString(query)
+	sb.WriteString("\n\nMemories:\n")
+	for i, rec := range records {
+		content := rec.Content
+		if runes := []rune(content); len(runes) > maxDocumentChars {
+			content = string(runes[:maxDocumentChars]) + "..."
+		}
+		sb.WriteString(fmt.Sprintf("%d. %s\n", i, strings.ReplaceAll(content, "\n", " ")))
+	}
+	sb.WriteString("\nScore every memory from 0 (irrelevant) to 10 (directly answers the query).\n")
+	sb.WriteString("Reply with only a JSON array such as [{\"index\": 0, \"score\": 7}].\n")
+
+	rsp, err := r.generator.Generate(ctx, sb.String())
+	if err != nil {
+		return nil, err
+	}
+
+	var judged []struct {
+		Index int     `json:"index"`
+		Score float64 `json:"score"`
+	}
+
+	if err := extractjson.Into(rsp, &judged); err != nil {
+		return nil, fmt.Errorf("failed to parse rerank judgement: %w", err)
+	}
+
+	// memories the model skipped keep a score of 0
+	for _, j := range judged {
+		if j.Index < 0 || j.Index >= len(records) {
+			continue
+		}
+		scores[j.Index] = min(max(j.Score/10, 0), 1)
+	}
+
+	return scores, nil
+}
+
+func NewReranker(opts ...reranker.Option) reranker.Reranker {
+	options := reranker.NewOptions(opts...)
+
+	gen, ok := GeneratorFrom(options.Context)
+	if !ok || gen == nil {
+		panic("missing generator for llm reranker")
+	}
+
+	r := &llmReranker{
+		options:   options,
+		generator: gen,
+	}
+
+	return r
+}
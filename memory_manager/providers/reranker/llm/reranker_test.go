@@ -0,0 +1,45 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+)
+
+type promptRecorder struct {
+	prompt string
+	reply  string
+}
+
+func (g *promptRecorder) Generate(ctx context.Context, prompt string) (string, error) {
+	g.prompt = prompt
+	return g.reply, nil
+}
+
+func TestRerankTruncatesByRune(t *testing.T) {
+	gen := &promptRecorder{reply: `[{"index": 0, "score": 8}]`}
+	r := NewReranker(WithGenerator(gen))
+
+	// a three byte rune straddles the byte offset of the limit
+	content := strings.Repeat("a", maxDocumentChars-1) + strings.Repeat("€", 10)
+
+	scores, err := r.Rerank(context.Background(), "query", []storer.Record{{Content: content}})
+	if err != nil {
+		t.Fatalf("rerank: %v", err)
+	}
+
+	if !utf8.ValidString(gen.prompt) {
+		t.Fatal("prompt is not valid utf-8")
+	}
+
+	if want := strings.Repeat("a", maxDocumentChars-1) + "€..."; !strings.Contains(gen.prompt, want) {
+		t.Fatalf("prompt does not keep the first %d runes", maxDocumentChars)
+	}
+
+	if len(scores) != 1 || scores[0] != 0.8 {
+		t.Fatalf("want [0.8], got %v", scores)
+	}
+}
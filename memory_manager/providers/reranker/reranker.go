@@ -0,0 +1,13 @@
+package reranker
+
+import (
+	"context"
+
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+)
+
+// Reranker scores each record's relevance to the query between 0 and 1, in
+// the same order as the records it is given.
+type Reranker interface {
+	Rerank(ctx context.Context, query string, records []storer.Record) ([]float64, error)
+}
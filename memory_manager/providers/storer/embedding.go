@@ -0,0 +1,49 @@
+package storer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var ErrDimensionMismatch = errors.New("vector dimension does not match the storer")
+
+// Reembedder is implemented by storers that can hold a second embedding
+// per record while a space moves to another model. Staged embeddings are
+// never searched so the space keeps answering with its live ones until
+// PromoteEmbeddings swaps them in.
+type Reembedder interface {
+	// PendingEmbeddings returns records in the space with neither a live
+	// nor a staged embedding from model
+	PendingEmbeddings(ctx context.Context, spaceId string, model string, limit int) ([]Record, error)
+	StageEmbeddings(ctx context.Context, model string, vectors map[string][]float32) error
+	// PromoteEmbeddings makes every embedding staged from model live and
+	// returns how many records moved over
+	PromoteEmbeddings(ctx context.Context, spaceId string, model string) (int, error)
+	// OtherEmbeddingModel returns the live model of any record in the space
+	// embedded by something other than model, or empty when there is none.
+	// Records stored before models were recorded match every model.
+	OtherEmbeddingModel(ctx context.Context, spaceId string, model string) (string, error)
+}
+
+// ModelScope matches records embedded by model along with those stored
+// before models were recorded
+func ModelScope(model string) *Filter {
+	return In(FieldEmbeddingModel, model, "")
+}
+
+// TakeEmbeddingModel removes the model a caller recorded in metadata so
+// the storer can keep it alongside the vector instead
+func TakeEmbeddingModel(metadata map[string]any) string {
+	model, _ := metadata[FieldEmbeddingModel].(string)
+	delete(metadata, FieldEmbeddingModel)
+	return model
+}
+
+// CheckDimension is a no-op for storers that were not given a vector size
+func CheckDimension(size uint64, vector []float32) error {
+	if size == 0 || uint64(len(vector)) == size {
+		return nil
+	}
+	return fmt.Errorf("%w: got %d want %d", ErrDimensionMismatch, len(vector), size)
+}
@@ -0,0 +1,22 @@
+package storer
+
+import "time"
+
+type Entity struct {
+	Id         string         `json:"id"`
+	SpaceId    string         `json:"space_id,omitempty"`
+	Name       string         `json:"name"`
+	Kind       string         `json:"kind"`
+	Aliases    []string       `json:"aliases,omitempty"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+	Embedding  []float32      `json:"embedding,omitempty"`
+	Score      float32        `json:"score,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at,omitempty"`
+}
+
+type Relation struct {
+	SourceId string `json:"source_id"`
+	TargetId string `json:"target_id"`
+	Type     string `json:"type"`
+}
@@ -0,0 +1,345 @@
+package storer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ExportVersion is bumped whenever the shape of an Item changes in a way
+// older readers cannot ignore
+const ExportVersion = 1
+
+type ItemKind string
+
+const (
+	KindHeader   ItemKind = "header"
+	KindRecord   ItemKind = "record"
+	KindEntity   ItemKind = "entity"
+	KindRelation ItemKind = "relation"
+	KindSkill    ItemKind = "skill"
+	KindChunk    ItemKind = "chunk"
+)
+
+// Item is one line of an export. Exactly one of the payload fields is set
+// and matches Kind.
+type Item struct {
+	Kind     ItemKind  `json:"kind"`
+	Header   *Header   `json:"header,omitempty"`
+	Record   *Record   `json:"record,omitempty"`
+	Entity   *Entity   `json:"entity,omitempty"`
+	Relation *Relation `json:"relation,omitempty"`
+	Skill    *Skill    `json:"skill,omitempty"`
+	Chunk    *Chunk    `json:"chunk,omitempty"`
+}
+
+type Header struct {
+	Version    int       `json:"version"`
+	SpaceId    string    `json:"space_id"`
+	Dimension  int       `json:"dimension,omitempty"`
+	ExportedAt time.Time `json:"exported_at"`
+}
+
+type Skill struct {
+	Id        string    `json:"id"`
+	SpaceId   string    `json:"space_id"`
+	Trigger   string    `json:"trigger"`
+	SOP       string    `json:"sop"`
+	Embedding []float32 `json:"embedding,omitempty"`
+}
+
+type Chunk struct {
+	Id         string    `json:"id"`
+	SpaceId    string    `json:"space_id"`
+	FileId     string    `json:"file_id"`
+	Filename   string    `json:"filename"`
+	ChunkIndex int       `json:"chunk_index"`
+	Content    string    `json:"content"`
+	Embedding  []float32 `json:"embedding,omitempty"`
+}
+
+type ImportStats struct {
+	Records   int `json:"records"`
+	Edges     int `json:"edges"`
+	Entities  int `json:"entities"`
+	Relations int `json:"relations"`
+	Skills    int `json:"skills"`
+	Files     int `json:"files"`
+	Chunks    int `json:"chunks"`
+	// relations whose entities are missing from the stream
+	Skipped int `json:"skipped"`
+}
+
+type ItemWriter interface {
+	Write(item Item) error
+}
+
+// ItemReader returns io.EOF once the stream is exhausted
+type ItemReader interface {
+	Read() (Item, error)
+}
+
+// Exporter streams a space's records followed by its entities and
+// relations, then any skills and file chunks. Record edges are always in
+// metadata["edges"].
+type Exporter interface {
+	Export(ctx context.Context, spaceId string, w ItemWriter) error
+}
+
+// Importer writes every item into the space named on the item. Targets
+// assign their own ids so edges and relations are rewritten as they go.
+type Importer interface {
+	Import(ctx context.Context, r ItemReader) (ImportStats, error)
+}
+
+type ItemWriterFunc func(item Item) error
+
+func (f ItemWriterFunc) Write(item Item) error {
+	return f(item)
+}
+
+type ItemReaderFunc func() (Item, error)
+
+func (f ItemReaderFunc) Read() (Item, error) {
+	return f()
+}
+
+type jsonlWriter struct {
+	enc *json.Encoder
+}
+
+func (w *jsonlWriter) Write(item Item) error {
+	return w.enc.Encode(item)
+}
+
+// NewJSONLWriter writes the header line straight away and one item per line after it
+func NewJSONLWriter(w io.Writer, header Header) (ItemWriter, error) {
+	header.Version = ExportVersion
+	if header.ExportedAt.IsZero() {
+		header.ExportedAt = time.Now().UTC()
+	}
+
+	jw := &jsonlWriter{enc: json.NewEncoder(w)}
+
+	if err := jw.Write(Item{Kind: KindHeader, Header: &header}); err != nil {
+		return nil, err
+	}
+
+	return jw, nil
+}
+
+type jsonlReader struct {
+	scanner *bufio.Scanner
+	header  Header
+}
+
+func (r *jsonlReader) Read() (Item, error) {
+	for r.scanner.Scan() {
+		line := r.scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var item Item
+		if err := json.Unmarshal(line, &item); err != nil {
+			return Item{}, fmt.Errorf("export: %w", err)
+		}
+
+		if item.Kind == KindHeader {
+			continue
+		}
+
+		return item, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return Item{}, err
+	}
+
+	return Item{}, io.EOF
+}
+
+// NewJSONLReader reads and checks the header line before handing back the reader
+func NewJSONLReader(r io.Reader) (ItemReader, Header, error) {
+	scanner := bufio.NewScanner(r)
+	// embeddings make for long lines
+	scanner.Buffer(make([]byte, 0, 1024*1024), 64*1024*1024)
+
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, Header{}, err
+		}
+		return nil, Header{}, fmt.Errorf("export: missing header")
+	}
+
+	var item Item
+	if err := json.Unmarshal(scanner.Bytes(), &item); err != nil {
+		return nil, Header{}, fmt.Errorf("export: %w", err)
+	}
+
+	if item.Kind != KindHeader || item.Header == nil {
+		return nil, Header{}, fmt.Errorf("export: first line is %q not a header", item.Kind)
+	}
+
+	if item.Header.Version > ExportVersion {
+		return nil, Header{}, fmt.Errorf("export: version %d is newer than supported version %d", item.Header.Version, ExportVersion)
+	}
+
+	return &jsonlReader{scanner: scanner, header: *item.Header}, *item.Header, nil
+}
+
+// ImportTarget is what a storer provides to ImportItems. InsertRecord must
+// keep the record's timestamps but ignore its id and edges and return the
+// id it assigned. A storer without entities, skills or files leaves those
+// fields nil and importing such items fails.
+type ImportTarget struct {
+	InsertRecord func(ctx context.Context, rec Record) (string, error)
+	AddEdges     func(ctx context.Context, sourceId string, edges []map[string]string) error
+	Entities     EntityStorer
+	InsertSkill  func(ctx context.Context, skill Skill) error
+	// InsertFile returns the id it assigned to the file chunks are kept under
+	InsertFile  func(ctx context.Context, spaceId string, filename string) (string, error)
+	InsertChunk func(ctx context.Context, chunk Chunk) error
+}
+
+// ImportItems does the id bookkeeping shared by every storer. Edges to
+// records not yet seen are held back until the end of the stream.
+func ImportItems(ctx context.Context, r ItemReader, target ImportTarget) (ImportStats, error) {
+	stats := ImportStats{}
+
+	records := map[string]string{}
+	entities := map[string]Entity{}
+	files := map[string]string{}
+	pending := map[string][]map[string]string{}
+
+	addEdges := func(sourceId string, edges []map[string]string) error {
+		if len(edges) == 0 {
+			return nil
+		}
+		if err := target.AddEdges(ctx, sourceId, edges); err != nil {
+			return err
+		}
+		stats.Edges += len(edges)
+		return nil
+	}
+
+	for {
+		item, err := r.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return stats, err
+		}
+
+		switch {
+		case !target.supports(item.Kind):
+			return stats, fmt.Errorf("export: this storer cannot import %s items", item.Kind)
+		case item.Kind == KindRecord && item.Record != nil:
+			rec := *item.Record
+
+			metadata := make(map[string]any, len(rec.Metadata))
+			for k, v := range rec.Metadata {
+				metadata[k] = v
+			}
+			edges := SanitizeEdges(metadata)
+			delete(metadata, "edges")
+			rec.Metadata = metadata
+
+			id, err := target.InsertRecord(ctx, rec)
+			if err != nil {
+				return stats, err
+			}
+			records[rec.Id] = id
+			stats.Records++
+
+			ready := []map[string]string{}
+			for _, edge := range edges {
+				if newId, ok := records[edge["target"]]; ok {
+					ready = append(ready, map[string]string{"target": newId, "type": edge["type"]})
+				} else {
+					pending[id] = append(pending[id], edge)
+				}
+			}
+
+			if err := addEdges(id, ready); err != nil {
+				return stats, err
+			}
+		case item.Kind == KindEntity && item.Entity != nil:
+			entity := *item.Entity
+			oldId := entity.Id
+			entity.Id = ""
+			id, err := target.Entities.UpsertEntity(ctx, entity.SpaceId, entity)
+			if err != nil {
+				return stats, err
+			}
+			entity.Id = id
+			entities[oldId] = entity
+			stats.Entities++
+		case item.Kind == KindRelation && item.Relation != nil:
+			source, ok1 := entities[item.Relation.SourceId]
+			dest, ok2 := entities[item.Relation.TargetId]
+			if !ok1 || !ok2 {
+				stats.Skipped++
+				continue
+			}
+			if err := target.Entities.Relate(ctx, source.SpaceId, Relation{SourceId: source.Id, TargetId: dest.Id, Type: item.Relation.Type}); err != nil {
+				return stats, err
+			}
+			stats.Relations++
+		case item.Kind == KindSkill && item.Skill != nil:
+			if err := target.InsertSkill(ctx, *item.Skill); err != nil {
+				return stats, err
+			}
+			stats.Skills++
+		case item.Kind == KindChunk && item.Chunk != nil:
+			chunk := *item.Chunk
+			fileId, ok := files[chunk.FileId]
+			if !ok {
+				fileId, err = target.InsertFile(ctx, chunk.SpaceId, chunk.Filename)
+				if err != nil {
+					return stats, err
+				}
+				files[chunk.FileId] = fileId
+				stats.Files++
+			}
+			chunk.FileId = fileId
+			if err := target.InsertChunk(ctx, chunk); err != nil {
+				return stats, err
+			}
+			stats.Chunks++
+		default:
+			return stats, fmt.Errorf("export: malformed %s item", item.Kind)
+		}
+	}
+
+	for sourceId, edges := range pending {
+		ready := []map[string]string{}
+		for _, edge := range edges {
+			if newId, ok := records[edge["target"]]; ok {
+				ready = append(ready, map[string]string{"target": newId, "type": edge["type"]})
+			}
+		}
+		if err := addEdges(sourceId, ready); err != nil {
+			return stats, err
+		}
+	}
+
+	return stats, nil
+}
+
+func (t ImportTarget) supports(kind ItemKind) bool {
+	switch kind {
+	case KindRecord:
+		return true
+	case KindEntity, KindRelation:
+		return t.Entities != nil
+	case KindSkill:
+		return t.InsertSkill != nil
+	case KindChunk:
+		return t.InsertFile != nil && t.InsertChunk != nil
+	}
+	return false
+}
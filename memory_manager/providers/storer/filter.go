@@ -0,0 +1,252 @@
+package storer
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+type Op string
+
+const (
+	OpEq     Op = "eq"
+	OpIn     Op = "in"
+	OpRange  Op = "range"
+	OpExists Op = "exists"
+	OpAnd    Op = "and"
+	OpOr     Op = "or"
+)
+
+// fields that map onto record columns rather than metadata keys
+const (
+	FieldSessionId      = "session_id"
+	FieldCreatedAt      = "created_at"
+	FieldEmbeddingModel = "embedding_model"
+)
+
+var validField = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Filter is a backend independent predicate over a record. Field is either
+// one of the record fields above or a top-level metadata key. Range bounds
+// are inclusive and a nil bound leaves that side open.
+type Filter struct {
+	Op      Op
+	Field   string
+	Value   any
+	Values  []any
+	Min     any
+	Max     any
+	Filters []*Filter
+}
+
+func Eq(field string, value any) *Filter {
+	return &Filter{Op: OpEq, Field: field, Value: value}
+}
+
+func In(field string, values ...any) *Filter {
+	return &Filter{Op: OpIn, Field: field, Values: values}
+}
+
+func Range(field string, min any, max any) *Filter {
+	return &Filter{Op: OpRange, Field: field, Min: min, Max: max}
+}
+
+func Exists(field string) *Filter {
+	return &Filter{Op: OpExists, Field: field}
+}
+
+func And(filters ...*Filter) *Filter {
+	return &Filter{Op: OpAnd, Filters: filters}
+}
+
+func Or(filters ...*Filter) *Filter {
+	return &Filter{Op: OpOr, Filters: filters}
+}
+
+func (f *Filter) Validate() error {
+	if f == nil {
+		return nil
+	}
+
+	switch f.Op {
+	case OpAnd, OpOr:
+		for _, child := range f.Filters {
+			if child == nil {
+				return fmt.Errorf("filter: nil clause in %s", f.Op)
+			}
+			if err := child.Validate(); err != nil {
+				return err
+			}
+		}
+		return nil
+	case OpEq, OpIn, OpRange, OpExists:
+	default:
+		return fmt.Errorf("filter: unknown op %q", f.Op)
+	}
+
+	if !validField.MatchString(f.Field) {
+		return fmt.Errorf("filter: invalid field %q", f.Field)
+	}
+
+	if f.Op == OpRange && f.Min == nil && f.Max == nil {
+		return fmt.Errorf("filter: range on %s has no bounds", f.Field)
+	}
+
+	return nil
+}
+
+func (f *Filter) Matches(rec Record) bool {
+	if f == nil {
+		return true
+	}
+
+	switch f.Op {
+	case OpAnd:
+		for _, child := range f.Filters {
+			if !child.Matches(rec) {
+				return false
+			}
+		}
+		return true
+	case OpOr:
+		for _, child := range f.Filters {
+			if child.Matches(rec) {
+				return true
+			}
+		}
+		return false
+	}
+
+	v, ok := fieldValue(rec, f.Field)
+
+	switch f.Op {
+	case OpExists:
+		if f.Field == FieldEmbeddingModel {
+			return len(rec.EmbeddingModel) > 0
+		}
+		return ok
+	case OpEq:
+		return ok && compare(v, f.Value) == 0
+	case OpIn:
+		if !ok {
+			return false
+		}
+		for _, candidate := range f.Values {
+			if compare(v, candidate) == 0 {
+				return true
+			}
+		}
+		return false
+	case OpRange:
+		if !ok {
+			return false
+		}
+		if f.Min != nil {
+			if c := compare(v, f.Min); c < 0 || c == incomparable {
+				return false
+			}
+		}
+		if f.Max != nil {
+			if c := compare(v, f.Max); c > 0 || c == incomparable {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+func fieldValue(rec Record, field string) (any, bool) {
+	switch field {
+	case FieldSessionId:
+		return rec.SessionId, len(rec.SessionId) > 0
+	case FieldCreatedAt:
+		return rec.CreatedAt, !rec.CreatedAt.IsZero()
+	case FieldEmbeddingModel:
+		// an empty model is a value so untracked records can be matched
+		return rec.EmbeddingModel, true
+	}
+
+	v, ok := rec.Metadata[field]
+	if !ok || v == nil {
+		return nil, false
+	}
+
+	return v, true
+}
+
+const incomparable = 2
+
+func compare(a any, b any) int {
+	if x, ok := ToFloat(a); ok {
+		if y, ok := ToFloat(b); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+
+	if x, ok := ToTime(a); ok {
+		if y, ok := ToTime(b); ok {
+			return x.Compare(y)
+		}
+	}
+
+	switch x := a.(type) {
+	case string:
+		if y, ok := b.(string); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	case bool:
+		if y, ok := b.(bool); ok && x == y {
+			return 0
+		}
+	}
+
+	return incomparable
+}
+
+func ToFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func ToTime(v any) (time.Time, bool) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, true
+	case string:
+		if parsed, err := time.Parse(time.RFC3339Nano, t); err == nil {
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,28 @@
+package storer
+
+import (
+	"strings"
+	"unicode"
+)
+
+func Tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' && r != '.'
+	})
+
+	tokens := make([]string, 0, len(fields))
+	for _, field := range fields {
+		field = strings.Trim(field, "-.")
+		if len(field) == 0 {
+			continue
+		}
+		tokens = append(tokens, field)
+
+		// identifiers such as ERR-1234 or pkg.Func also match on their parts
+		if parts := strings.FieldsFunc(field, func(r rune) bool { return r == '-' || r == '.' }); len(parts) > 1 {
+			tokens = append(tokens, parts...)
+		}
+	}
+
+	return tokens
+}
@@ -0,0 +1,149 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	memorymanager "github.com/w-h-a/agent/memory_manager"
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+)
+
+func (s *memoryStorer) UpsertEntity(ctx context.Context, spaceId string, entity storer.Entity) (string, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	now := time.Now().UTC()
+
+	if len(entity.Id) > 0 {
+		existing, ok := s.entities[entity.Id]
+		if !ok || existing.SpaceId != spaceId {
+			return "", fmt.Errorf("entity %s not found", entity.Id)
+		}
+		entity.CreatedAt = existing.CreatedAt
+	} else {
+		entity.Id = uuid.New().String()
+		entity.CreatedAt = now
+	}
+
+	entity.SpaceId = spaceId
+	entity.UpdatedAt = now
+	entity.Score = 0
+	entity.Aliases = append([]string(nil), entity.Aliases...)
+	entity.Attributes = maps.Clone(entity.Attributes)
+	entity.Embedding = append([]float32(nil), entity.Embedding...)
+
+	s.entities[entity.Id] = entity
+
+	return entity.Id, nil
+}
+
+func (s *memoryStorer) SearchEntities(ctx context.Context, spaceId string, vector []float32, limit int) ([]storer.Entity, error) {
+	if limit < 1 {
+		return nil, nil
+	}
+
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	candidates := make([]storer.Entity, 0, len(s.entities))
+
+	for _, entity := range s.entities {
+		if entity.SpaceId != spaceId {
+			continue
+		}
+		entity.Score = float32(memorymanager.CosineSimilarity(vector, entity.Embedding))
+		candidates = append(candidates, entity)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	return candidates, nil
+}
+
+func (s *memoryStorer) Relate(ctx context.Context, spaceId string, relation storer.Relation) error {
+	relation.Type = storer.SanitizeType(relation.Type)
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for _, id := range []string{relation.SourceId, relation.TargetId} {
+		if entity, ok := s.entities[id]; !ok || entity.SpaceId != spaceId {
+			return fmt.Errorf("entity %s not found", id)
+		}
+	}
+
+	key := relation.SourceId + "|" + relation.TargetId + "|" + relation.Type
+	s.relations[key] = relation
+
+	return nil
+}
+
+func (s *memoryStorer) SearchEntityGraph(ctx context.Context, spaceId string, seedIds []string, hops int, limit int) ([]storer.Entity, []storer.Relation, error) {
+	if limit < 1 || len(seedIds) == 0 {
+		return nil, nil, nil
+	}
+
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	adjacent := map[string][]string{}
+	for _, rel := range s.relations {
+		adjacent[rel.SourceId] = append(adjacent[rel.SourceId], rel.TargetId)
+		adjacent[rel.TargetId] = append(adjacent[rel.TargetId], rel.SourceId)
+	}
+
+	included := map[string]struct{}{}
+	var entities []storer.Entity
+
+	frontier := seedIds
+
+	for depth := 0; depth <= hops && len(frontier) > 0; depth++ {
+		next := []string{}
+		for _, id := range frontier {
+			if _, seen := included[id]; seen {
+				continue
+			}
+			entity, ok := s.entities[id]
+			if !ok || entity.SpaceId != spaceId {
+				continue
+			}
+			included[id] = struct{}{}
+			entities = append(entities, entity)
+			if len(entities) >= limit {
+				return entities, s.relationsWithin(included), nil
+			}
+			next = append(next, adjacent[id]...)
+		}
+		frontier = next
+	}
+
+	return entities, s.relationsWithin(included), nil
+}
+
+func (s *memoryStorer) relationsWithin(included map[string]struct{}) []storer.Relation {
+	var relations []storer.Relation
+	for _, rel := range s.relations {
+		_, source := included[rel.SourceId]
+		_, target := included[rel.TargetId]
+		if source && target {
+			relations = append(relations, rel)
+		}
+	}
+	sort.Slice(relations, func(i, j int) bool {
+		if relations[i].SourceId != relations[j].SourceId {
+			return relations[i].SourceId < relations[j].SourceId
+		}
+		return relations[i].TargetId < relations[j].TargetId
+	})
+	return relations
+}
@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+)
+
+func (s *memoryStorer) Export(ctx context.Context, spaceId string, w storer.ItemWriter) error {
+	// copy out under the lock so a slow writer never blocks the store
+	s.mtx.RLock()
+
+	records := []storer.Record{}
+	for _, rec := range s.records {
+		if rec.SpaceId == spaceId {
+			rec.Metadata = maps.Clone(rec.Metadata)
+			records = append(records, rec)
+		}
+	}
+
+	entities := []storer.Entity{}
+	for _, entity := range s.entities {
+		if entity.SpaceId == spaceId {
+			entities = append(entities, entity)
+		}
+	}
+
+	relations := []storer.Relation{}
+	for _, relation := range s.relations {
+		if source, ok := s.entities[relation.SourceId]; ok && source.SpaceId == spaceId {
+			relations = append(relations, relation)
+		}
+	}
+
+	s.mtx.RUnlock()
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].CreatedAt.Before(records[j].CreatedAt)
+	})
+
+	for i := range records {
+		if err := w.Write(storer.Item{Kind: storer.KindRecord, Record: &records[i]}); err != nil {
+			return err
+		}
+	}
+
+	for i := range entities {
+		if err := w.Write(storer.Item{Kind: storer.KindEntity, Entity: &entities[i]}); err != nil {
+			return err
+		}
+	}
+
+	for i := range relations {
+		if err := w.Write(storer.Item{Kind: storer.KindRelation, Relation: &relations[i]}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *memoryStorer) Import(ctx context.Context, r storer.ItemReader) (storer.ImportStats, error) {
+	return storer.ImportItems(ctx, r, storer.ImportTarget{
+		InsertRecord: s.insert,
+		AddEdges:     s.addEdges,
+		Entities:     s,
+	})
+}
+
+func (s *memoryStorer) insert(ctx context.Context, rec storer.Record) (string, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	rec.Id = uuid.New().String()
+	rec.Score = 0
+	rec.Metadata = maps.Clone(rec.Metadata)
+	rec.Embedding = append([]float32(nil), rec.Embedding...)
+
+	if rec.CreatedAt.IsZero() {
+		rec.CreatedAt = time.Now().UTC()
+	}
+
+	s.records[rec.Id] = rec
+
+	if s.lexical != nil {
+		s.lexical.add(rec.Id, rec.Content)
+	}
+
+	s.index(rec)
+
+	return rec.Id, nil
+}
+
+func (s *memoryStorer) addEdges(ctx context.Context, sourceId string, edges []map[string]string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	rec, ok := s.records[sourceId]
+	if !ok {
+		return nil
+	}
+
+	metadata := maps.Clone(rec.Metadata)
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+
+	storer.AddEdges(metadata, edges)
+
+	rec.Metadata = metadata
+	s.records[sourceId] = rec
+
+	return nil
+}
@@ -0,0 +1,426 @@
+package memory
+
+import (
+	"container/heap"
+	"math"
+	"math/rand/v2"
+	"sort"
+)
+
+type hnswNode struct {
+	id string
+	// unit length so similarity is a dot product
+	vector    []float32
+	level     int
+	neighbors [][]int32
+}
+
+// nodes are addressed by slot so the hot loops avoid hashing ids
+type hnswIndex struct {
+	config    HNSWConfig
+	nodes     []*hnswNode
+	slots     map[string]int32
+	entry     int32
+	maxLevel  int
+	levelMult float64
+	rng       *rand.Rand
+}
+
+type hnswCandidate struct {
+	slot  int32
+	score float64
+}
+
+func (x *hnswIndex) insert(id string, vector []float32) {
+	level := int(math.Floor(-math.Log(1-x.rng.Float64()) * x.levelMult))
+
+	x.add(&hnswNode{
+		id:        id,
+		vector:    normalize(vector),
+		level:     level,
+		neighbors: make([][]int32, level+1),
+	})
+
+	slot := x.slots[id]
+	node := x.nodes[slot]
+
+	if x.entry < 0 {
+		x.entry = slot
+		x.maxLevel = level
+		return
+	}
+
+	entries := x.descend(node.vector, level)
+
+	for l := min(level, x.maxLevel); l >= 0; l-- {
+		candidates := x.searchLayer(node.vector, entries, x.config.EfConstruction, l)
+
+		selected := x.selectNeighbors(candidates, x.config.M)
+		node.neighbors[l] = slotsOf(selected)
+
+		for _, c := range selected {
+			neighbor := x.nodes[c.slot]
+			neighbor.neighbors[l] = append(neighbor.neighbors[l], slot)
+			if len(neighbor.neighbors[l]) > x.maxConnections(l) {
+				x.prune(neighbor, l)
+			}
+		}
+
+		entries = slotsOf(candidates)
+	}
+
+	if level > x.maxLevel {
+		x.maxLevel = level
+		x.entry = slot
+	}
+}
+
+func (x *hnswIndex) add(node *hnswNode) {
+	x.slots[node.id] = int32(len(x.nodes))
+	x.nodes = append(x.nodes, node)
+}
+
+func (x *hnswIndex) search(vector []float32, k int, ef int) []hnswCandidate {
+	if x.entry < 0 || k < 1 {
+		return nil
+	}
+
+	query := normalize(vector)
+
+	entries := x.descend(query, 0)
+
+	results := x.searchLayer(query, entries, max(ef, k), 0)
+	if len(results) > k {
+		results = results[:k]
+	}
+
+	return results
+}
+
+func (x *hnswIndex) size() int {
+	return len(x.slots)
+}
+
+// remove drops the nodes and reconnects every node that linked to one of
+// them through the removed node's own neighbours
+func (x *hnswIndex) remove(deleted map[string]struct{}) {
+	removed := map[int32]*hnswNode{}
+	for id := range deleted {
+		if slot, ok := x.slots[id]; ok {
+			removed[slot] = x.nodes[slot]
+			x.nodes[slot] = nil
+			delete(x.slots, id)
+		}
+	}
+
+	if len(removed) == 0 {
+		return
+	}
+
+	for _, node := range x.nodes {
+		if node == nil {
+			continue
+		}
+		for l, links := range node.neighbors {
+			kept := make([]int32, 0, len(links))
+			candidates := map[int32]struct{}{}
+			lost := false
+			for _, link := range links {
+				gone, ok := removed[link]
+				if !ok {
+					kept = append(kept, link)
+					candidates[link] = struct{}{}
+					continue
+				}
+				lost = true
+				if l < len(gone.neighbors) {
+					for _, next := range gone.neighbors[l] {
+						if _, dead := removed[next]; !dead {
+							candidates[next] = struct{}{}
+						}
+					}
+				}
+			}
+
+			if !lost {
+				continue
+			}
+
+			node.neighbors[l] = kept
+
+			scored := make([]hnswCandidate, 0, len(candidates))
+			for candidate := range candidates {
+				if other := x.nodes[candidate]; other != nil && other != node {
+					scored = append(scored, hnswCandidate{slot: candidate, score: dot(node.vector, other.vector)})
+				}
+			}
+			sortCandidates(scored)
+
+			node.neighbors[l] = slotsOf(x.selectNeighbors(scored, x.maxConnections(l)))
+		}
+	}
+
+	if _, ok := removed[x.entry]; ok {
+		x.entry = -1
+		x.maxLevel = 0
+		for slot, node := range x.nodes {
+			if node != nil && (x.entry < 0 || node.level > x.maxLevel) {
+				x.entry = int32(slot)
+				x.maxLevel = node.level
+			}
+		}
+	}
+
+	// compact once most slots are empty
+	if len(x.slots) < len(x.nodes)/2 {
+		x.compact()
+	}
+}
+
+func (x *hnswIndex) compact() {
+	remap := make([]int32, len(x.nodes))
+	nodes := make([]*hnswNode, 0, len(x.slots))
+
+	for slot, node := range x.nodes {
+		remap[slot] = -1
+		if node != nil {
+			remap[slot] = int32(len(nodes))
+			nodes = append(nodes, node)
+		}
+	}
+
+	for _, node := range nodes {
+		x.slots[node.id] = remap[x.slots[node.id]]
+		for l, links := range node.neighbors {
+			kept := links[:0]
+			for _, link := range links {
+				if remap[link] >= 0 {
+					kept = append(kept, remap[link])
+				}
+			}
+			node.neighbors[l] = kept
+		}
+	}
+
+	if x.entry >= 0 {
+		x.entry = remap[x.entry]
+	}
+
+	x.nodes = nodes
+}
+
+// descend greedily walks the upper layers down to level
+func (x *hnswIndex) descend(query []float32, level int) []int32 {
+	entries := []int32{x.entry}
+	for l := x.maxLevel; l > level; l-- {
+		if best := x.searchLayer(query, entries, 1, l); len(best) > 0 {
+			entries = []int32{best[0].slot}
+		}
+	}
+	return entries
+}
+
+func (x *hnswIndex) searchLayer(query []float32, entries []int32, ef int, level int) []hnswCandidate {
+	visited := make([]bool, len(x.nodes))
+	candidates := &candidateHeap{max: true}
+	results := &candidateHeap{}
+
+	for _, slot := range entries {
+		node := x.nodes[slot]
+		if node == nil {
+			continue
+		}
+		visited[slot] = true
+		c := hnswCandidate{slot: slot, score: dot(query, node.vector)}
+		heap.Push(candidates, c)
+		heap.Push(results, c)
+	}
+
+	for candidates.Len() > 0 {
+		current := heap.Pop(candidates).(hnswCandidate)
+		if results.Len() >= ef && current.score < results.items[0].score {
+			break
+		}
+
+		node := x.nodes[current.slot]
+		if level >= len(node.neighbors) {
+			continue
+		}
+
+		for _, slot := range node.neighbors[level] {
+			if visited[slot] {
+				continue
+			}
+			visited[slot] = true
+
+			neighbor := x.nodes[slot]
+			if neighbor == nil {
+				continue
+			}
+
+			c := hnswCandidate{slot: slot, score: dot(query, neighbor.vector)}
+			if results.Len() < ef || c.score > results.items[0].score {
+				heap.Push(candidates, c)
+				heap.Push(results, c)
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	out := make([]hnswCandidate, len(results.items))
+	copy(out, results.items)
+	sortCandidates(out)
+
+	return out
+}
+
+// selectNeighbors prefers candidates that are closer to the query than to
+// anything already selected so links spread in different directions, then
+// tops up with the closest of the rest
+func (x *hnswIndex) selectNeighbors(candidates []hnswCandidate, m int) []hnswCandidate {
+	if len(candidates) <= m {
+		return candidates
+	}
+
+	selected := make([]hnswCandidate, 0, m)
+	skipped := []hnswCandidate{}
+
+	for _, c := range candidates {
+		if len(selected) >= m {
+			break
+		}
+		vector := x.nodes[c.slot].vector
+		diverse := true
+		for _, s := range selected {
+			if dot(vector, x.nodes[s.slot].vector) > c.score {
+				diverse = false
+				break
+			}
+		}
+		if diverse {
+			selected = append(selected, c)
+		} else {
+			skipped = append(skipped, c)
+		}
+	}
+
+	for _, c := range skipped {
+		if len(selected) >= m {
+			break
+		}
+		selected = append(selected, c)
+	}
+
+	return selected
+}
+
+func (x *hnswIndex) prune(node *hnswNode, level int) {
+	scored := make([]hnswCandidate, 0, len(node.neighbors[level]))
+	for _, slot := range node.neighbors[level] {
+		if other := x.nodes[slot]; other != nil {
+			scored = append(scored, hnswCandidate{slot: slot, score: dot(node.vector, other.vector)})
+		}
+	}
+	sortCandidates(scored)
+
+	node.neighbors[level] = slotsOf(x.selectNeighbors(scored, x.maxConnections(level)))
+}
+
+func (x *hnswIndex) maxConnections(level int) int {
+	if level == 0 {
+		return 2 * x.config.M
+	}
+	return x.config.M
+}
+
+func newHNSWIndex(cfg HNSWConfig) *hnswIndex {
+	return &hnswIndex{
+		config:    cfg,
+		slots:     map[string]int32{},
+		entry:     -1,
+		levelMult: 1 / math.Log(float64(cfg.M)),
+		rng:       rand.New(rand.NewPCG(uint64(cfg.M), uint64(cfg.EfConstruction))),
+	}
+}
+
+type candidateHeap struct {
+	items []hnswCandidate
+	max   bool
+}
+
+func (h *candidateHeap) Len() int { return len(h.items) }
+
+func (h *candidateHeap) Less(i, j int) bool {
+	if h.max {
+		return h.items[i].score > h.items[j].score
+	}
+	return h.items[i].score < h.items[j].score
+}
+
+func (h *candidateHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *candidateHeap) Push(v any) { h.items = append(h.items, v.(hnswCandidate)) }
+
+func (h *candidateHeap) Pop() any {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
+
+func sortCandidates(candidates []hnswCandidate) {
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+}
+
+func slotsOf(candidates []hnswCandidate) []int32 {
+	out := make([]int32, 0, len(candidates))
+	for _, c := range candidates {
+		out = append(out, c.slot)
+	}
+	return out
+}
+
+func normalize(vector []float32) []float32 {
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+
+	out := make([]float32, len(vector))
+	if norm == 0 {
+		return out
+	}
+
+	norm = math.Sqrt(norm)
+	for i, v := range vector {
+		out[i] = float32(float64(v) / norm)
+	}
+
+	return out
+}
+
+// dot is the hot loop of every build and search so it is unrolled with
+// float32 accumulators
+func dot(a []float32, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+
+	var s0, s1, s2, s3 float32
+	i := 0
+	for ; i+4 <= len(a); i += 4 {
+		x, y := a[i:i+4:i+4], b[i:i+4:i+4]
+		s0 += x[0] * y[0]
+		s1 += x[1] * y[1]
+		s2 += x[2] * y[2]
+		s3 += x[3] * y[3]
+	}
+	for ; i < len(a); i++ {
+		s0 += a[i] * b[i]
+	}
+
+	return float64(s0 + s1 + s2 + s3)
+}
@@ -0,0 +1,134 @@
+package memory_test
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+	"github.com/w-h-a/agent/memory_manager/providers/storer/memory"
+)
+
+const (
+	benchRecords  = 10000
+	benchQueries  = 100
+	benchDim      = 128
+	benchClusters = 50
+	benchK        = 10
+)
+
+func TestHNSWRecall(t *testing.T) {
+	ctx := context.Background()
+	records, queries := dataset(5000, 100, benchDim)
+
+	linear := load(t, memory.NewStorer(), records)
+
+	for _, ef := range []int{16, 64} {
+		t.Run(fmt.Sprintf("ef=%d", ef), func(t *testing.T) {
+			approx := load(t, memory.NewStorer(memory.WithHNSW(16, 200, ef)), records)
+
+			hits := 0
+			for _, q := range queries {
+				want, err := linear.Search(ctx, "bench", q, benchK, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := approx.Search(ctx, "bench", q, benchK, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				truth := contents(want)
+				for _, content := range contents(got) {
+					if slices.Contains(truth, content) {
+						hits++
+					}
+				}
+			}
+
+			recall := float64(hits) / float64(len(queries)*benchK)
+			t.Logf("recall@%d = %.3f", benchK, recall)
+			if recall < 0.95 {
+				t.Errorf("recall@%d = %.3f, want at least 0.95", benchK, recall)
+			}
+		})
+	}
+}
+
+func BenchmarkSearchLinear(b *testing.B) {
+	benchmarkSearch(b, memory.NewStorer())
+}
+
+func BenchmarkSearchHNSW(b *testing.B) {
+	for _, ef := range []int{16, 64, 128} {
+		b.Run(fmt.Sprintf("ef=%d", ef), func(b *testing.B) {
+			benchmarkSearch(b, memory.NewStorer(memory.WithHNSW(16, 200, ef)))
+		})
+	}
+}
+
+func benchmarkSearch(b *testing.B, s storer.Storer) {
+	ctx := context.Background()
+	records, queries := dataset(benchRecords, benchQueries, benchDim)
+
+	load(b, s, records)
+
+	i := 0
+	for b.Loop() {
+		if _, err := s.Search(ctx, "bench", queries[i%len(queries)], benchK, nil); err != nil {
+			b.Fatal(err)
+		}
+		i++
+	}
+}
+
+func load(tb testing.TB, s storer.Storer, records [][]float32) storer.Storer {
+	tb.Helper()
+	for i, vector := range records {
+		if _, err := s.Store(context.Background(), "bench", "", fmt.Sprint(i), nil, vector); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	return s
+}
+
+// dataset draws records and queries around shared centres so neighbours are meaningful
+func dataset(records int, queries int, dim int) ([][]float32, [][]float32) {
+	rng := rand.New(rand.NewPCG(7, 7))
+
+	centres := make([][]float32, benchClusters)
+	for i := range centres {
+		centres[i] = gaussian(rng, nil, dim, 1)
+	}
+
+	sample := func(n int) [][]float32 {
+		out := make([][]float32, n)
+		for i := range out {
+			out[i] = gaussian(rng, centres[rng.IntN(len(centres))], dim, 0.3)
+		}
+		return out
+	}
+
+	return sample(records), sample(queries)
+}
+
+func gaussian(rng *rand.Rand, centre []float32, dim int, spread float64) []float32 {
+	v := make([]float32, dim)
+	for i := range v {
+		x := rng.NormFloat64() * spread
+		if centre != nil {
+			x += float64(centre[i])
+		}
+		v[i] = float32(x)
+	}
+	return v
+}
+
+func contents(records []storer.Record) []string {
+	out := make([]string, 0, len(records))
+	for _, rec := range records {
+		out = append(out, rec.Content)
+	}
+	return out
+}
@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+)
+
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+type bm25Index struct {
+	postings map[string]map[string]int
+	docs     map[string]map[string]int
+	lengths  map[string]int
+	total    int
+}
+
+func (x *bm25Index) add(id string, text string) {
+	terms := map[string]int{}
+	tokens := storer.Tokenize(text)
+	for _, token := range tokens {
+		terms[token]++
+	}
+
+	for term, tf := range terms {
+		if x.postings[term] == nil {
+			x.postings[term] = map[string]int{}
+		}
+		x.postings[term][id] = tf
+	}
+
+	x.docs[id] = terms
+	x.lengths[id] = len(tokens)
+	x.total += len(tokens)
+}
+
+func (x *bm25Index) remove(id string) {
+	terms, ok := x.docs[id]
+	if !ok {
+		return
+	}
+
+	for term := range terms {
+		delete(x.postings[term], id)
+		if len(x.postings[term]) == 0 {
+			delete(x.postings, term)
+		}
+	}
+
+	x.total -= x.lengths[id]
+	delete(x.docs, id)
+	delete(x.lengths, id)
+}
+
+func (x *bm25Index) score(query string) map[string]float64 {
+	scores := map[string]float64{}
+
+	n := float64(len(x.docs))
+	if n == 0 {
+		return scores
+	}
+
+	avg := float64(x.total) / n
+
+	seen := map[string]struct{}{}
+	for _, term := range storer.Tokenize(query) {
+		if _, ok := seen[term]; ok {
+			continue
+		}
+		seen[term] = struct{}{}
+
+		postings := x.postings[term]
+		if len(postings) == 0 {
+			continue
+		}
+
+		df := float64(len(postings))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+
+		for id, tf := range postings {
+			f := float64(tf)
+			norm := 1 - bm25B + bm25B*float64(x.lengths[id])/avg
+			scores[id] += idf * (f * (bm25K1 + 1)) / (f + bm25K1*norm)
+		}
+	}
+
+	return scores
+}
+
+func (s *memoryStorer) SearchLexical(ctx context.Context, spaceId string, query string, limit int, filter *storer.Filter) ([]storer.Record, error) {
+	if limit < 1 || s.lexical == nil {
+		return nil, nil
+	}
+
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	filter = storer.Unexpired(s.options.TTL, filter)
+
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	var candidates []storer.Record
+
+	for id, score := range s.lexical.score(query) {
+		rec, ok := s.records[id]
+		if !ok || rec.SpaceId != spaceId || !filter.Matches(rec) {
+			continue
+		}
+		rec.Score = float32(score)
+		candidates = append(candidates, rec)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	return candidates, nil
+}
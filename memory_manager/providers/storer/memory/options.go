@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+)
+
+type hnswKey struct{}
+
+type snapshotKey struct{}
+
+type HNSWConfig struct {
+	M              int
+	EfConstruction int
+	EfSearch       int
+}
+
+// WithHNSW replaces the linear scan with an approximate nearest neighbour
+// graph per space. m is the number of links per node and ef the candidate
+// list size used while building and searching.
+func WithHNSW(m int, efConstruction int, efSearch int) storer.Option {
+	return func(o *storer.Options) {
+		o.Context = context.WithValue(o.Context, hnswKey{}, HNSWConfig{M: m, EfConstruction: efConstruction, EfSearch: efSearch})
+	}
+}
+
+func HNSWFrom(ctx context.Context) (HNSWConfig, bool) {
+	cfg, ok := ctx.Value(hnswKey{}).(HNSWConfig)
+	if !ok {
+		return HNSWConfig{}, false
+	}
+
+	cfg.M = max(cfg.M, 2)
+	cfg.EfConstruction = max(cfg.EfConstruction, cfg.M)
+	cfg.EfSearch = max(cfg.EfSearch, 1)
+
+	return cfg, true
+}
+
+// WithSnapshot loads the store from path on start when the file exists and
+// is where Snapshot writes to
+func WithSnapshot(path string) storer.Option {
+	return func(o *storer.Options) {
+		o.Context = context.WithValue(o.Context, snapshotKey{}, path)
+	}
+}
+
+func SnapshotFrom(ctx context.Context) (string, bool) {
+	path, ok := ctx.Value(snapshotKey{}).(string)
+	return path, ok && len(path) > 0
+}
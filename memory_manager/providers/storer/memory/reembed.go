@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+)
+
+type stagedEmbedding struct {
+	Model  string    `json:"model"`
+	Vector []float32 `json:"vector"`
+}
+
+func (s *memoryStorer) PendingEmbeddings(ctx context.Context, spaceId string, model string, limit int) ([]storer.Record, error) {
+	if limit < 1 {
+		return nil, nil
+	}
+
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	pending := []storer.Record{}
+
+	for id, rec := range s.records {
+		if rec.SpaceId != spaceId || rec.EmbeddingModel == model {
+			continue
+		}
+		if staged, ok := s.staged[id]; ok && staged.Model == model {
+			continue
+		}
+		pending = append(pending, rec)
+	}
+
+	// oldest first so a resumed job walks the space in the same order
+	sort.Slice(pending, func(i, j int) bool {
+		if pending[i].CreatedAt.Equal(pending[j].CreatedAt) {
+			return pending[i].Id < pending[j].Id
+		}
+		return pending[i].CreatedAt.Before(pending[j].CreatedAt)
+	})
+
+	if len(pending) > limit {
+		pending = pending[:limit]
+	}
+
+	return pending, nil
+}
+
+func (s *memoryStorer) StageEmbeddings(ctx context.Context, model string, vectors map[string][]float32) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for id, vector := range vectors {
+		if _, ok := s.records[id]; !ok {
+			continue
+		}
+		s.staged[id] = stagedEmbedding{
+			Model:  model,
+			Vector: append([]float32(nil), vector...),
+		}
+	}
+
+	return nil
+}
+
+func (s *memoryStorer) PromoteEmbeddings(ctx context.Context, spaceId string, model string) (int, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	promoted := map[string]struct{}{}
+
+	for id, staged := range s.staged {
+		rec, ok := s.records[id]
+		if !ok || rec.SpaceId != spaceId || staged.Model != model {
+			continue
+		}
+		rec.Embedding = staged.Vector
+		rec.EmbeddingModel = staged.Model
+		s.records[id] = rec
+		delete(s.staged, id)
+		promoted[id] = struct{}{}
+	}
+
+	if len(promoted) == 0 {
+		return 0, nil
+	}
+
+	// the old vectors are baked into the graph so the space is indexed afresh
+	if _, ok := s.indexes[spaceId]; ok {
+		delete(s.indexes, spaceId)
+		for _, rec := range s.records {
+			if rec.SpaceId == spaceId {
+				s.index(rec)
+			}
+		}
+	}
+
+	return len(promoted), nil
+}
+
+func (s *memoryStorer) OtherEmbeddingModel(ctx context.Context, spaceId string, model string) (string, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	for _, rec := range s.records {
+		if rec.SpaceId == spaceId && rec.EmbeddingModel != model && len(rec.EmbeddingModel) > 0 {
+			return rec.EmbeddingModel, nil
+		}
+	}
+
+	return "", nil
+}
@@ -0,0 +1,203 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+)
+
+type snapshotFile struct {
+	Records   []storer.Record          `json:"records"`
+	Entities  []storer.Entity          `json:"entities"`
+	Relations []storer.Relation        `json:"relations"`
+	Indexes   map[string]snapshotIndex `json:"indexes,omitempty"`
+	// embeddings waiting on a re-embed to be promoted
+	Staged map[string]stagedEmbedding `json:"staged,omitempty"`
+}
+
+type snapshotIndex struct {
+	Entry    string                  `json:"entry"`
+	MaxLevel int                     `json:"max_level"`
+	Nodes    map[string]snapshotNode `json:"nodes"`
+}
+
+type snapshotNode struct {
+	Level     int        `json:"level"`
+	Neighbors [][]string `json:"neighbors"`
+}
+
+// Snapshot writes every record, entity and index graph to the path given
+// by WithSnapshot so a later NewStorer can pick up where this one left off
+func (s *memoryStorer) Snapshot(ctx context.Context) error {
+	if len(s.snapshot) == 0 {
+		return fmt.Errorf("memory storer has no snapshot path")
+	}
+
+	s.mtx.RLock()
+
+	file := snapshotFile{
+		Records:   make([]storer.Record, 0, len(s.records)),
+		Entities:  make([]storer.Entity, 0, len(s.entities)),
+		Relations: make([]storer.Relation, 0, len(s.relations)),
+		Indexes:   map[string]snapshotIndex{},
+		Staged:    s.staged,
+	}
+
+	for _, rec := range s.records {
+		file.Records = append(file.Records, rec)
+	}
+
+	for _, entity := range s.entities {
+		file.Entities = append(file.Entities, entity)
+	}
+
+	for _, relation := range s.relations {
+		file.Relations = append(file.Relations, relation)
+	}
+
+	for spaceId, idx := range s.indexes {
+		if idx.entry < 0 {
+			continue
+		}
+		// links are saved by record id since slots are only meaningful in process
+		nodes := make(map[string]snapshotNode, idx.size())
+		for _, node := range idx.nodes {
+			if node == nil {
+				continue
+			}
+			neighbors := make([][]string, len(node.neighbors))
+			for l, links := range node.neighbors {
+				neighbors[l] = make([]string, 0, len(links))
+				for _, slot := range links {
+					if other := idx.nodes[slot]; other != nil {
+						neighbors[l] = append(neighbors[l], other.id)
+					}
+				}
+			}
+			nodes[node.id] = snapshotNode{Level: node.level, Neighbors: neighbors}
+		}
+		file.Indexes[spaceId] = snapshotIndex{Entry: idx.nodes[idx.entry].id, MaxLevel: idx.maxLevel, Nodes: nodes}
+	}
+
+	bs, err := json.Marshal(file)
+
+	s.mtx.RUnlock()
+
+	if err != nil {
+		return err
+	}
+
+	// write then rename so a crash never leaves half a snapshot behind
+	tmp, err := os.CreateTemp(filepath.Dir(s.snapshot), filepath.Base(s.snapshot)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(bs); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.snapshot)
+}
+
+func (s *memoryStorer) load() error {
+	bs, err := os.ReadFile(s.snapshot)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var file snapshotFile
+	if err := json.Unmarshal(bs, &file); err != nil {
+		return err
+	}
+
+	for _, rec := range file.Records {
+		s.records[rec.Id] = rec
+		if s.lexical != nil {
+			s.lexical.add(rec.Id, rec.Content)
+		}
+	}
+
+	for id, staged := range file.Staged {
+		if _, ok := s.records[id]; ok {
+			s.staged[id] = staged
+		}
+	}
+
+	for _, entity := range file.Entities {
+		s.entities[entity.Id] = entity
+	}
+
+	for _, relation := range file.Relations {
+		s.relations[relation.SourceId+"|"+relation.TargetId+"|"+relation.Type] = relation
+	}
+
+	if s.hnsw == nil {
+		return nil
+	}
+
+	// saved graphs are reused as is and anything without one is indexed afresh
+	for spaceId, saved := range file.Indexes {
+		idx := newHNSWIndex(*s.hnsw)
+		for id, node := range saved.Nodes {
+			rec, ok := s.records[id]
+			if !ok {
+				continue
+			}
+			idx.add(&hnswNode{
+				id:        id,
+				vector:    normalize(rec.Embedding),
+				level:     node.Level,
+				neighbors: make([][]int32, node.Level+1),
+			})
+		}
+		for id, node := range saved.Nodes {
+			slot, ok := idx.slots[id]
+			if !ok {
+				continue
+			}
+			for l, links := range node.Neighbors {
+				if l > node.Level {
+					break
+				}
+				for _, link := range links {
+					if other, ok := idx.slots[link]; ok {
+						idx.nodes[slot].neighbors[l] = append(idx.nodes[slot].neighbors[l], other)
+					}
+				}
+			}
+		}
+		entry, ok := idx.slots[saved.Entry]
+		if !ok {
+			continue
+		}
+		idx.entry = entry
+		idx.maxLevel = saved.MaxLevel
+		s.indexes[spaceId] = idx
+	}
+
+	for _, rec := range file.Records {
+		if idx, ok := s.indexes[rec.SpaceId]; ok {
+			if _, indexed := idx.slots[rec.Id]; indexed {
+				continue
+			}
+		}
+		s.index(rec)
+	}
+
+	return nil
+}
@@ -2,7 +2,9 @@ package memory
 
 import (
 	"context"
+	"log/slog"
 	"maps"
+	"slices"
 	"sort"
 	"sync"
 	"time"
@@ -13,16 +15,28 @@ import (
 )
 
 type memoryStorer struct {
-	options storer.Options
-	records map[string]storer.Record
-	mtx     sync.RWMutex
+	options   storer.Options
+	records   map[string]storer.Record
+	entities  map[string]storer.Entity
+	relations map[string]storer.Relation
+	lexical   *bm25Index
+	hnsw      *HNSWConfig
+	indexes   map[string]*hnswIndex
+	staged    map[string]stagedEmbedding
+	snapshot  string
+	mtx       sync.RWMutex
 }
 
-func (s *memoryStorer) Store(ctx context.Context, spaceId string, sessionId string, content string, metadata map[string]any, vector []float32) error {
+func (s *memoryStorer) Store(ctx context.Context, spaceId string, sessionId string, content string, metadata map[string]any, vector []float32) (string, error) {
+	if err := storer.CheckDimension(s.options.VectorSize, vector); err != nil {
+		return "", err
+	}
+
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
 	storer.SanitizeEdges(metadata)
+	model := storer.TakeEmbeddingModel(metadata)
 
 	id := uuid.New().String()
 
@@ -32,32 +46,56 @@ func (s *memoryStorer) Store(ctx context.Context, spaceId string, sessionId stri
 	copy(cpy, vector)
 
 	rec := storer.Record{
-		Id:        id,
-		SessionId: sessionId,
-		Content:   content,
-		Metadata:  metadata,
-		Embedding: cpy,
-		SpaceId:   spaceId,
-		CreatedAt: now,
+		Id:             id,
+		SessionId:      sessionId,
+		Content:        content,
+		Metadata:       metadata,
+		Embedding:      cpy,
+		SpaceId:        spaceId,
+		CreatedAt:      now,
+		EmbeddingModel: model,
 	}
 
 	s.records[id] = rec
 
-	return nil
+	if s.lexical != nil {
+		s.lexical.add(id, content)
+	}
+
+	s.index(rec)
+
+	return id, nil
 }
 
-func (s *memoryStorer) Search(ctx context.Context, spaceId string, vector []float32, limit int) ([]storer.Record, error) {
+func (s *memoryStorer) Search(ctx context.Context, spaceId string, vector []float32, limit int, filter *storer.Filter) ([]storer.Record, error) {
 	if limit < 1 {
 		return nil, nil
 	}
 
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	filter = storer.Unexpired(s.options.TTL, filter)
+
+	if err := storer.CheckDimension(s.options.VectorSize, vector); err != nil {
+		return nil, err
+	}
+
 	s.mtx.RLock()
 	defer s.mtx.RUnlock()
 
+	if idx, ok := s.indexes[spaceId]; ok {
+		if records, ok := s.searchIndex(idx, vector, limit, filter); ok {
+			return records, nil
+		}
+	}
+
 	candidates := make([]storer.Record, 0, len(s.records))
 
 	for _, rec := range s.records {
-		if rec.SpaceId != spaceId {
+		// vectors of another size come from another model and are never compared
+		if rec.SpaceId != spaceId || len(rec.Embedding) != len(vector) || !filter.Matches(rec) {
 			continue
 		}
 		score := memorymanager.CosineSimilarity(vector, rec.Embedding)
@@ -76,18 +114,83 @@ func (s *memoryStorer) Search(ctx context.Context, spaceId string, vector []floa
 	return candidates, nil
 }
 
-func (s *memoryStorer) SearchNeighborhood(ctx context.Context, seedIds []string, hops int, limit int) ([]storer.Record, error) {
+// searchIndex widens the candidate list until enough records survive the
+// filter, falling back to a scan once it covers the whole space
+func (s *memoryStorer) searchIndex(idx *hnswIndex, vector []float32, limit int, filter *storer.Filter) ([]storer.Record, bool) {
+	ef := max(idx.config.EfSearch, limit)
+
+	for ef < 4*idx.size() {
+		k := limit
+		if filter != nil {
+			k = ef
+		}
+
+		results := idx.search(vector, k, ef)
+
+		records := make([]storer.Record, 0, limit)
+		for _, c := range results {
+			rec, ok := s.records[idx.nodes[c.slot].id]
+			if !ok || len(rec.Embedding) != len(vector) || !filter.Matches(rec) {
+				continue
+			}
+			rec.Score = float32(memorymanager.CosineSimilarity(vector, rec.Embedding))
+			records = append(records, rec)
+			if len(records) >= limit {
+				break
+			}
+		}
+
+		if len(records) >= limit {
+			sort.SliceStable(records, func(i, j int) bool {
+				return records[i].Score > records[j].Score
+			})
+			return records, true
+		}
+
+		ef *= 4
+	}
+
+	return nil, false
+}
+
+func (s *memoryStorer) index(rec storer.Record) {
+	if s.hnsw == nil {
+		return
+	}
+
+	idx, ok := s.indexes[rec.SpaceId]
+	if !ok {
+		idx = newHNSWIndex(*s.hnsw)
+		s.indexes[rec.SpaceId] = idx
+	}
+
+	idx.insert(rec.Id, rec.Embedding)
+}
+
+func (s *memoryStorer) SearchNeighborhood(ctx context.Context, seedIds []string, hops int, limit int, filter *storer.Filter) ([]storer.Record, error) {
 	if limit < 1 || len(seedIds) == 0 {
 		return nil, nil
 	}
 
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	filter = storer.Unexpired(s.options.TTL, filter)
+
 	s.mtx.RLock()
 	defer s.mtx.RUnlock()
 
 	visited := map[string]struct{}{}
+	seeds := map[string]struct{}{}
+	for _, id := range seedIds {
+		seeds[id] = struct{}{}
+	}
+
 	var records []storer.Record
 
-	for range hops {
+	// the first pass only resolves the seeds' edges
+	for range hops + 1 {
 		if len(seedIds) == 0 {
 			break
 		}
@@ -113,9 +216,12 @@ func (s *memoryStorer) SearchNeighborhood(ctx context.Context, seedIds []string,
 
 		next := []string{}
 		for _, rec := range batch {
-			records = append(records, rec)
-			if len(records) >= limit {
-				return records, nil
+			// filtered records are still walked through, just not returned
+			if _, isSeed := seeds[rec.Id]; !isSeed && filter.Matches(rec) {
+				records = append(records, rec)
+				if len(records) >= limit {
+					return records, nil
+				}
 			}
 			if rec.Metadata != nil {
 				metadataCopy := make(map[string]any, len(rec.Metadata))
@@ -135,13 +241,137 @@ func (s *memoryStorer) SearchNeighborhood(ctx context.Context, seedIds []string,
 	return records, nil
 }
 
+func (s *memoryStorer) Delete(ctx context.Context, ids []string) (int, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.deleteWhere(func(rec storer.Record) bool {
+		return slices.Contains(ids, rec.Id)
+	}), nil
+}
+
+func (s *memoryStorer) DeleteBySession(ctx context.Context, sessionId string) (int, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.deleteWhere(func(rec storer.Record) bool {
+		return rec.SessionId == sessionId
+	}), nil
+}
+
+func (s *memoryStorer) DeleteBySpace(ctx context.Context, spaceId string) (int, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for id, entity := range s.entities {
+		if entity.SpaceId == spaceId {
+			delete(s.entities, id)
+		}
+	}
+
+	for key, rel := range s.relations {
+		_, source := s.entities[rel.SourceId]
+		_, target := s.entities[rel.TargetId]
+		if !source || !target {
+			delete(s.relations, key)
+		}
+	}
+
+	return s.deleteWhere(func(rec storer.Record) bool {
+		return rec.SpaceId == spaceId
+	}), nil
+}
+
+func (s *memoryStorer) DeleteExpired(ctx context.Context) (int, error) {
+	cutoff, ok := storer.ExpiredBefore(s.options.TTL)
+	if !ok {
+		return 0, nil
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.deleteWhere(func(rec storer.Record) bool {
+		return rec.CreatedAt.Before(cutoff)
+	}), nil
+}
+
+func (s *memoryStorer) deleteWhere(match func(storer.Record) bool) int {
+	deleted := map[string]struct{}{}
+	spaces := map[string]struct{}{}
+
+	for id, rec := range s.records {
+		if match(rec) {
+			delete(s.records, id)
+			if s.lexical != nil {
+				s.lexical.remove(id)
+			}
+			delete(s.staged, id)
+			deleted[id] = struct{}{}
+			spaces[rec.SpaceId] = struct{}{}
+		}
+	}
+
+	if len(deleted) == 0 {
+		return 0
+	}
+
+	for spaceId := range spaces {
+		if idx, ok := s.indexes[spaceId]; ok {
+			idx.remove(deleted)
+			if idx.size() == 0 {
+				delete(s.indexes, spaceId)
+			}
+		}
+	}
+
+	// edges live on their source record so drop any pointing at what is gone
+	for id, rec := range s.records {
+		if rec.Metadata == nil {
+			continue
+		}
+		metadata := maps.Clone(rec.Metadata)
+		if storer.RemoveEdgesTo(metadata, deleted) {
+			rec.Metadata = metadata
+			s.records[id] = rec
+		}
+	}
+
+	return len(deleted)
+}
+
 func NewStorer(opts ...storer.Option) *memoryStorer {
 	options := storer.NewOptions(opts...)
 
 	s := &memoryStorer{
-		options: options,
-		records: map[string]storer.Record{},
-		mtx:     sync.RWMutex{},
+		options:   options,
+		records:   map[string]storer.Record{},
+		entities:  map[string]storer.Entity{},
+		relations: map[string]storer.Relation{},
+		indexes:   map[string]*hnswIndex{},
+		staged:    map[string]stagedEmbedding{},
+		mtx:       sync.RWMutex{},
+	}
+
+	if cfg, ok := HNSWFrom(options.Context); ok {
+		s.hnsw = &cfg
+	}
+
+	if options.Lexical {
+		s.lexical = &bm25Index{
+			postings: map[string]map[string]int{},
+			docs:     map[string]map[string]int{},
+			lengths:  map[string]int{},
+		}
+	}
+
+	if path, ok := SnapshotFrom(options.Context); ok {
+		s.snapshot = path
+		if err := s.load(); err != nil {
+			detail := "failed to load memory storer snapshot"
+			slog.ErrorContext(context.Background(), detail, "path", path, "error", err)
+			panic(detail)
+		}
 	}
 
 	return s
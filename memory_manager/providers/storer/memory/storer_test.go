@@ -0,0 +1,26 @@
+package memory_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+	"github.com/w-h-a/agent/memory_manager/providers/storer/memory"
+	"github.com/w-h-a/agent/memory_manager/providers/storer/storertest"
+)
+
+func TestConformance(t *testing.T) {
+	size := storer.WithVectorSize(storertest.VectorSize)
+
+	t.Run("linear", func(t *testing.T) {
+		storertest.Run(t, memory.NewStorer(size))
+	})
+
+	t.Run("hnsw", func(t *testing.T) {
+		storertest.Run(t, memory.NewStorer(size, memory.WithHNSW(16, 200, 64)))
+	})
+
+	t.Run("ttl", func(t *testing.T) {
+		storertest.RunTTL(t, memory.NewStorer(size, storer.WithTTL(time.Hour), storer.WithLexicalIndex(true)), time.Hour)
+	})
+}
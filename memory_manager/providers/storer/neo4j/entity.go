@@ -0,0 +1,269 @@
+package neo4j
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+	getsafe "github.com/w-h-a/agent/util/get_safe"
+)
+
+func (s *neo4jStorer) UpsertEntity(ctx context.Context, spaceId string, entity storer.Entity) (string, error) {
+	session := s.driver.NewSession(ctx, neo4j.SessionConfig{
+		DatabaseName: s.options.Collection,
+	})
+	defer session.Close(ctx)
+
+	jsonAttrs, _ := json.Marshal(entity.Attributes)
+
+	id := entity.Id
+	isNew := len(id) == 0
+	if isNew {
+		id = uuid.New().String()
+	}
+
+	aliases := entity.Aliases
+	if aliases == nil {
+		aliases = []string{}
+	}
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MERGE (e:Entity {id: $id})
+			ON CREATE SET e.created_at = datetime(), e.space_id = $spaceId
+			WITH e
+			WHERE e.space_id = $spaceId
+			SET e.name = $name,
+				e.kind = $kind,
+				e.aliases = $aliases,
+				e.attributes = $attributes,
+				e.embedding = $embedding,
+				e.updated_at = datetime()
+			RETURN e.id
+		`
+
+		params := map[string]any{
+			"id":         id,
+			"spaceId":    spaceId,
+			"name":       entity.Name,
+			"kind":       entity.Kind,
+			"aliases":    aliases,
+			"attributes": string(jsonAttrs),
+			"embedding":  entity.Embedding,
+		}
+
+		result, err := tx.Run(ctx, query, params)
+		if err != nil {
+			return nil, err
+		}
+
+		if !result.Next(ctx) {
+			return nil, fmt.Errorf("entity %s not found", id)
+		}
+
+		return nil, result.Err()
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+func (s *neo4jStorer) SearchEntities(ctx context.Context, spaceId string, vector []float32, limit int) ([]storer.Entity, error) {
+	if limit < 1 {
+		return nil, nil
+	}
+
+	session := s.driver.NewSession(ctx, neo4j.SessionConfig{
+		DatabaseName: s.options.Collection,
+	})
+	defer session.Close(ctx)
+
+	query := `
+		CALL db.index.vector.queryNodes($index, $k, $vec)
+		YIELD node, score
+		WHERE node.space_id = $spaceId
+		RETURN node, score
+		LIMIT $finalLimit
+	`
+
+	params := map[string]any{
+		"index":      s.entityIndex(),
+		"k":          limit * 2,
+		"vec":        vector,
+		"spaceId":    spaceId,
+		"finalLimit": limit,
+	}
+
+	result, err := session.Run(ctx, query, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var entities []storer.Entity
+	for result.Next(ctx) {
+		entities = append(entities, s.mapToStorerEntity(result.Record()))
+	}
+
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+
+	return entities, nil
+}
+
+func (s *neo4jStorer) Relate(ctx context.Context, spaceId string, relation storer.Relation) error {
+	session := s.driver.NewSession(ctx, neo4j.SessionConfig{
+		DatabaseName: s.options.Collection,
+	})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MATCH (source:Entity {id: $sourceId, space_id: $spaceId})
+			MATCH (target:Entity {id: $targetId, space_id: $spaceId})
+			MERGE (source)-[:RELATES_TO {type: $type}]->(target)
+		`
+
+		params := map[string]any{
+			"sourceId": relation.SourceId,
+			"targetId": relation.TargetId,
+			"spaceId":  spaceId,
+			"type":     storer.SanitizeType(relation.Type),
+		}
+
+		_, err := tx.Run(ctx, query, params)
+		return nil, err
+	})
+
+	return err
+}
+
+func (s *neo4jStorer) SearchEntityGraph(ctx context.Context, spaceId string, seedIds []string, hops int, limit int) ([]storer.Entity, []storer.Relation, error) {
+	if limit < 1 || len(seedIds) == 0 {
+		return nil, nil, nil
+	}
+
+	session := s.driver.NewSession(ctx, neo4j.SessionConfig{
+		DatabaseName: s.options.Collection,
+	})
+	defer session.Close(ctx)
+
+	query := fmt.Sprintf(`
+		MATCH (seed:Entity)
+		WHERE seed.id IN $seedIds AND seed.space_id = $spaceId
+		MATCH p = (seed)-[:RELATES_TO*0..%d]-(neighbor:Entity)
+		WHERE neighbor.space_id = $spaceId
+		WITH neighbor, min(length(p)) AS depth
+		RETURN neighbor AS node, 0.0 AS score
+		ORDER BY depth
+		LIMIT $limit
+	`, max(hops, 0))
+
+	params := map[string]any{
+		"seedIds": seedIds,
+		"spaceId": spaceId,
+		"limit":   limit,
+	}
+
+	result, err := session.Run(ctx, query, params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var entities []storer.Entity
+	ids := []string{}
+	for result.Next(ctx) {
+		entity := s.mapToStorerEntity(result.Record())
+		entities = append(entities, entity)
+		ids = append(ids, entity.Id)
+	}
+
+	if err := result.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(entities) == 0 {
+		return nil, nil, nil
+	}
+
+	relResult, err := session.Run(ctx, `
+		MATCH (source:Entity)-[r:RELATES_TO]->(target:Entity)
+		WHERE source.id IN $ids AND target.id IN $ids
+		RETURN source.id AS source, target.id AS target, r.type AS type
+	`, map[string]any{"ids": ids})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var relations []storer.Relation
+	for relResult.Next(ctx) {
+		props := relResult.Record().AsMap()
+		relations = append(relations, storer.Relation{
+			SourceId: getsafe.String(props, "source"),
+			TargetId: getsafe.String(props, "target"),
+			Type:     getsafe.String(props, "type"),
+		})
+	}
+
+	if err := relResult.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return entities, relations, nil
+}
+
+func (s *neo4jStorer) mapToStorerEntity(r *neo4j.Record) storer.Entity {
+	nodeVal, _ := r.Get("node")
+
+	node := neo4j.Node{}
+	if n, ok := nodeVal.(neo4j.Node); ok {
+		node = n
+	}
+
+	props := node.Props
+
+	var attrs map[string]any
+	if v, ok := props["attributes"]; ok {
+		if str, ok := v.(string); ok {
+			json.Unmarshal([]byte(str), &attrs)
+		}
+	}
+
+	var aliases []string
+	if v, ok := props["aliases"].([]any); ok {
+		for _, a := range v {
+			if str, ok := a.(string); ok {
+				aliases = append(aliases, str)
+			}
+		}
+	}
+
+	scoreVal, _ := r.Get("score")
+
+	score := float32(0)
+	if s, ok := scoreVal.(float64); ok {
+		score = float32(s)
+	}
+
+	return storer.Entity{
+		Id:         getsafe.String(props, "id"),
+		SpaceId:    getsafe.String(props, "space_id"),
+		Name:       getsafe.String(props, "name"),
+		Kind:       getsafe.String(props, "kind"),
+		Aliases:    aliases,
+		Attributes: attrs,
+		Embedding:  getsafe.Float32s(props, "embedding"),
+		Score:      score,
+		CreatedAt:  getsafe.Time(props, "created_at"),
+		UpdatedAt:  getsafe.Time(props, "updated_at"),
+	}
+}
+
+func (s *neo4jStorer) entityIndex() string {
+	return s.options.VectorIndex + "_entities"
+}
@@ -0,0 +1,156 @@
+package neo4j
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+	getsafe "github.com/w-h-a/agent/util/get_safe"
+)
+
+func (s *neo4jStorer) Export(ctx context.Context, spaceId string, w storer.ItemWriter) error {
+	session := s.driver.NewSession(ctx, neo4j.SessionConfig{
+		DatabaseName: s.options.Collection,
+	})
+	defer session.Close(ctx)
+
+	result, err := session.Run(ctx, `
+		MATCH (m:Memory {space_id: $spaceId})
+		RETURN m AS node, 0.0 AS score, `+edgesOf("m")+`
+		ORDER BY m.created_at
+	`, map[string]any{"spaceId": spaceId})
+	if err != nil {
+		return err
+	}
+
+	for result.Next(ctx) {
+		rec, err := s.mapToStorerRecord(result.Record())
+		if err != nil {
+			return err
+		}
+
+		if err := w.Write(storer.Item{Kind: storer.KindRecord, Record: &rec}); err != nil {
+			return err
+		}
+	}
+
+	if err := result.Err(); err != nil {
+		return err
+	}
+
+	entityResult, err := session.Run(ctx, `
+		MATCH (e:Entity {space_id: $spaceId})
+		RETURN e AS node, 0.0 AS score
+		ORDER BY e.created_at
+	`, map[string]any{"spaceId": spaceId})
+	if err != nil {
+		return err
+	}
+
+	for entityResult.Next(ctx) {
+		entity := s.mapToStorerEntity(entityResult.Record())
+		if err := w.Write(storer.Item{Kind: storer.KindEntity, Entity: &entity}); err != nil {
+			return err
+		}
+	}
+
+	if err := entityResult.Err(); err != nil {
+		return err
+	}
+
+	relResult, err := session.Run(ctx, `
+		MATCH (source:Entity {space_id: $spaceId})-[r:RELATES_TO]->(target:Entity)
+		RETURN source.id AS source, target.id AS target, r.type AS type
+	`, map[string]any{"spaceId": spaceId})
+	if err != nil {
+		return err
+	}
+
+	for relResult.Next(ctx) {
+		props := relResult.Record().AsMap()
+		relation := storer.Relation{
+			SourceId: getsafe.String(props, "source"),
+			TargetId: getsafe.String(props, "target"),
+			Type:     getsafe.String(props, "type"),
+		}
+		if err := w.Write(storer.Item{Kind: storer.KindRelation, Relation: &relation}); err != nil {
+			return err
+		}
+	}
+
+	return relResult.Err()
+}
+
+func (s *neo4jStorer) Import(ctx context.Context, r storer.ItemReader) (storer.ImportStats, error) {
+	return storer.ImportItems(ctx, r, storer.ImportTarget{
+		InsertRecord: s.insert,
+		AddEdges:     s.addEdges,
+		Entities:     s,
+	})
+}
+
+func (s *neo4jStorer) insert(ctx context.Context, rec storer.Record) (string, error) {
+	session := s.driver.NewSession(ctx, neo4j.SessionConfig{
+		DatabaseName: s.options.Collection,
+	})
+	defer session.Close(ctx)
+
+	jsonMeta, _ := json.Marshal(rec.Metadata)
+
+	id := uuid.New().String()
+
+	createdAt := rec.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		query := `
+			MERGE (m:Memory {id: $id})
+			SET m.content = $content,
+				m.space_id = $spaceId,
+				m.session_id = $sessionId,
+				m.metadata = $metadata,
+				m.created_at = $createdAt,
+				m.embedding = $embedding,
+				m.embedding_model = $model
+			SET m += $metaProps
+		`
+
+		params := map[string]any{
+			"id":        id,
+			"spaceId":   rec.SpaceId,
+			"sessionId": rec.SessionId,
+			"content":   rec.Content,
+			"metadata":  string(jsonMeta),
+			"createdAt": createdAt.UTC(),
+			"embedding": rec.Embedding,
+			"model":     rec.EmbeddingModel,
+			"metaProps": metadataProperties(rec.Metadata),
+		}
+
+		_, err := tx.Run(ctx, query, params)
+		return nil, err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+func (s *neo4jStorer) addEdges(ctx context.Context, sourceId string, edges []map[string]string) error {
+	session := s.driver.NewSession(ctx, neo4j.SessionConfig{
+		DatabaseName: s.options.Collection,
+	})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		return nil, s.createEdges(ctx, tx, sourceId, edges)
+	})
+
+	return err
+}
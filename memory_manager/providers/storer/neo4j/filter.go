@@ -0,0 +1,119 @@
+package neo4j
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+)
+
+// metadata is kept as a JSON string on the node so the filterable top-level
+// scalars are mirrored onto meta_ prefixed properties
+const metaPrefix = "meta_"
+
+type filterQuery struct {
+	params map[string]any
+}
+
+func (q *filterQuery) param(v any) string {
+	name := fmt.Sprintf("filter%d", len(q.params))
+	q.params[name] = v
+	return "$" + name
+}
+
+func (q *filterQuery) where(node string, f *storer.Filter) string {
+	if f == nil {
+		return "true"
+	}
+
+	switch f.Op {
+	case storer.OpAnd, storer.OpOr:
+		joiner, empty := " AND ", "true"
+		if f.Op == storer.OpOr {
+			joiner, empty = " OR ", "false"
+		}
+		if len(f.Filters) == 0 {
+			return empty
+		}
+		clauses := make([]string, 0, len(f.Filters))
+		for _, child := range f.Filters {
+			clauses = append(clauses, q.where(node, child))
+		}
+		return "(" + strings.Join(clauses, joiner) + ")"
+	}
+
+	prop := fmt.Sprintf("%s.%s%s", node, metaPrefix, f.Field)
+	value := propertyValue
+	switch f.Field {
+	case storer.FieldSessionId:
+		prop = node + ".session_id"
+	case storer.FieldCreatedAt:
+		prop = node + ".created_at"
+		value = createdAtValue
+	case storer.FieldEmbeddingModel:
+		// nodes from before models were tracked have no property at all
+		prop = fmt.Sprintf("coalesce(%s.embedding_model, '')", node)
+		if f.Op == storer.OpExists {
+			return prop + " <> ''"
+		}
+	}
+
+	switch f.Op {
+	case storer.OpEq:
+		return fmt.Sprintf("%s = %s", prop, q.param(value(f.Value)))
+	case storer.OpIn:
+		values := make([]any, 0, len(f.Values))
+		for _, v := range f.Values {
+			values = append(values, value(v))
+		}
+		return fmt.Sprintf("%s IN %s", prop, q.param(values))
+	case storer.OpRange:
+		clauses := []string{}
+		if f.Min != nil {
+			clauses = append(clauses, fmt.Sprintf("%s >= %s", prop, q.param(value(f.Min))))
+		}
+		if f.Max != nil {
+			clauses = append(clauses, fmt.Sprintf("%s <= %s", prop, q.param(value(f.Max))))
+		}
+		return "(" + strings.Join(clauses, " AND ") + ")"
+	case storer.OpExists:
+		return fmt.Sprintf("%s IS NOT NULL", prop)
+	}
+
+	return "false"
+}
+
+func propertyValue(v any) any {
+	if t, ok := v.(time.Time); ok {
+		return t.UTC().Format(time.RFC3339Nano)
+	}
+	return v
+}
+
+func createdAtValue(v any) any {
+	if t, ok := storer.ToTime(v); ok {
+		return t
+	}
+	return v
+}
+
+func metadataProperties(metadata map[string]any) map[string]any {
+	props := map[string]any{}
+
+	for key, v := range metadata {
+		if key == "edges" {
+			continue
+		}
+		switch val := v.(type) {
+		case string, bool, int, int32, int64, float32, float64:
+			props[metaPrefix+key] = val
+		case time.Time:
+			props[metaPrefix+key] = propertyValue(val)
+		case []string:
+			props[metaPrefix+key] = val
+		}
+	}
+
+	return props
+}
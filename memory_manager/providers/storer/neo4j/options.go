@@ -0,0 +1,67 @@
+package neo4j
+
+import (
+	"context"
+	"crypto/tls"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/config"
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+)
+
+type authKey struct{}
+
+type tlsKey struct{}
+
+type driverConfigKey struct{}
+
+// WithBasicAuth logs in with a username and password. realm may be empty.
+func WithBasicAuth(username string, password string, realm string) storer.Option {
+	return withAuth(neo4j.BasicAuth(username, password, realm))
+}
+
+// WithBearerAuth logs in with a token from an SSO provider
+func WithBearerAuth(token string) storer.Option {
+	return withAuth(neo4j.BearerAuth(token))
+}
+
+// WithKerberosAuth logs in with a base64 encoded kerberos ticket
+func WithKerberosAuth(ticket string) storer.Option {
+	return withAuth(neo4j.KerberosAuth(ticket))
+}
+
+func withAuth(token neo4j.AuthToken) storer.Option {
+	return func(o *storer.Options) {
+		o.Context = context.WithValue(o.Context, authKey{}, token)
+	}
+}
+
+func AuthFrom(ctx context.Context) (neo4j.AuthToken, bool) {
+	token, ok := ctx.Value(authKey{}).(neo4j.AuthToken)
+	return token, ok
+}
+
+// WithTLS sets the certificates used for encrypted connections. The driver
+// only encrypts for neo4j+s, neo4j+ssc, bolt+s and bolt+ssc locations.
+func WithTLS(cfg *tls.Config) storer.Option {
+	return func(o *storer.Options) {
+		o.Context = context.WithValue(o.Context, tlsKey{}, cfg)
+	}
+}
+
+func TLSFrom(ctx context.Context) (*tls.Config, bool) {
+	cfg, ok := ctx.Value(tlsKey{}).(*tls.Config)
+	return cfg, ok && cfg != nil
+}
+
+// WithDriverConfig tunes the connection pool, timeouts and the like
+func WithDriverConfig(fn func(*config.Config)) storer.Option {
+	return func(o *storer.Options) {
+		o.Context = context.WithValue(o.Context, driverConfigKey{}, fn)
+	}
+}
+
+func DriverConfigFrom(ctx context.Context) (func(*config.Config), bool) {
+	fn, ok := ctx.Value(driverConfigKey{}).(func(*config.Config))
+	return fn, ok && fn != nil
+}
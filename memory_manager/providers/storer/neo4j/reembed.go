@@ -0,0 +1,130 @@
+package neo4j
+
+import (
+	"context"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+)
+
+func (s *neo4jStorer) PendingEmbeddings(ctx context.Context, spaceId string, model string, limit int) ([]storer.Record, error) {
+	if limit < 1 {
+		return nil, nil
+	}
+
+	session := s.driver.NewSession(ctx, neo4j.SessionConfig{
+		DatabaseName: s.options.Collection,
+	})
+	defer session.Close(ctx)
+
+	// oldest first so a resumed job walks the space in the same order
+	result, err := session.Run(ctx, `
+		MATCH (m:Memory {space_id: $spaceId})
+		WHERE coalesce(m.embedding_model, '') <> $model AND coalesce(m.staged_model, '') <> $model
+		RETURN m AS node, 0.0 AS score
+		ORDER BY m.created_at, m.id
+		LIMIT $limit
+	`, map[string]any{"spaceId": spaceId, "model": model, "limit": limit})
+	if err != nil {
+		return nil, err
+	}
+
+	var records []storer.Record
+	for result.Next(ctx) {
+		rec, err := s.mapToStorerRecord(result.Record())
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+func (s *neo4jStorer) StageEmbeddings(ctx context.Context, model string, vectors map[string][]float32) error {
+	session := s.driver.NewSession(ctx, neo4j.SessionConfig{
+		DatabaseName: s.options.Collection,
+	})
+	defer session.Close(ctx)
+
+	rows := make([]map[string]any, 0, len(vectors))
+	for id, vector := range vectors {
+		rows = append(rows, map[string]any{"id": id, "embedding": vector})
+	}
+
+	// the vector index only covers m.embedding so staged vectors are never searched
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		_, err := tx.Run(ctx, `
+			UNWIND $rows AS row
+			MATCH (m:Memory {id: row.id})
+			SET m.staged_embedding = row.embedding, m.staged_model = $model
+		`, map[string]any{"rows": rows, "model": model})
+		return nil, err
+	})
+
+	return err
+}
+
+func (s *neo4jStorer) PromoteEmbeddings(ctx context.Context, spaceId string, model string) (int, error) {
+	session := s.driver.NewSession(ctx, neo4j.SessionConfig{
+		DatabaseName: s.options.Collection,
+	})
+	defer session.Close(ctx)
+
+	promoted, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		result, err := tx.Run(ctx, `
+			MATCH (m:Memory {space_id: $spaceId, staged_model: $model})
+			SET m.embedding = m.staged_embedding, m.embedding_model = m.staged_model
+			REMOVE m.staged_embedding, m.staged_model
+			RETURN count(m) AS promoted
+		`, map[string]any{"spaceId": spaceId, "model": model})
+		if err != nil {
+			return 0, err
+		}
+
+		rec, err := result.Single(ctx)
+		if err != nil {
+			return 0, err
+		}
+
+		n, _ := rec.Get("promoted")
+		count, _ := n.(int64)
+
+		return int(count), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return promoted.(int), nil
+}
+
+func (s *neo4jStorer) OtherEmbeddingModel(ctx context.Context, spaceId string, model string) (string, error) {
+	session := s.driver.NewSession(ctx, neo4j.SessionConfig{
+		DatabaseName: s.options.Collection,
+	})
+	defer session.Close(ctx)
+
+	result, err := session.Run(ctx, `
+		MATCH (m:Memory {space_id: $spaceId})
+		WHERE NOT coalesce(m.embedding_model, '') IN [$model, '']
+		RETURN m.embedding_model AS model
+		LIMIT 1
+	`, map[string]any{"spaceId": spaceId, "model": model})
+	if err != nil {
+		return "", err
+	}
+
+	if !result.Next(ctx) {
+		return "", result.Err()
+	}
+
+	other, _ := result.Record().Get("model")
+	name, _ := other.(string)
+
+	return name, nil
+}
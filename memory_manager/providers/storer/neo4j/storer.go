@@ -4,10 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/config"
 	"github.com/w-h-a/agent/memory_manager/providers/storer"
 	getsafe "github.com/w-h-a/agent/util/get_safe"
 )
@@ -17,16 +20,23 @@ type neo4jStorer struct {
 	driver  neo4j.DriverWithContext
 }
 
-func (s *neo4jStorer) Store(ctx context.Context, spaceId string, sessionId string, content string, metadata map[string]any, vector []float32) error {
+func (s *neo4jStorer) Store(ctx context.Context, spaceId string, sessionId string, content string, metadata map[string]any, vector []float32) (string, error) {
+	if err := storer.CheckDimension(s.options.VectorSize, vector); err != nil {
+		return "", err
+	}
+
 	session := s.driver.NewSession(ctx, neo4j.SessionConfig{
 		DatabaseName: s.options.Collection,
 	})
 	defer session.Close(ctx)
 
 	edges := storer.SanitizeEdges(metadata)
+	model := storer.TakeEmbeddingModel(metadata)
 
 	jsonMeta, _ := json.Marshal(metadata)
 
+	id := uuid.New().String()
+
 	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
 		createNode := `
 			MERGE (m:Memory {id: $id})
@@ -35,69 +45,101 @@ func (s *neo4jStorer) Store(ctx context.Context, spaceId string, sessionId strin
 				m.session_id = $sessionId,
 				m.metadata = $metadata,
 				m.created_at = datetime(),
-				m.embedding = $embedding
+				m.embedding = $embedding,
+				m.embedding_model = $model
+			SET m += $metaProps
 		`
 		nodeParams := map[string]any{
-			"id":        uuid.New().String(),
+			"id":        id,
 			"spaceId":   spaceId,
 			"sessionId": sessionId,
 			"content":   content,
 			"metadata":  string(jsonMeta),
 			"embedding": vector,
+			"model":     model,
+			"metaProps": metadataProperties(metadata),
 		}
 
 		if _, err := tx.Run(ctx, createNode, nodeParams); err != nil {
 			return nil, err
 		}
 
-		if len(edges) == 0 {
-			return nil, nil
-		}
+		return nil, s.createEdges(ctx, tx, id, edges)
+	})
+	if err != nil {
+		return "", err
+	}
 
-		for _, edge := range edges {
-			createEdge := fmt.Sprintf(`
-					MATCH (source:Memory {id: $sourceId})
-					MATCH (target:Memory {id: $targetId})
-					MERGE (source)-[:%s]->(target)
-				`, edge["type"])
+	return id, nil
+}
 
-			edgeParams := map[string]any{
-				"sourceId": nodeParams["id"],
-				"targetId": edge["target"],
-			}
+func (s *neo4jStorer) createEdges(ctx context.Context, tx neo4j.ManagedTransaction, id string, edges []map[string]string) error {
+	for _, edge := range edges {
+		// types cannot be parameters so they are quoted instead
+		createEdge := fmt.Sprintf(`
+				MATCH (source:Memory {id: $sourceId})
+				MATCH (target:Memory {id: $targetId})
+				MERGE (source)-[:%s]->(target)
+			`, quoteIdentifier(edge["type"]))
+
+		edgeParams := map[string]any{
+			"sourceId": id,
+			"targetId": edge["target"],
+		}
 
-			if _, err := tx.Run(ctx, createEdge, edgeParams); err != nil {
-				return nil, err
-			}
+		if _, err := tx.Run(ctx, createEdge, edgeParams); err != nil {
+			return err
 		}
+	}
+
+	return nil
+}
 
+func (s *neo4jStorer) Search(ctx context.Context, spaceId string, vector []float32, limit int, filter *storer.Filter) ([]storer.Record, error) {
+	if limit < 1 {
 		return nil, nil
-	})
+	}
 
-	return err
-}
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	filter = storer.Unexpired(s.options.TTL, filter)
+
+	if err := storer.CheckDimension(s.options.VectorSize, vector); err != nil {
+		return nil, err
+	}
 
-func (s *neo4jStorer) Search(ctx context.Context, spaceId string, vector []float32, limit int) ([]storer.Record, error) {
 	session := s.driver.NewSession(ctx, neo4j.SessionConfig{
 		DatabaseName: s.options.Collection,
 	})
 	defer session.Close(ctx)
 
+	q := &filterQuery{params: map[string]any{}}
+	where := q.where("node", filter)
+
 	query := `
 		CALL db.index.vector.queryNodes($index, $k, $vec)
 		YIELD node, score
-		WHERE node.space_id = $spaceId
-		RETURN node, score
+		WHERE node.space_id = $spaceId AND ` + where + `
+		RETURN node, score, ` + edgesOf("node") + `
 		LIMIT $finalLimit
 	`
 
+	// the index is queried before filtering so over-fetch when filtering
+	k := limit * 2
+	if filter != nil {
+		k = limit * 10
+	}
+
 	params := map[string]any{
 		"index":      s.options.VectorIndex,
-		"k":          limit * 2,
+		"k":          k,
 		"vec":        vector,
 		"spaceId":    spaceId,
 		"finalLimit": limit,
 	}
+	maps.Copy(params, q.params)
 
 	result, err := session.Run(ctx, query, params)
 	if err != nil {
@@ -119,25 +161,40 @@ func (s *neo4jStorer) Search(ctx context.Context, spaceId string, vector []float
 	return records, nil
 }
 
-func (s *neo4jStorer) SearchNeighborhood(ctx context.Context, seedIds []string, hops int, limit int) ([]storer.Record, error) {
+func (s *neo4jStorer) SearchNeighborhood(ctx context.Context, seedIds []string, hops int, limit int, filter *storer.Filter) ([]storer.Record, error) {
+	if limit < 1 || len(seedIds) == 0 {
+		return nil, nil
+	}
+
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	filter = storer.Unexpired(s.options.TTL, filter)
+
 	session := s.driver.NewSession(ctx, neo4j.SessionConfig{
 		DatabaseName: s.options.Collection,
 	})
 	defer session.Close(ctx)
 
+	q := &filterQuery{params: map[string]any{}}
+	where := q.where("neighbor", filter)
+
 	query := fmt.Sprintf(`
 		MATCH (start:Memory)
 		WHERE start.id IN $seedIds
 		MATCH (start)-[*1..%d]-(neighbor:Memory)
-		WHERE NOT neighbor.id IN $seedIds
-		RETURN DISTINCT neighbor as node, 0.0 as score
+		WHERE NOT neighbor.id IN $seedIds AND %s
+		WITH DISTINCT neighbor
+		RETURN neighbor as node, 0.0 as score, %s
 		LIMIT $limit
-	`, hops)
+	`, hops, where, edgesOf("neighbor"))
 
 	params := map[string]any{
 		"seedIds": seedIds,
 		"limit":   limit,
 	}
+	maps.Copy(params, q.params)
 
 	result, err := session.Run(ctx, query, params)
 	if err != nil {
@@ -159,6 +216,172 @@ func (s *neo4jStorer) SearchNeighborhood(ctx context.Context, seedIds []string,
 	return records, nil
 }
 
+func (s *neo4jStorer) SearchLexical(ctx context.Context, spaceId string, query string, limit int, filter *storer.Filter) ([]storer.Record, error) {
+	tokens := storer.Tokenize(query)
+	if limit < 1 || !s.options.Lexical || len(tokens) == 0 {
+		return nil, nil
+	}
+
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	filter = storer.Unexpired(s.options.TTL, filter)
+
+	session := s.driver.NewSession(ctx, neo4j.SessionConfig{
+		DatabaseName: s.options.Collection,
+	})
+	defer session.Close(ctx)
+
+	q := &filterQuery{params: map[string]any{}}
+	where := q.where("node", filter)
+
+	// any token may match and each is escaped so lucene syntax in the query is literal
+	terms := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		terms = append(terms, `"`+escapeLucene(token)+`"`)
+	}
+
+	cypher := `
+		CALL db.index.fulltext.queryNodes($index, $terms)
+		YIELD node, score
+		WHERE node.space_id = $spaceId AND ` + where + `
+		RETURN node, score, ` + edgesOf("node") + `
+		LIMIT $limit
+	`
+
+	params := map[string]any{
+		"index":   s.textIndex(),
+		"terms":   strings.Join(terms, " OR "),
+		"spaceId": spaceId,
+		"limit":   limit,
+	}
+	maps.Copy(params, q.params)
+
+	result, err := session.Run(ctx, cypher, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []storer.Record
+	for result.Next(ctx) {
+		record, err := s.mapToStorerRecord(result.Record())
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// edgesOf projects a memory's outgoing relationships, which are
+// authoritative for its edges, in the shape Record.Metadata keeps them
+func edgesOf(variable string) string {
+	return fmt.Sprintf("[(%s)-[r]->(t:Memory) | {target: t.id, type: type(r)}] AS edges", variable)
+}
+
+// quoteIdentifier escapes a label, relationship type or index name for
+// places cypher does not take parameters
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func (s *neo4jStorer) textIndex() string {
+	return s.options.VectorIndex + "_text"
+}
+
+func escapeLucene(term string) string {
+	var sb strings.Builder
+	for _, r := range term {
+		if strings.ContainsRune(`+-&|!(){}[]^"~*?:\/`, r) {
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+func (s *neo4jStorer) Delete(ctx context.Context, ids []string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	return s.deleteNodes(ctx, `
+		MATCH (m:Memory)
+		WHERE m.id IN $ids
+		DETACH DELETE m
+	`, map[string]any{"ids": ids})
+}
+
+func (s *neo4jStorer) DeleteBySession(ctx context.Context, sessionId string) (int, error) {
+	return s.deleteNodes(ctx, `
+		MATCH (m:Memory {session_id: $sessionId})
+		DETACH DELETE m
+	`, map[string]any{"sessionId": sessionId})
+}
+
+func (s *neo4jStorer) DeleteBySpace(ctx context.Context, spaceId string) (int, error) {
+	n, err := s.deleteNodes(ctx, `
+		MATCH (m:Memory {space_id: $spaceId})
+		DETACH DELETE m
+	`, map[string]any{"spaceId": spaceId})
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := s.deleteNodes(ctx, `
+		MATCH (e:Entity {space_id: $spaceId})
+		DETACH DELETE e
+	`, map[string]any{"spaceId": spaceId}); err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+func (s *neo4jStorer) DeleteExpired(ctx context.Context) (int, error) {
+	cutoff, ok := storer.ExpiredBefore(s.options.TTL)
+	if !ok {
+		return 0, nil
+	}
+
+	return s.deleteNodes(ctx, `
+		MATCH (m:Memory)
+		WHERE m.created_at < $cutoff
+		DETACH DELETE m
+	`, map[string]any{"cutoff": cutoff})
+}
+
+func (s *neo4jStorer) deleteNodes(ctx context.Context, query string, params map[string]any) (int, error) {
+	session := s.driver.NewSession(ctx, neo4j.SessionConfig{
+		DatabaseName: s.options.Collection,
+	})
+	defer session.Close(ctx)
+
+	// DETACH DELETE takes the node's relationships with it
+	deleted, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		result, err := tx.Run(ctx, query, params)
+		if err != nil {
+			return nil, err
+		}
+		summary, err := result.Consume(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return summary.Counters().NodesDeleted(), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted.(int), nil
+}
+
 func (s *neo4jStorer) mapToStorerRecord(r *neo4j.Record) (storer.Record, error) {
 	nodeVal, _ := r.Get("node")
 
@@ -183,14 +406,26 @@ func (s *neo4jStorer) mapToStorerRecord(r *neo4j.Record) (storer.Record, error)
 		score = float32(s)
 	}
 
+	if raw, ok := r.Get("edges"); ok {
+		if meta == nil {
+			meta = map[string]any{}
+		}
+		delete(meta, "edges")
+		if edges := storer.ValidateEdges(raw); len(edges) > 0 {
+			meta["edges"] = edges
+		}
+	}
+
 	rec := storer.Record{
-		Id:        getsafe.String(props, "id"),
-		SpaceId:   getsafe.String(props, "space_id"),
-		SessionId: getsafe.String(props, "session_id"),
-		Content:   getsafe.String(props, "content"),
-		Metadata:  meta,
-		Score:     score,
-		CreatedAt: getsafe.Time(props, "created_at"),
+		Id:             getsafe.String(props, "id"),
+		SpaceId:        getsafe.String(props, "space_id"),
+		SessionId:      getsafe.String(props, "session_id"),
+		Content:        getsafe.String(props, "content"),
+		Metadata:       meta,
+		Embedding:      getsafe.Float32s(props, "embedding"),
+		Score:          score,
+		CreatedAt:      getsafe.Time(props, "created_at"),
+		EmbeddingModel: getsafe.String(props, storer.FieldEmbeddingModel),
 	}
 
 	return rec, nil
@@ -202,11 +437,15 @@ func (s *neo4jStorer) configure(ctx context.Context) error {
 	})
 	defer session.Close(ctx)
 
-	distance := s.options.Distance
+	distance := strings.ToLower(s.options.Distance)
 	if len(distance) == 0 {
 		distance = "cosine"
 	}
 
+	if distance != "cosine" && distance != "euclidean" {
+		return fmt.Errorf("unsupported neo4j similarity function %q", s.options.Distance)
+	}
+
 	vectorQuery := fmt.Sprintf(
 		"CREATE VECTOR INDEX %s IF NOT EXISTS "+
 			"FOR (m:Memory) ON (m.embedding) "+
@@ -214,13 +453,46 @@ func (s *neo4jStorer) configure(ctx context.Context) error {
 			" `vector.dimensions`: %d,"+
 			" `vector.similarity_function`: '%s'"+
 			"}}",
-		s.options.VectorIndex, s.options.VectorSize, distance,
+		quoteIdentifier(s.options.VectorIndex), s.options.VectorSize, distance,
 	)
 
 	if _, err := session.Run(ctx, vectorQuery, nil); err != nil {
 		return fmt.Errorf("failed to create vector index: %w", err)
 	}
 
+	entityVectorQuery := fmt.Sprintf(
+		"CREATE VECTOR INDEX %s IF NOT EXISTS "+
+			"FOR (e:Entity) ON (e.embedding) "+
+			"OPTIONS {indexConfig: {"+
+			" `vector.dimensions`: %d,"+
+			" `vector.similarity_function`: '%s'"+
+			"}}",
+		quoteIdentifier(s.entityIndex()), s.options.VectorSize, distance,
+	)
+
+	if _, err := session.Run(ctx, entityVectorQuery, nil); err != nil {
+		return fmt.Errorf("failed to create entity vector index: %w", err)
+	}
+
+	if s.options.Lexical {
+		textQuery := fmt.Sprintf(
+			"CREATE FULLTEXT INDEX %s IF NOT EXISTS FOR (m:Memory) ON EACH [m.content]",
+			quoteIdentifier(s.textIndex()),
+		)
+
+		if _, err := session.Run(ctx, textQuery, nil); err != nil {
+			return fmt.Errorf("failed to create full-text index: %w", err)
+		}
+	}
+
+	modelQuery := `
+		CREATE INDEX memory_space_model IF NOT EXISTS
+		FOR (m:Memory) ON (m.space_id, m.embedding_model)
+	`
+	if _, err := session.Run(ctx, modelQuery, nil); err != nil {
+		return fmt.Errorf("failed to create embedding model index: %w", err)
+	}
+
 	constraintQuery := `
 		CREATE CONSTRAINT memory_id_unique IF NOT EXISTS
 		FOR (m:Memory) REQUIRE m.id IS UNIQUE
@@ -229,32 +501,61 @@ func (s *neo4jStorer) configure(ctx context.Context) error {
 		return fmt.Errorf("failed to create unique constraint: %w", err)
 	}
 
+	entityConstraintQuery := `
+		CREATE CONSTRAINT entity_id_unique IF NOT EXISTS
+		FOR (e:Entity) REQUIRE e.id IS UNIQUE
+	`
+	if _, err := session.Run(ctx, entityConstraintQuery, nil); err != nil {
+		return fmt.Errorf("failed to create entity unique constraint: %w", err)
+	}
+
 	return nil
 }
 
-func NewStorer(opts ...storer.Option) storer.Storer {
+func NewStorer(opts ...storer.Option) (storer.Storer, error) {
 	options := storer.NewOptions(opts...)
 
 	s := &neo4jStorer{
 		options: options,
 	}
 
+	auth := neo4j.NoAuth()
+	if token, ok := AuthFrom(options.Context); ok {
+		auth = token
+	} else if len(options.ApiKey) > 0 {
+		auth = neo4j.BearerAuth(options.ApiKey)
+	}
+
 	driver, err := neo4j.NewDriverWithContext(
 		s.options.Location,
-		// TODO
-		neo4j.NoAuth(),
+		auth,
+		func(c *config.Config) {
+			if cfg, ok := TLSFrom(options.Context); ok {
+				c.TlsConfig = cfg
+			}
+			if fn, ok := DriverConfigFrom(options.Context); ok {
+				fn(c)
+			}
+		},
 	)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("failed to create neo4j driver: %w", err)
 	}
 
 	s.driver = driver
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
+
+	if err := driver.VerifyConnectivity(ctx); err != nil {
+		driver.Close(context.Background())
+		return nil, fmt.Errorf("failed to connect to neo4j: %w", err)
+	}
+
 	if err := s.configure(ctx); err != nil {
-		panic(err)
+		driver.Close(context.Background())
+		return nil, err
 	}
 
-	return s
+	return s, nil
 }
@@ -0,0 +1,45 @@
+package neo4j_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+	"github.com/w-h-a/agent/memory_manager/providers/storer/neo4j"
+	"github.com/w-h-a/agent/memory_manager/providers/storer/storertest"
+)
+
+// the instance from docker-compose.yml
+const location = "bolt://localhost:7687"
+
+func TestConformance(t *testing.T) {
+	if os.Getenv("INTEGRATION") != "1" {
+		t.Skip("set INTEGRATION=1 to run against docker")
+	}
+
+	s, err := neo4j.NewStorer(
+		storer.WithVectorSize(storertest.VectorSize),
+		storer.WithLocation(location),
+		storer.WithVectorIndex("conformance"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storertest.Run(t, s)
+
+	t.Run("ttl", func(t *testing.T) {
+		s, err := neo4j.NewStorer(
+			storer.WithVectorSize(storertest.VectorSize),
+			storer.WithLocation(location),
+			storer.WithVectorIndex("conformance"),
+			storer.WithTTL(time.Hour),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		storertest.RunTTL(t, s, time.Hour)
+	})
+}
@@ -1,6 +1,9 @@
 package storer
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Option func(*Options)
 
@@ -11,6 +14,8 @@ type Options struct {
 	VectorIndex string
 	VectorSize  uint64
 	Distance    string
+	TTL         time.Duration
+	Lexical     bool
 	Context     context.Context
 }
 
@@ -50,6 +55,18 @@ func WithDistance(dist string) Option {
 	}
 }
 
+func WithTTL(ttl time.Duration) Option {
+	return func(o *Options) {
+		o.TTL = ttl
+	}
+}
+
+func WithLexicalIndex(enabled bool) Option {
+	return func(o *Options) {
+		o.Lexical = enabled
+	}
+}
+
 func NewOptions(opts ...Option) Options {
 	options := Options{
 		Context: context.Background(),
@@ -0,0 +1,273 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/lib/pq"
+	"github.com/pgvector/pgvector-go"
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+)
+
+func (p *postgresStorer) UpsertEntity(ctx context.Context, spaceId string, entity storer.Entity) (string, error) {
+	if err := storer.CheckDimension(p.options.VectorSize, entity.Embedding); err != nil {
+		return "", err
+	}
+
+	aliasesJSON, err := json.Marshal(entity.Aliases)
+	if err != nil {
+		return "", fmt.Errorf("marshal aliases: %w", err)
+	}
+
+	attrsJSON, err := json.Marshal(entity.Attributes)
+	if err != nil {
+		return "", fmt.Errorf("marshal attributes: %w", err)
+	}
+
+	if len(entity.Id) > 0 {
+		query := `
+			UPDATE entities
+			SET name = $1,
+				kind = $2,
+				aliases = $3,
+				attributes = $4,
+				embedding = $5,
+				updated_at = NOW()
+			WHERE id = $6 AND space_id = $7
+		`
+
+		res, err := p.conn.ExecContext(
+			ctx,
+			query,
+			entity.Name,
+			entity.Kind,
+			aliasesJSON,
+			attrsJSON,
+			pgvector.NewVector(entity.Embedding),
+			entity.Id,
+			spaceId,
+		)
+		if err != nil {
+			return "", err
+		}
+
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return "", fmt.Errorf("entity %s not found", entity.Id)
+		}
+
+		return entity.Id, nil
+	}
+
+	query := `
+		INSERT INTO entities (
+			space_id,
+			name,
+			kind,
+			aliases,
+			attributes,
+			embedding
+		)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	var id int64
+	if err := p.conn.QueryRowContext(
+		ctx,
+		query,
+		spaceId,
+		entity.Name,
+		entity.Kind,
+		aliasesJSON,
+		attrsJSON,
+		pgvector.NewVector(entity.Embedding),
+	).Scan(&id); err != nil {
+		return "", err
+	}
+
+	return strconv.FormatInt(id, 10), nil
+}
+
+func (p *postgresStorer) SearchEntities(ctx context.Context, spaceId string, vector []float32, limit int) ([]storer.Entity, error) {
+	if limit < 1 {
+		return nil, nil
+	}
+
+	if err := storer.CheckDimension(p.options.VectorSize, vector); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT
+			id,
+			space_id,
+			name,
+			kind,
+			aliases,
+			attributes,
+			embedding,
+			1 - (` + castEmbedding(len(vector)) + ` <=> $2) as score,
+			created_at,
+			updated_at
+		FROM entities
+		WHERE space_id = $1 AND ` + sized(len(vector)) + `
+		ORDER BY ` + castEmbedding(len(vector)) + ` <=> $2
+		LIMIT $3
+	`
+
+	rows, err := p.conn.QueryContext(ctx, query, spaceId, pgvector.NewVector(vector), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return p.scanEntities(rows)
+}
+
+func (p *postgresStorer) Relate(ctx context.Context, spaceId string, relation storer.Relation) error {
+	query := `
+		INSERT INTO entity_relations (source_id, target_id, type)
+		SELECT s.id, t.id, $3
+		FROM entities s, entities t
+		WHERE s.id = $1 AND t.id = $2 AND s.space_id = $4 AND t.space_id = $4
+		ON CONFLICT DO NOTHING
+	`
+
+	_, err := p.conn.ExecContext(
+		ctx,
+		query,
+		relation.SourceId,
+		relation.TargetId,
+		storer.SanitizeType(relation.Type),
+		spaceId,
+	)
+
+	return err
+}
+
+func (p *postgresStorer) SearchEntityGraph(ctx context.Context, spaceId string, seedIds []string, hops int, limit int) ([]storer.Entity, []storer.Relation, error) {
+	if limit < 1 || len(seedIds) == 0 {
+		return nil, nil, nil
+	}
+
+	query := `
+    WITH RECURSIVE graph_walk AS (
+        SELECT id, 0 as depth
+        FROM entities
+        WHERE id = ANY($1::bigint[]) AND space_id = $2
+
+        UNION
+
+        SELECT CASE WHEN r.source_id = gw.id THEN r.target_id ELSE r.source_id END, gw.depth + 1
+        FROM entity_relations r
+        INNER JOIN graph_walk gw ON gw.id = r.source_id OR gw.id = r.target_id
+        WHERE gw.depth < $3
+    ),
+    nearest AS (
+        SELECT id, MIN(depth) as depth
+        FROM graph_walk
+        GROUP BY id
+    )
+    SELECT e.id, e.space_id, e.name, e.kind, e.aliases, e.attributes, e.embedding, 0 as score, e.created_at, e.updated_at
+    FROM nearest n
+    INNER JOIN entities e ON e.id = n.id
+    WHERE e.space_id = $2
+    ORDER BY n.depth, e.id
+    LIMIT $4
+    `
+
+	rows, err := p.conn.QueryContext(ctx, query, pq.Array(seedIds), spaceId, hops, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	entities, err := p.scanEntities(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(entities) == 0 {
+		return nil, nil, nil
+	}
+
+	ids := make([]string, 0, len(entities))
+	for _, entity := range entities {
+		ids = append(ids, entity.Id)
+	}
+
+	relRows, err := p.conn.QueryContext(
+		ctx,
+		`SELECT source_id, target_id, type FROM entity_relations WHERE source_id = ANY($1::bigint[]) AND target_id = ANY($1::bigint[])`,
+		pq.Array(ids),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer relRows.Close()
+
+	var relations []storer.Relation
+	for relRows.Next() {
+		var source, target int64
+		var rel storer.Relation
+		if err := relRows.Scan(&source, &target, &rel.Type); err != nil {
+			return nil, nil, err
+		}
+		rel.SourceId = strconv.FormatInt(source, 10)
+		rel.TargetId = strconv.FormatInt(target, 10)
+		relations = append(relations, rel)
+	}
+
+	if err := relRows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return entities, relations, nil
+}
+
+func (p *postgresStorer) scanEntities(rows *sql.Rows) ([]storer.Entity, error) {
+	var entities []storer.Entity
+
+	for rows.Next() {
+		var id int64
+		var entity storer.Entity
+		var aliasBytes, attrBytes []byte
+		var vec pgvector.Vector
+
+		err := rows.Scan(
+			&id,
+			&entity.SpaceId,
+			&entity.Name,
+			&entity.Kind,
+			&aliasBytes,
+			&attrBytes,
+			&vec,
+			&entity.Score,
+			&entity.CreatedAt,
+			&entity.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		entity.Id = strconv.FormatInt(id, 10)
+		entity.Embedding = vec.Slice()
+
+		json.Unmarshal(aliasBytes, &entity.Aliases)
+
+		if err := json.Unmarshal(attrBytes, &entity.Attributes); err != nil {
+			entity.Attributes = make(map[string]any)
+		}
+
+		entities = append(entities, entity)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entities, nil
+}
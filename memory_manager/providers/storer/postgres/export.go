@@ -0,0 +1,348 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pgvector/pgvector-go"
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+)
+
+func (p *postgresStorer) Export(ctx context.Context, spaceId string, w storer.ItemWriter) error {
+	// edges are small so they are gathered up front and records streamed after
+	edges, err := p.spaceEdges(ctx, spaceId)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		SELECT
+			id,
+			session_id,
+			content,
+			metadata,
+			embedding,
+			space_id,
+			created_at,
+			updated_at,
+			embedding_model
+		FROM messages
+		WHERE space_id = $1
+		ORDER BY created_at, id
+	`
+
+	rows, err := p.conn.QueryContext(ctx, query, spaceId)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var rec storer.Record
+		var metaBytes []byte
+		var vec pgvector.Vector
+
+		err := rows.Scan(
+			&id,
+			&rec.SessionId,
+			&rec.Content,
+			&metaBytes,
+			&vec,
+			&rec.SpaceId,
+			&rec.CreatedAt,
+			&rec.UpdatedAt,
+			&rec.EmbeddingModel,
+		)
+		if err != nil {
+			return err
+		}
+
+		rec.Id = strconv.FormatInt(id, 10)
+		rec.Embedding = vec.Slice()
+
+		if err := json.Unmarshal(metaBytes, &rec.Metadata); err != nil || rec.Metadata == nil {
+			rec.Metadata = make(map[string]any)
+		}
+
+		// the edge table is authoritative over whatever metadata kept
+		delete(rec.Metadata, "edges")
+		if out := edges[rec.Id]; len(out) > 0 {
+			rec.Metadata["edges"] = out
+		}
+
+		if err := w.Write(storer.Item{Kind: storer.KindRecord, Record: &rec}); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	entityRows, err := p.conn.QueryContext(
+		ctx,
+		`SELECT id, space_id, name, kind, aliases, attributes, embedding, 0 as score, created_at, updated_at FROM entities WHERE space_id = $1 ORDER BY id`,
+		spaceId,
+	)
+	if err != nil {
+		return err
+	}
+	defer entityRows.Close()
+
+	entities, err := p.scanEntities(entityRows)
+	if err != nil {
+		return err
+	}
+
+	for i := range entities {
+		if err := w.Write(storer.Item{Kind: storer.KindEntity, Entity: &entities[i]}); err != nil {
+			return err
+		}
+	}
+
+	relRows, err := p.conn.QueryContext(
+		ctx,
+		`SELECT r.source_id, r.target_id, r.type FROM entity_relations r INNER JOIN entities e ON e.id = r.source_id WHERE e.space_id = $1`,
+		spaceId,
+	)
+	if err != nil {
+		return err
+	}
+	defer relRows.Close()
+
+	for relRows.Next() {
+		var source, target int64
+		var rel storer.Relation
+		if err := relRows.Scan(&source, &target, &rel.Type); err != nil {
+			return err
+		}
+		rel.SourceId = strconv.FormatInt(source, 10)
+		rel.TargetId = strconv.FormatInt(target, 10)
+		if err := w.Write(storer.Item{Kind: storer.KindRelation, Relation: &rel}); err != nil {
+			return err
+		}
+	}
+
+	if err := relRows.Err(); err != nil {
+		return err
+	}
+
+	// skills and files arrived with migration 000008
+	migrated, err := p.hasTable(ctx, "skills")
+	if err != nil || !migrated {
+		return err
+	}
+
+	if err := p.exportSkills(ctx, spaceId, w); err != nil {
+		return err
+	}
+
+	return p.exportChunks(ctx, spaceId, w)
+}
+
+func (p *postgresStorer) exportSkills(ctx context.Context, spaceId string, w storer.ItemWriter) error {
+	rows, err := p.conn.QueryContext(
+		ctx,
+		`SELECT id, space_id, trigger, sop, embedding FROM skills WHERE space_id = $1 ORDER BY created_at, id`,
+		spaceId,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var skill storer.Skill
+		var vec *pgvector.Vector
+		if err := rows.Scan(&skill.Id, &skill.SpaceId, &skill.Trigger, &skill.SOP, &vec); err != nil {
+			return err
+		}
+		if vec != nil {
+			skill.Embedding = vec.Slice()
+		}
+		if err := w.Write(storer.Item{Kind: storer.KindSkill, Skill: &skill}); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (p *postgresStorer) exportChunks(ctx context.Context, spaceId string, w storer.ItemWriter) error {
+	rows, err := p.conn.QueryContext(
+		ctx,
+		`
+		SELECT c.id, c.space_id, c.file_id, f.filename, c.chunk_index, c.content, c.embedding
+		FROM chunks c
+		INNER JOIN files f ON f.id = c.file_id
+		WHERE c.space_id = $1
+		ORDER BY f.created_at, c.file_id, c.chunk_index
+		`,
+		spaceId,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var chunk storer.Chunk
+		var vec *pgvector.Vector
+		if err := rows.Scan(&chunk.Id, &chunk.SpaceId, &chunk.FileId, &chunk.Filename, &chunk.ChunkIndex, &chunk.Content, &vec); err != nil {
+			return err
+		}
+		if vec != nil {
+			chunk.Embedding = vec.Slice()
+		}
+		if err := w.Write(storer.Item{Kind: storer.KindChunk, Chunk: &chunk}); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (p *postgresStorer) spaceEdges(ctx context.Context, spaceId string) (map[string][]map[string]string, error) {
+	query := `
+		SELECT e.source_id, e.target_id, e.type
+		FROM message_edges e
+		INNER JOIN messages m ON m.id = e.source_id
+		WHERE m.space_id = $1
+	`
+
+	rows, err := p.conn.QueryContext(ctx, query, spaceId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	edges := map[string][]map[string]string{}
+
+	for rows.Next() {
+		var source, target int64
+		var t string
+		if err := rows.Scan(&source, &target, &t); err != nil {
+			return nil, err
+		}
+		id := strconv.FormatInt(source, 10)
+		edges[id] = append(edges[id], map[string]string{
+			"target": strconv.FormatInt(target, 10),
+			"type":   t,
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return edges, nil
+}
+
+func (p *postgresStorer) Import(ctx context.Context, r storer.ItemReader) (storer.ImportStats, error) {
+	return storer.ImportItems(ctx, r, storer.ImportTarget{
+		InsertRecord: p.insert,
+		AddEdges:     p.addEdges,
+		Entities:     p,
+		InsertSkill:  p.insertSkill,
+		InsertFile:   p.insertFile,
+		InsertChunk:  p.insertChunk,
+	})
+}
+
+func (p *postgresStorer) insertSkill(ctx context.Context, skill storer.Skill) error {
+	_, err := p.conn.ExecContext(
+		ctx,
+		`INSERT INTO skills (id, space_id, trigger, sop, embedding) VALUES ($1, $2, $3, $4, $5)`,
+		uuid.New().String(),
+		skill.SpaceId,
+		skill.Trigger,
+		skill.SOP,
+		nullVector(skill.Embedding),
+	)
+	return err
+}
+
+func (p *postgresStorer) insertFile(ctx context.Context, spaceId string, filename string) (string, error) {
+	id := uuid.New().String()
+	if _, err := p.conn.ExecContext(ctx, `INSERT INTO files (id, space_id, filename) VALUES ($1, $2, $3)`, id, spaceId, filename); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+func (p *postgresStorer) insertChunk(ctx context.Context, chunk storer.Chunk) error {
+	_, err := p.conn.ExecContext(
+		ctx,
+		`INSERT INTO chunks (id, file_id, space_id, chunk_index, content, embedding) VALUES ($1, $2, $3, $4, $5, $6)`,
+		uuid.New().String(),
+		chunk.FileId,
+		chunk.SpaceId,
+		chunk.ChunkIndex,
+		chunk.Content,
+		nullVector(chunk.Embedding),
+	)
+	return err
+}
+
+func (p *postgresStorer) insert(ctx context.Context, rec storer.Record) (string, error) {
+	if err := storer.CheckDimension(p.options.VectorSize, rec.Embedding); err != nil {
+		return "", err
+	}
+
+	metaJSON, err := json.Marshal(rec.Metadata)
+	if err != nil {
+		return "", fmt.Errorf("marshal metadata: %w", err)
+	}
+
+	query := `
+		INSERT INTO messages (
+			session_id,
+			content,
+			metadata,
+			embedding,
+			space_id,
+			created_at,
+			updated_at,
+			embedding_model
+		)
+		VALUES ($1, $2, $3, $4, $5, COALESCE($6, NOW()), COALESCE($7, NOW()), $8)
+		RETURNING id
+	`
+
+	var id int64
+	if err := p.conn.QueryRowContext(
+		ctx,
+		query,
+		rec.SessionId,
+		rec.Content,
+		metaJSON,
+		pgvector.NewVector(rec.Embedding),
+		rec.SpaceId,
+		nullTime(rec.CreatedAt),
+		nullTime(rec.UpdatedAt),
+		rec.EmbeddingModel,
+	).Scan(&id); err != nil {
+		return "", err
+	}
+
+	return strconv.FormatInt(id, 10), nil
+}
+
+func nullVector(v []float32) any {
+	if len(v) == 0 {
+		return nil
+	}
+	return pgvector.NewVector(v)
+}
+
+func nullTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
@@ -0,0 +1,152 @@
+package postgres
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+)
+
+type filterQuery struct {
+	args []any
+}
+
+func (q *filterQuery) param(v any) string {
+	q.args = append(q.args, v)
+	return fmt.Sprintf("$%d", len(q.args))
+}
+
+func (q *filterQuery) where(f *storer.Filter) (string, error) {
+	if f == nil {
+		return "TRUE", nil
+	}
+
+	switch f.Op {
+	case storer.OpAnd, storer.OpOr:
+		joiner, empty := " AND ", "TRUE"
+		if f.Op == storer.OpOr {
+			joiner, empty = " OR ", "FALSE"
+		}
+		if len(f.Filters) == 0 {
+			return empty, nil
+		}
+		clauses := make([]string, 0, len(f.Filters))
+		for _, child := range f.Filters {
+			clause, err := q.where(child)
+			if err != nil {
+				return "", err
+			}
+			clauses = append(clauses, clause)
+		}
+		return "(" + strings.Join(clauses, joiner) + ")", nil
+	}
+
+	switch f.Field {
+	case storer.FieldSessionId, storer.FieldCreatedAt, storer.FieldEmbeddingModel:
+		return q.column(f), nil
+	}
+
+	return q.metadata(f)
+}
+
+func (q *filterQuery) column(f *storer.Filter) string {
+	col := f.Field
+
+	switch f.Op {
+	case storer.OpEq:
+		return fmt.Sprintf("%s = %s", col, q.param(f.Value))
+	case storer.OpIn:
+		if col == storer.FieldCreatedAt {
+			return fmt.Sprintf("%s = ANY(%s::timestamptz[])", col, q.param(pq.Array(timeStrings(f.Values))))
+		}
+		values := make([]string, 0, len(f.Values))
+		for _, v := range f.Values {
+			values = append(values, fmt.Sprint(v))
+		}
+		return fmt.Sprintf("%s = ANY(%s::text[])", col, q.param(pq.Array(values)))
+	case storer.OpRange:
+		clauses := []string{}
+		if f.Min != nil {
+			clauses = append(clauses, fmt.Sprintf("%s >= %s", col, q.param(f.Min)))
+		}
+		if f.Max != nil {
+			clauses = append(clauses, fmt.Sprintf("%s <= %s", col, q.param(f.Max)))
+		}
+		return "(" + strings.Join(clauses, " AND ") + ")"
+	case storer.OpExists:
+		if col == storer.FieldSessionId || col == storer.FieldEmbeddingModel {
+			return fmt.Sprintf("%s <> ''", col)
+		}
+		return fmt.Sprintf("%s IS NOT NULL", col)
+	}
+
+	return "FALSE"
+}
+
+func (q *filterQuery) metadata(f *storer.Filter) (string, error) {
+	value := fmt.Sprintf("(metadata -> %s::text)", q.param(f.Field))
+
+	switch f.Op {
+	case storer.OpEq:
+		bs, err := json.Marshal(jsonValue(f.Value))
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s = %s::jsonb", value, q.param(string(bs))), nil
+	case storer.OpIn:
+		values := make([]string, 0, len(f.Values))
+		for _, v := range f.Values {
+			bs, err := json.Marshal(jsonValue(v))
+			if err != nil {
+				return "", err
+			}
+			values = append(values, string(bs))
+		}
+		return fmt.Sprintf("%s = ANY(%s::jsonb[])", value, q.param(pq.Array(values))), nil
+	case storer.OpRange:
+		clauses := []string{}
+		for _, bound := range []struct {
+			v  any
+			op string
+		}{{f.Min, ">="}, {f.Max, "<="}} {
+			if bound.v == nil {
+				continue
+			}
+			// only numbers are cast so a stray string never breaks the query
+			if n, ok := storer.ToFloat(bound.v); ok {
+				clauses = append(clauses, fmt.Sprintf(
+					"CASE WHEN jsonb_typeof(%s) = 'number' THEN (%s #>> '{}')::numeric %s %s ELSE FALSE END",
+					value, value, bound.op, q.param(n),
+				))
+				continue
+			}
+			clauses = append(clauses, fmt.Sprintf(
+				"CASE WHEN jsonb_typeof(%s) = 'string' THEN (%s #>> '{}') %s %s ELSE FALSE END",
+				value, value, bound.op, q.param(fmt.Sprint(jsonValue(bound.v))),
+			))
+		}
+		return "(" + strings.Join(clauses, " AND ") + ")", nil
+	case storer.OpExists:
+		return fmt.Sprintf("coalesce(jsonb_typeof(%s), 'null') <> 'null'", value), nil
+	}
+
+	return "FALSE", nil
+}
+
+func jsonValue(v any) any {
+	if t, ok := v.(time.Time); ok {
+		return t.UTC().Format(time.RFC3339Nano)
+	}
+	return v
+}
+
+func timeStrings(values []any) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		out = append(out, fmt.Sprint(jsonValue(v)))
+	}
+	return out
+}
@@ -0,0 +1,22 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+)
+
+type autoMigrateKey struct{}
+
+// WithAutoMigrate applies the embedded schema migrations when the storer is
+// created instead of leaving them to an explicit Migrate call
+func WithAutoMigrate() storer.Option {
+	return func(o *storer.Options) {
+		o.Context = context.WithValue(o.Context, autoMigrateKey{}, true)
+	}
+}
+
+func AutoMigrateFrom(ctx context.Context) bool {
+	enabled, _ := ctx.Value(autoMigrateKey{}).(bool)
+	return enabled
+}
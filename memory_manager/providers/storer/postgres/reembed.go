@@ -0,0 +1,160 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/pgvector/pgvector-go"
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+)
+
+func (p *postgresStorer) PendingEmbeddings(ctx context.Context, spaceId string, model string, limit int) ([]storer.Record, error) {
+	if limit < 1 {
+		return nil, nil
+	}
+
+	// oldest first so a resumed job walks the space in the same order
+	query := `
+		SELECT
+			id,
+			session_id,
+			content,
+			metadata,
+			embedding,
+			space_id,
+			created_at,
+			updated_at,
+			embedding_model
+		FROM messages
+		WHERE space_id = $1 AND embedding_model <> $2 AND staged_model IS DISTINCT FROM $2
+		ORDER BY created_at, id
+		LIMIT $3
+	`
+
+	rows, err := p.conn.QueryContext(ctx, query, spaceId, model, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []storer.Record
+
+	for rows.Next() {
+		var id int64
+		var rec storer.Record
+		var metaBytes []byte
+		var vec pgvector.Vector
+
+		err := rows.Scan(
+			&id,
+			&rec.SessionId,
+			&rec.Content,
+			&metaBytes,
+			&vec,
+			&rec.SpaceId,
+			&rec.CreatedAt,
+			&rec.UpdatedAt,
+			&rec.EmbeddingModel,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		rec.Id = strconv.FormatInt(id, 10)
+		rec.Embedding = vec.Slice()
+
+		if err := json.Unmarshal(metaBytes, &rec.Metadata); err != nil {
+			rec.Metadata = make(map[string]any)
+		}
+
+		records = append(records, rec)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+func (p *postgresStorer) StageEmbeddings(ctx context.Context, model string, vectors map[string][]float32) error {
+	tx, err := p.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `UPDATE messages SET staged_embedding = $1, staged_model = $2 WHERE id = $3`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for id, vector := range vectors {
+		if _, err := stmt.ExecContext(ctx, pgvector.NewVector(vector), model, id); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (p *postgresStorer) PromoteEmbeddings(ctx context.Context, spaceId string, model string) (int, error) {
+	rows, err := p.conn.QueryContext(
+		ctx,
+		`SELECT DISTINCT vector_dims(staged_embedding) FROM messages WHERE space_id = $1 AND staged_model = $2`,
+		spaceId, model,
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var sizes []int
+	for rows.Next() {
+		var size int
+		if err := rows.Scan(&size); err != nil {
+			return 0, err
+		}
+		sizes = append(sizes, size)
+	}
+
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	// a model of another size needs its index before searches move to it
+	for _, size := range sizes {
+		if err := p.createIndexes(ctx, size); err != nil {
+			return 0, err
+		}
+	}
+
+	query := `
+		UPDATE messages
+		SET embedding = staged_embedding,
+			embedding_model = staged_model,
+			staged_embedding = NULL,
+			staged_model = NULL,
+			updated_at = NOW()
+		WHERE space_id = $1 AND staged_model = $2
+	`
+
+	return p.exec(ctx, query, spaceId, model)
+}
+
+func (p *postgresStorer) OtherEmbeddingModel(ctx context.Context, spaceId string, model string) (string, error) {
+	var other string
+	err := p.conn.QueryRowContext(
+		ctx,
+		`SELECT embedding_model FROM messages WHERE space_id = $1 AND embedding_model NOT IN ($2, '') LIMIT 1`,
+		spaceId, model,
+	).Scan(&other)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return other, err
+}
@@ -8,8 +8,9 @@ import (
 	"log/slog"
 	"strconv"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/pgvector/pgvector-go"
+	"github.com/w-h-a/agent/db"
 	"github.com/w-h-a/agent/memory_manager/providers/storer"
 	"go.nhat.io/otelsql"
 	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
@@ -17,6 +18,9 @@ import (
 
 var DRIVER string
 
+// the width of the original schema, indexed when no vector size is given
+const defaultDimension = 1536
+
 func init() {
 	driver, err := otelsql.Register(
 		"postgres",
@@ -39,12 +43,17 @@ type postgresStorer struct {
 	conn    *sql.DB
 }
 
-func (p *postgresStorer) Store(ctx context.Context, spaceId, sessionId string, content string, metadata map[string]any, vector []float32) error {
+func (p *postgresStorer) Store(ctx context.Context, spaceId, sessionId string, content string, metadata map[string]any, vector []float32) (string, error) {
+	if err := storer.CheckDimension(p.options.VectorSize, vector); err != nil {
+		return "", err
+	}
+
 	edges := storer.SanitizeEdges(metadata)
+	model := storer.TakeEmbeddingModel(metadata)
 
 	metaJSON, err := json.Marshal(metadata)
 	if err != nil {
-		return fmt.Errorf("marshal metadata: %w", err)
+		return "", fmt.Errorf("marshal metadata: %w", err)
 	}
 
 	query := `
@@ -53,9 +62,10 @@ func (p *postgresStorer) Store(ctx context.Context, spaceId, sessionId string, c
 			content, 
 			metadata, 
 			embedding,
-			space_id
+			space_id,
+			embedding_model
 		)
-		VALUES ($1, $2, $3, $4, $5)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id
 	`
 
@@ -68,20 +78,21 @@ func (p *postgresStorer) Store(ctx context.Context, spaceId, sessionId string, c
 		metaJSON,
 		pgvector.NewVector(vector),
 		spaceId,
+		model,
 	).Scan(&id); err != nil {
-		return err
+		return "", err
 	}
 
 	idstr := strconv.FormatInt(id, 10)
 
 	if len(edges) == 0 {
-		return nil
+		return idstr, nil
 	}
 	if err := p.addEdges(ctx, idstr, edges); err != nil {
-		return err
+		return "", err
 	}
 
-	return nil
+	return idstr, nil
 }
 
 func (p *postgresStorer) addEdges(ctx context.Context, id string, edges []map[string]string) error {
@@ -106,11 +117,28 @@ func (p *postgresStorer) addEdges(ctx context.Context, id string, edges []map[st
 	return tx.Commit()
 }
 
-func (p *postgresStorer) Search(ctx context.Context, spaceId string, vector []float32, limit int) ([]storer.Record, error) {
+func (p *postgresStorer) Search(ctx context.Context, spaceId string, vector []float32, limit int, filter *storer.Filter) ([]storer.Record, error) {
 	if limit < 1 {
 		return nil, nil
 	}
 
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	filter = storer.Unexpired(p.options.TTL, filter)
+
+	if err := storer.CheckDimension(p.options.VectorSize, vector); err != nil {
+		return nil, err
+	}
+
+	q := &filterQuery{args: []any{spaceId, pgvector.NewVector(vector), limit}}
+
+	where, err := q.where(filter)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
 		SELECT 
 			id, 
@@ -118,17 +146,18 @@ func (p *postgresStorer) Search(ctx context.Context, spaceId string, vector []fl
 			content, 
 			metadata,
 			embedding, 
-			1 - (embedding <=> $2) as score,
+			1 - (` + castEmbedding(len(vector)) + ` <=> $2) as score,
 			space_id,
 			created_at, 
-			updated_at
+			updated_at,
+			embedding_model
 		FROM messages
-		WHERE space_id = $1
-		ORDER BY embedding <=> $2
+		WHERE space_id = $1 AND ` + sized(len(vector)) + ` AND ` + where + `
+		ORDER BY ` + castEmbedding(len(vector)) + ` <=> $2
 		LIMIT $3
 	`
 
-	rows, err := p.conn.QueryContext(ctx, query, spaceId, pgvector.NewVector(vector), limit)
+	rows, err := p.conn.QueryContext(ctx, query, q.args...)
 	if err != nil {
 		return nil, err
 	}
@@ -140,23 +169,26 @@ func (p *postgresStorer) Search(ctx context.Context, spaceId string, vector []fl
 		var id int64
 		var rec storer.Record
 		var metaBytes []byte
+		var vec pgvector.Vector
 
 		err := rows.Scan(
 			&id,
 			&rec.SessionId,
 			&rec.Content,
 			&metaBytes,
-			&rec.Embedding,
+			&vec,
 			&rec.Score,
 			&rec.SpaceId,
 			&rec.CreatedAt,
 			&rec.UpdatedAt,
+			&rec.EmbeddingModel,
 		)
 		if err != nil {
 			return nil, err
 		}
 
 		rec.Id = strconv.FormatInt(id, 10)
+		rec.Embedding = vec.Slice()
 
 		if err := json.Unmarshal(metaBytes, &rec.Metadata); err != nil {
 			rec.Metadata = make(map[string]any)
@@ -172,31 +204,45 @@ func (p *postgresStorer) Search(ctx context.Context, spaceId string, vector []fl
 	return records, nil
 }
 
-func (p *postgresStorer) SearchNeighborhood(ctx context.Context, seedIds []string, hops int, limit int) ([]storer.Record, error) {
+func (p *postgresStorer) SearchNeighborhood(ctx context.Context, seedIds []string, hops int, limit int, filter *storer.Filter) ([]storer.Record, error) {
 	if limit < 1 {
 		return nil, nil
 	}
 
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	filter = storer.Unexpired(p.options.TTL, filter)
+
+	q := &filterQuery{args: []any{pq.Array(seedIds), hops, limit}}
+
+	where, err := q.where(filter)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
     WITH RECURSIVE graph_walk AS (
-        SELECT id, session_id, content, metadata, embedding, space_id, created_at, updated_at, 0 as depth
+        SELECT id, session_id, content, metadata, embedding, space_id, created_at, updated_at, embedding_model, 0 as depth
         FROM messages
         WHERE id = ANY($1::bigint[])
         
         UNION
         
-        SELECT m.id, m.session_id, m.content, m.metadata, m.embedding, m.space_id, m.created_at, m.updated_at, gw.depth + 1
+        SELECT m.id, m.session_id, m.content, m.metadata, m.embedding, m.space_id, m.created_at, m.updated_at, m.embedding_model, gw.depth + 1
         FROM messages m
         INNER JOIN message_edges e ON e.target_id = m.id
         INNER JOIN graph_walk gw ON gw.id = e.source_id
         WHERE gw.depth < $2
     )
-    SELECT DISTINCT ON (id) id, session_id, content, metadata, embedding, 0 as score, space_id, created_at, updated_at 
+    SELECT DISTINCT ON (id) id, session_id, content, metadata, embedding, 0 as score, space_id, created_at, updated_at, embedding_model 
     FROM graph_walk
+    WHERE NOT id = ANY($1::bigint[]) AND ` + where + `
     LIMIT $3;
     `
 
-	rows, err := p.conn.QueryContext(ctx, query, seedIds, hops, limit)
+	rows, err := p.conn.QueryContext(ctx, query, q.args...)
 	if err != nil {
 		return nil, err
 	}
@@ -207,23 +253,26 @@ func (p *postgresStorer) SearchNeighborhood(ctx context.Context, seedIds []strin
 		var id int64
 		var rec storer.Record
 		var metaBytes []byte
+		var vec pgvector.Vector
 
 		err := rows.Scan(
 			&id,
 			&rec.SessionId,
 			&rec.Content,
 			&metaBytes,
-			&rec.Embedding,
+			&vec,
 			&rec.Score,
 			&rec.SpaceId,
 			&rec.CreatedAt,
 			&rec.UpdatedAt,
+			&rec.EmbeddingModel,
 		)
 		if err != nil {
 			return nil, err
 		}
 
 		rec.Id = strconv.FormatInt(id, 10)
+		rec.Embedding = vec.Slice()
 
 		if err := json.Unmarshal(metaBytes, &rec.Metadata); err != nil {
 			rec.Metadata = make(map[string]any)
@@ -240,6 +289,236 @@ func (p *postgresStorer) SearchNeighborhood(ctx context.Context, seedIds []strin
 
 }
 
+func (p *postgresStorer) SearchLexical(ctx context.Context, spaceId string, query string, limit int, filter *storer.Filter) ([]storer.Record, error) {
+	tokens := storer.Tokenize(query)
+	if limit < 1 || !p.options.Lexical || len(tokens) == 0 {
+		return nil, nil
+	}
+
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	filter = storer.Unexpired(p.options.TTL, filter)
+
+	q := &filterQuery{args: []any{spaceId, pq.Array(tokens), limit}}
+
+	where, err := q.where(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// any token may match so each is parsed on its own and or-ed together
+	sqlQuery := `
+		WITH q AS (
+			SELECT string_agg(plainto_tsquery('simple', t)::text, ' | ')::tsquery AS query
+			FROM unnest($2::text[]) AS t
+			WHERE plainto_tsquery('simple', t)::text <> ''
+		)
+		SELECT 
+			id, 
+			session_id, 
+			content, 
+			metadata,
+			embedding, 
+			ts_rank_cd(content_tsv, q.query) as score,
+			space_id,
+			created_at, 
+			updated_at,
+			embedding_model
+		FROM messages, q
+		WHERE space_id = $1 AND content_tsv @@ q.query AND ` + where + `
+		ORDER BY score DESC
+		LIMIT $3
+	`
+
+	rows, err := p.conn.QueryContext(ctx, sqlQuery, q.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []storer.Record
+
+	for rows.Next() {
+		var id int64
+		var rec storer.Record
+		var metaBytes []byte
+		var vec pgvector.Vector
+
+		err := rows.Scan(
+			&id,
+			&rec.SessionId,
+			&rec.Content,
+			&metaBytes,
+			&vec,
+			&rec.Score,
+			&rec.SpaceId,
+			&rec.CreatedAt,
+			&rec.UpdatedAt,
+			&rec.EmbeddingModel,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		rec.Id = strconv.FormatInt(id, 10)
+		rec.Embedding = vec.Slice()
+
+		if err := json.Unmarshal(metaBytes, &rec.Metadata); err != nil {
+			rec.Metadata = make(map[string]any)
+		}
+
+		records = append(records, rec)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+func (p *postgresStorer) Delete(ctx context.Context, ids []string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	// message_edges rows go with their endpoints via ON DELETE CASCADE
+	return p.exec(ctx, `DELETE FROM messages WHERE id = ANY($1::bigint[])`, pq.Array(ids))
+}
+
+func (p *postgresStorer) DeleteBySession(ctx context.Context, sessionId string) (int, error) {
+	return p.exec(ctx, `DELETE FROM messages WHERE session_id = $1`, sessionId)
+}
+
+func (p *postgresStorer) DeleteBySpace(ctx context.Context, spaceId string) (int, error) {
+	tx, err := p.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM messages WHERE space_id = $1`, spaceId)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM entities WHERE space_id = $1`, spaceId); err != nil {
+		return 0, err
+	}
+
+	migrated, err := p.hasTable(ctx, "skills")
+	if err != nil {
+		return 0, err
+	}
+
+	if migrated {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM skills WHERE space_id = $1`, spaceId); err != nil {
+			return 0, err
+		}
+
+		// chunks go with their files
+		if _, err := tx.ExecContext(ctx, `DELETE FROM files WHERE space_id = $1`, spaceId); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n), nil
+}
+
+func (p *postgresStorer) DeleteExpired(ctx context.Context) (int, error) {
+	cutoff, ok := storer.ExpiredBefore(p.options.TTL)
+	if !ok {
+		return 0, nil
+	}
+
+	return p.exec(ctx, `DELETE FROM messages WHERE created_at < $1`, cutoff)
+}
+
+// Migrate brings the schema up to date with the migrations embedded in the
+// db package and is safe to call from several processes at once
+func (p *postgresStorer) Migrate(ctx context.Context) error {
+	return db.Migrate(ctx, p.conn)
+}
+
+func (p *postgresStorer) hasTable(ctx context.Context, name string) (bool, error) {
+	var exists bool
+	err := p.conn.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.tables
+			WHERE table_schema = current_schema() AND table_name = $1
+		)`, name).Scan(&exists)
+	return exists, err
+}
+
+// createIndexes builds the partial index for one size. The column takes
+// vectors of any size so each size in use gets its own. Tables a database
+// has not been migrated to yet are skipped.
+func (p *postgresStorer) createIndexes(ctx context.Context, size int) error {
+	for _, table := range []struct {
+		name   string
+		prefix string
+	}{{"messages", "memory"}, {"entities", "entity"}} {
+		var exists bool
+		if err := p.conn.QueryRowContext(ctx, `
+			SELECT EXISTS (
+				SELECT 1 FROM information_schema.columns
+				WHERE table_schema = current_schema() AND table_name = $1 AND column_name = 'embedding'
+			)`, table.name).Scan(&exists); err != nil {
+			return err
+		}
+
+		if !exists {
+			slog.WarnContext(ctx, "skipping vector index for unmigrated table", "table", table.name)
+			continue
+		}
+
+		query := fmt.Sprintf(
+			`CREATE INDEX IF NOT EXISTS %s_embedding_%d_idx ON %s USING hnsw ((%s) vector_cosine_ops) WHERE %s`,
+			table.prefix, size, table.name, castEmbedding(size), sized(size),
+		)
+		if _, err := p.conn.ExecContext(ctx, query); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// castEmbedding is the expression the partial index for size is built on
+// so queries must use it to reach the index
+func castEmbedding(size int) string {
+	return fmt.Sprintf("embedding::vector(%d)", size)
+}
+
+func sized(size int) string {
+	return fmt.Sprintf("vector_dims(embedding) = %d", size)
+}
+
+func (p *postgresStorer) exec(ctx context.Context, query string, args ...any) (int, error) {
+	res, err := p.conn.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n), nil
+}
+
 func NewStorer(opts ...storer.Option) storer.Storer {
 	options := storer.NewOptions(opts...)
 
@@ -269,5 +548,24 @@ func NewStorer(opts ...storer.Option) storer.Storer {
 
 	p.conn = conn
 
+	if AutoMigrateFrom(options.Context) {
+		if err := p.Migrate(context.Background()); err != nil {
+			detail := "failed to migrate postgres storer schema"
+			slog.ErrorContext(context.Background(), detail, "error", err)
+			panic(detail)
+		}
+	}
+
+	size := defaultDimension
+	if options.VectorSize > 0 {
+		size = int(options.VectorSize)
+	}
+
+	if err := p.createIndexes(context.Background(), size); err != nil {
+		detail := "failed to create vector indexes for postgres storer"
+		slog.ErrorContext(context.Background(), detail, "error", err)
+		panic(detail)
+	}
+
 	return p
 }
@@ -42,5 +42,42 @@ type qdrantPointResult struct {
 	Id      string         `json:"id"`
 	Score   float64        `json:"score"`
 	Payload map[string]any `json:"payload"`
-	Vector  []float32      `json:"vector"`
+	Vector  qdrantVector   `json:"vector"`
+}
+
+// collections with a sparse index return every vector keyed by name
+type qdrantVector []float32
+
+func (v *qdrantVector) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '{' {
+		var named map[string]json.RawMessage
+		if err := json.Unmarshal(b, &named); err != nil {
+			return err
+		}
+		dense, ok := named[""]
+		if !ok {
+			*v = nil
+			return nil
+		}
+		b = dense
+	}
+
+	var dense []float32
+	if err := json.Unmarshal(b, &dense); err != nil {
+		return err
+	}
+
+	*v = dense
+
+	return nil
+}
+
+type qdrantSparseVector struct {
+	Indices []uint32  `json:"indices"`
+	Values  []float32 `json:"values"`
+}
+
+type qdrantScrollResult struct {
+	Points         []qdrantPointResult `json:"points"`
+	NextPageOffset any                 `json:"next_page_offset"`
 }
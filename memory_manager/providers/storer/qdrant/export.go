@@ -0,0 +1,150 @@
+package qdrant
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+	getsafe "github.com/w-h-a/agent/util/get_safe"
+)
+
+func (s *qdrantStorer) Export(ctx context.Context, spaceId string, w storer.ItemWriter) error {
+	var offset any
+
+	path := fmt.Sprintf("/collections/%s/points/scroll", url.PathEscape(s.options.Collection))
+
+	// one page at a time so large spaces never sit in memory
+	for {
+		req := map[string]any{
+			"filter": map[string]any{
+				"must": []map[string]any{
+					{
+						"key":   "space_id",
+						"match": map[string]any{"value": spaceId},
+					},
+				},
+			},
+			"limit":        256,
+			"with_payload": true,
+			"with_vector":  true,
+		}
+		if offset != nil {
+			req["offset"] = offset
+		}
+
+		var rsp qdrantEnvelope[qdrantScrollResult]
+
+		if err := s.do(ctx, http.MethodPost, path, req, &rsp); err != nil {
+			return err
+		}
+
+		for _, point := range rsp.Result.Points {
+			rec := s.mapToStorerRecord(point)
+			if err := w.Write(storer.Item{Kind: storer.KindRecord, Record: &rec}); err != nil {
+				return err
+			}
+		}
+
+		if rsp.Result.NextPageOffset == nil {
+			return nil
+		}
+
+		offset = rsp.Result.NextPageOffset
+	}
+}
+
+func (s *qdrantStorer) Import(ctx context.Context, r storer.ItemReader) (storer.ImportStats, error) {
+	return storer.ImportItems(ctx, r, storer.ImportTarget{
+		InsertRecord: s.insert,
+		AddEdges:     s.addEdges,
+	})
+}
+
+func (s *qdrantStorer) insert(ctx context.Context, rec storer.Record) (string, error) {
+	id := uuid.New().String()
+
+	createdAt := rec.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	payload := map[string]any{
+		"session_id": rec.SessionId,
+		"content":    rec.Content,
+		"metadata":   rec.Metadata,
+		"space_id":   rec.SpaceId,
+		"created_at": createdAt.UTC().Format(time.RFC3339Nano),
+	}
+
+	if len(rec.EmbeddingModel) > 0 {
+		payload[storer.FieldEmbeddingModel] = rec.EmbeddingModel
+	}
+
+	point := map[string]any{
+		"id":      id,
+		"vector":  rec.Embedding,
+		"payload": payload,
+	}
+
+	if s.options.Lexical {
+		point["vector"] = map[string]any{
+			"":               rec.Embedding,
+			sparseVectorName: sparseVector(rec.Content),
+		}
+	}
+
+	req := map[string]any{
+		"points": []map[string]any{point},
+	}
+
+	var rsp qdrantEnvelope[json.RawMessage]
+
+	path := fmt.Sprintf("/collections/%s/points?wait=true", url.PathEscape(s.options.Collection))
+
+	if err := s.do(ctx, http.MethodPut, path, req, &rsp); err != nil {
+		return "", err
+	}
+
+	if !strings.EqualFold(rsp.Status.State, "ok") && len(rsp.Status.Error) > 0 {
+		return "", errors.New(rsp.Status.Error)
+	}
+
+	return id, nil
+}
+
+func (s *qdrantStorer) addEdges(ctx context.Context, sourceId string, edges []map[string]string) error {
+	points, err := s.retrievePoints(ctx, []string{sourceId})
+	if err != nil {
+		return err
+	}
+
+	if len(points) == 0 {
+		return nil
+	}
+
+	metadata := maps.Clone(getsafe.Metadata(points[0].Payload, "metadata"))
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+
+	storer.AddEdges(metadata, edges)
+
+	req := map[string]any{
+		"payload": map[string]any{"metadata": metadata},
+		"points":  []string{sourceId},
+	}
+
+	var rsp qdrantEnvelope[json.RawMessage]
+
+	path := fmt.Sprintf("/collections/%s/points/payload?wait=true", url.PathEscape(s.options.Collection))
+
+	return s.do(ctx, http.MethodPost, path, req, &rsp)
+}
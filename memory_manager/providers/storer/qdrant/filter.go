@@ -0,0 +1,108 @@
+package qdrant
+
+import (
+	"time"
+
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+)
+
+func translateFilter(f *storer.Filter) map[string]any {
+	switch f.Op {
+	case storer.OpAnd:
+		return map[string]any{"must": translateFilters(f.Filters)}
+	case storer.OpOr:
+		return map[string]any{"should": translateFilters(f.Filters)}
+	}
+
+	if f.Field == storer.FieldEmbeddingModel && (f.Op == storer.OpEq || f.Op == storer.OpIn) {
+		return modelCondition(f)
+	}
+
+	key := filterKey(f.Field)
+
+	switch f.Op {
+	case storer.OpEq:
+		if t, ok := f.Value.(time.Time); ok {
+			return map[string]any{"key": key, "range": map[string]any{"gte": formatTime(t), "lte": formatTime(t)}}
+		}
+		return map[string]any{"key": key, "match": map[string]any{"value": f.Value}}
+	case storer.OpIn:
+		return map[string]any{"key": key, "match": map[string]any{"any": f.Values}}
+	case storer.OpRange:
+		bounds := map[string]any{}
+		if f.Min != nil {
+			bounds["gte"] = rangeValue(f.Min)
+		}
+		if f.Max != nil {
+			bounds["lte"] = rangeValue(f.Max)
+		}
+		return map[string]any{"key": key, "range": bounds}
+	case storer.OpExists:
+		return map[string]any{
+			"must_not": []map[string]any{
+				{"is_empty": map[string]any{"key": key}},
+			},
+		}
+	}
+
+	return map[string]any{}
+}
+
+// modelCondition matches untracked points, which have no model key at all,
+// when the filter asks for the empty model
+func modelCondition(f *storer.Filter) map[string]any {
+	key := storer.FieldEmbeddingModel
+
+	values := f.Values
+	if f.Op == storer.OpEq {
+		values = []any{f.Value}
+	}
+
+	models := []any{}
+	untracked := false
+	for _, v := range values {
+		if model, ok := v.(string); ok && len(model) == 0 {
+			untracked = true
+			continue
+		}
+		models = append(models, v)
+	}
+
+	should := []map[string]any{}
+	if len(models) > 0 {
+		should = append(should, map[string]any{"key": key, "match": map[string]any{"any": models}})
+	}
+	if untracked {
+		should = append(should, map[string]any{"is_empty": map[string]any{"key": key}})
+	}
+
+	return map[string]any{"should": should}
+}
+
+func translateFilters(filters []*storer.Filter) []map[string]any {
+	conditions := make([]map[string]any, 0, len(filters))
+	for _, f := range filters {
+		conditions = append(conditions, translateFilter(f))
+	}
+	return conditions
+}
+
+func filterKey(field string) string {
+	switch field {
+	case storer.FieldSessionId, storer.FieldCreatedAt, storer.FieldEmbeddingModel:
+		return field
+	}
+	return "metadata." + field
+}
+
+func rangeValue(v any) any {
+	// qdrant compares RFC 3339 strings as datetimes
+	if t, ok := v.(time.Time); ok {
+		return formatTime(t)
+	}
+	return v
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,88 @@
+package qdrant
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"net/url"
+
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+)
+
+const sparseVectorName = "text"
+
+func (s *qdrantStorer) SearchLexical(ctx context.Context, spaceId string, query string, limit int, filter *storer.Filter) ([]storer.Record, error) {
+	sparse := sparseVector(query)
+	if limit < 1 || !s.options.Lexical || len(sparse.Indices) == 0 {
+		return nil, nil
+	}
+
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	filter = storer.Unexpired(s.options.TTL, filter)
+
+	must := []map[string]any{
+		{
+			"key":   "space_id",
+			"match": map[string]any{"value": spaceId},
+		},
+	}
+
+	if filter != nil {
+		must = append(must, translateFilter(filter))
+	}
+
+	req := map[string]any{
+		"vector": map[string]any{
+			"name":   sparseVectorName,
+			"vector": sparse,
+		},
+		"limit":        limit,
+		"with_vector":  true,
+		"with_payload": true,
+		"filter": map[string]any{
+			"must": must,
+		},
+	}
+
+	var rsp qdrantEnvelope[[]qdrantPointResult]
+
+	path := fmt.Sprintf("/collections/%s/points/search", url.PathEscape(s.options.Collection))
+
+	if err := s.do(ctx, http.MethodPost, path, req, &rsp); err != nil {
+		return nil, err
+	}
+
+	records := make([]storer.Record, 0, len(rsp.Result))
+
+	for _, point := range rsp.Result {
+		records = append(records, s.mapToStorerRecord(point))
+	}
+
+	return records, nil
+}
+
+// sparseVector hashes tokens into term frequencies and leaves the idf weighting to qdrant
+func sparseVector(text string) qdrantSparseVector {
+	counts := map[uint32]float32{}
+	for _, token := range storer.Tokenize(text) {
+		h := fnv.New32a()
+		h.Write([]byte(token))
+		counts[h.Sum32()]++
+	}
+
+	sparse := qdrantSparseVector{
+		Indices: make([]uint32, 0, len(counts)),
+		Values:  make([]float32, 0, len(counts)),
+	}
+
+	for idx, count := range counts {
+		sparse.Indices = append(sparse.Indices, idx)
+		sparse.Values = append(sparse.Values, count)
+	}
+
+	return sparse
+}
@@ -0,0 +1,185 @@
+package qdrant
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+	getsafe "github.com/w-h-a/agent/util/get_safe"
+)
+
+const (
+	stagedModelKey     = "staged_model"
+	stagedEmbeddingKey = "staged_embedding"
+)
+
+func (s *qdrantStorer) PendingEmbeddings(ctx context.Context, spaceId string, model string, limit int) ([]storer.Record, error) {
+	if limit < 1 {
+		return nil, nil
+	}
+
+	req := map[string]any{
+		"filter": map[string]any{
+			"must": []map[string]any{
+				{
+					"key":   "space_id",
+					"match": map[string]any{"value": spaceId},
+				},
+			},
+			"must_not": []map[string]any{
+				{
+					"key":   storer.FieldEmbeddingModel,
+					"match": map[string]any{"value": model},
+				},
+				{
+					"key":   stagedModelKey,
+					"match": map[string]any{"value": model},
+				},
+			},
+		},
+		"limit":        limit,
+		"with_payload": true,
+		"with_vector":  true,
+	}
+
+	var rsp qdrantEnvelope[qdrantScrollResult]
+
+	path := fmt.Sprintf("/collections/%s/points/scroll", url.PathEscape(s.options.Collection))
+
+	if err := s.do(ctx, http.MethodPost, path, req, &rsp); err != nil {
+		return nil, err
+	}
+
+	records := make([]storer.Record, 0, len(rsp.Result.Points))
+	for _, point := range rsp.Result.Points {
+		records = append(records, s.mapToStorerRecord(point))
+	}
+
+	return records, nil
+}
+
+func (s *qdrantStorer) StageEmbeddings(ctx context.Context, model string, vectors map[string][]float32) error {
+	path := fmt.Sprintf("/collections/%s/points/payload?wait=true", url.PathEscape(s.options.Collection))
+
+	// the collection holds one dense vector per point so the staged one waits in the payload
+	for id, vector := range vectors {
+		req := map[string]any{
+			"payload": map[string]any{
+				stagedModelKey:     model,
+				stagedEmbeddingKey: vector,
+			},
+			"points": []string{id},
+		}
+
+		var rsp qdrantEnvelope[json.RawMessage]
+
+		if err := s.do(ctx, http.MethodPost, path, req, &rsp); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *qdrantStorer) PromoteEmbeddings(ctx context.Context, spaceId string, model string) (int, error) {
+	filter := map[string]any{
+		"must": []map[string]any{
+			{
+				"key":   "space_id",
+				"match": map[string]any{"value": spaceId},
+			},
+			{
+				"key":   stagedModelKey,
+				"match": map[string]any{"value": model},
+			},
+		},
+	}
+
+	points, err := s.scroll(ctx, filter, true)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(points) == 0 {
+		return 0, nil
+	}
+
+	updates := make([]map[string]any, 0, len(points))
+	for _, point := range points {
+		vector := getsafe.Float32s(point.Payload, stagedEmbeddingKey)
+		if err := storer.CheckDimension(s.options.VectorSize, vector); err != nil {
+			return 0, err
+		}
+		update := map[string]any{"id": point.Id, "vector": vector}
+		if s.options.Lexical {
+			update["vector"] = map[string]any{"": vector}
+		}
+		updates = append(updates, update)
+	}
+
+	base := fmt.Sprintf("/collections/%s/points", url.PathEscape(s.options.Collection))
+
+	var rsp qdrantEnvelope[json.RawMessage]
+
+	if err := s.do(ctx, http.MethodPut, base+"/vectors?wait=true", map[string]any{"points": updates}, &rsp); err != nil {
+		return 0, err
+	}
+
+	if err := s.do(ctx, http.MethodPost, base+"/payload?wait=true", map[string]any{
+		"payload": map[string]any{storer.FieldEmbeddingModel: model},
+		"filter":  filter,
+	}, &rsp); err != nil {
+		return 0, err
+	}
+
+	if err := s.do(ctx, http.MethodPost, base+"/payload/delete?wait=true", map[string]any{
+		"keys":   []string{stagedModelKey, stagedEmbeddingKey},
+		"filter": filter,
+	}, &rsp); err != nil {
+		return 0, err
+	}
+
+	return len(points), nil
+}
+
+func (s *qdrantStorer) OtherEmbeddingModel(ctx context.Context, spaceId string, model string) (string, error) {
+	req := map[string]any{
+		"filter": map[string]any{
+			"must": []map[string]any{
+				{
+					"key":   "space_id",
+					"match": map[string]any{"value": spaceId},
+				},
+			},
+			"must_not": []map[string]any{
+				{
+					"key":   storer.FieldEmbeddingModel,
+					"match": map[string]any{"any": []string{model, ""}},
+				},
+				{
+					"is_empty": map[string]any{"key": storer.FieldEmbeddingModel},
+				},
+			},
+		},
+		"limit":        1,
+		"with_payload": []string{storer.FieldEmbeddingModel},
+		"with_vector":  false,
+	}
+
+	var rsp qdrantEnvelope[qdrantScrollResult]
+
+	path := fmt.Sprintf("/collections/%s/points/scroll", url.PathEscape(s.options.Collection))
+
+	if err := s.do(ctx, http.MethodPost, path, req, &rsp); err != nil {
+		return "", err
+	}
+
+	if len(rsp.Result.Points) == 0 {
+		return "", nil
+	}
+
+	return getsafe.String(rsp.Result.Points[0].Payload, storer.FieldEmbeddingModel), nil
+}
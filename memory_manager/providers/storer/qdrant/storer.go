@@ -18,13 +18,24 @@ import (
 	getsafe "github.com/w-h-a/agent/util/get_safe"
 )
 
+const (
+	// keeps id lists in a single request well under qdrant's body limit
+	maxPointsPerRequest = 256
+	edgeTargetKey       = "metadata.edges[].target"
+)
+
 type qdrantStorer struct {
 	options storer.Options
 	client  *http.Client
 }
 
-func (s *qdrantStorer) Store(ctx context.Context, spaceId string, sessionId string, content string, metadata map[string]any, vector []float32) error {
+func (s *qdrantStorer) Store(ctx context.Context, spaceId string, sessionId string, content string, metadata map[string]any, vector []float32) (string, error) {
+	if err := storer.CheckDimension(s.options.VectorSize, vector); err != nil {
+		return "", err
+	}
+
 	storer.SanitizeEdges(metadata)
+	model := storer.TakeEmbeddingModel(metadata)
 
 	id := uuid.New().String()
 
@@ -36,12 +47,24 @@ func (s *qdrantStorer) Store(ctx context.Context, spaceId string, sessionId stri
 		"created_at": time.Now().UTC().Format(time.RFC3339Nano),
 	}
 
+	// left off when empty so untracked points match the same way as older ones
+	if len(model) > 0 {
+		payload[storer.FieldEmbeddingModel] = model
+	}
+
 	point := map[string]any{
 		"id":      id,
 		"vector":  vector,
 		"payload": payload,
 	}
 
+	if s.options.Lexical {
+		point["vector"] = map[string]any{
+			"":               vector,
+			sparseVectorName: sparseVector(content),
+		}
+	}
+
 	req := map[string]any{
 		"points": []map[string]any{point},
 	}
@@ -51,33 +74,49 @@ func (s *qdrantStorer) Store(ctx context.Context, spaceId string, sessionId stri
 	path := fmt.Sprintf("/collections/%s/points?wait=true", url.PathEscape(s.options.Collection))
 
 	if err := s.do(ctx, http.MethodPut, path, req, &rsp); err != nil {
-		return err
+		return "", err
 	}
 
 	if !strings.EqualFold(rsp.Status.State, "ok") && len(rsp.Status.Error) > 0 {
-		return errors.New(rsp.Status.Error)
+		return "", errors.New(rsp.Status.Error)
 	}
 
-	return nil
+	return id, nil
 }
 
-func (s *qdrantStorer) Search(ctx context.Context, spaceId string, vector []float32, limit int) ([]storer.Record, error) {
+func (s *qdrantStorer) Search(ctx context.Context, spaceId string, vector []float32, limit int, filter *storer.Filter) ([]storer.Record, error) {
 	if limit < 1 {
 		return nil, nil
 	}
 
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	filter = storer.Unexpired(s.options.TTL, filter)
+
+	if err := storer.CheckDimension(s.options.VectorSize, vector); err != nil {
+		return nil, err
+	}
+
+	must := []map[string]any{
+		{
+			"key":   "space_id",
+			"match": map[string]any{"value": spaceId},
+		},
+	}
+
+	if filter != nil {
+		must = append(must, translateFilter(filter))
+	}
+
 	req := map[string]any{
 		"vector":       vector,
 		"limit":        limit,
 		"with_vector":  true,
 		"with_payload": true,
 		"filter": map[string]any{
-			"must": []map[string]any{
-				{
-					"key":   "space_id",
-					"match": map[string]any{"value": spaceId},
-				},
-			},
+			"must": must,
 		},
 	}
 
@@ -99,15 +138,30 @@ func (s *qdrantStorer) Search(ctx context.Context, spaceId string, vector []floa
 	return records, nil
 }
 
-func (s *qdrantStorer) SearchNeighborhood(ctx context.Context, seedIds []string, hops int, limit int) ([]storer.Record, error) {
+func (s *qdrantStorer) SearchNeighborhood(ctx context.Context, seedIds []string, hops int, limit int, filter *storer.Filter) ([]storer.Record, error) {
 	if limit < 1 || len(seedIds) == 0 {
 		return nil, nil
 	}
 
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	filter = storer.Unexpired(s.options.TTL, filter)
+
 	visited := map[string]struct{}{}
+	seeds := map[string]struct{}{}
+	for _, id := range seedIds {
+		seeds[id] = struct{}{}
+	}
+
+	// the walk never leaves the spaces the seeds belong to
+	spaces := map[string]struct{}{}
+
 	var records []storer.Record
 
-	for range hops {
+	// the first pass only resolves the seeds' edges
+	for depth := range hops + 1 {
 		if len(seedIds) == 0 {
 			break
 		}
@@ -130,11 +184,24 @@ func (s *qdrantStorer) SearchNeighborhood(ctx context.Context, seedIds []string,
 		}
 
 		next := []string{}
+		reached := []string{}
 		for _, p := range points {
 			rec := s.mapToStorerRecord(p)
-			records = append(records, rec)
-			if len(records) >= limit {
-				return records, nil
+
+			if depth == 0 {
+				spaces[rec.SpaceId] = struct{}{}
+			} else if _, ok := spaces[rec.SpaceId]; !ok {
+				continue
+			}
+
+			reached = append(reached, rec.Id)
+
+			// filtered records are still walked through, just not returned
+			if _, isSeed := seeds[rec.Id]; !isSeed && filter.Matches(rec) {
+				records = append(records, rec)
+				if len(records) >= limit {
+					return records, nil
+				}
 			}
 
 			if rec.Metadata != nil {
@@ -149,32 +216,249 @@ func (s *qdrantStorer) SearchNeighborhood(ctx context.Context, seedIds []string,
 			}
 		}
 
+		// the last pass's neighbours would never be fetched
+		if depth < hops {
+			sources, err := s.edgeSources(ctx, spaces, reached)
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, sources...)
+		}
+
 		seedIds = next
 	}
 
 	return records, nil
 }
 
-func (s *qdrantStorer) retrievePoints(ctx context.Context, ids []string) ([]qdrantPointResult, error) {
-	if len(ids) == 0 {
+// edgeSources finds the points whose edges lead to any of targets so the walk
+// can follow edges backwards
+func (s *qdrantStorer) edgeSources(ctx context.Context, spaces map[string]struct{}, targets []string) ([]string, error) {
+	if len(spaces) == 0 || len(targets) == 0 {
 		return nil, nil
 	}
 
+	spaceIds := make([]string, 0, len(spaces))
+	for id := range spaces {
+		spaceIds = append(spaceIds, id)
+	}
+
+	var ids []string
+
+	for start := 0; start < len(targets); start += maxPointsPerRequest {
+		chunk := targets[start:min(start+maxPointsPerRequest, len(targets))]
+
+		points, err := s.scroll(ctx, map[string]any{
+			"must": []map[string]any{
+				{
+					"key":   "space_id",
+					"match": map[string]any{"any": spaceIds},
+				},
+				{
+					"key":   edgeTargetKey,
+					"match": map[string]any{"any": chunk},
+				},
+			},
+		}, false)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range points {
+			ids = append(ids, p.Id)
+		}
+	}
+
+	return ids, nil
+}
+
+func (s *qdrantStorer) Delete(ctx context.Context, ids []string) (int, error) {
+	points, err := s.retrievePoints(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	existing := make([]string, 0, len(points))
+	for _, p := range points {
+		existing = append(existing, p.Id)
+	}
+
+	return s.deletePoints(ctx, existing)
+}
+
+func (s *qdrantStorer) DeleteBySession(ctx context.Context, sessionId string) (int, error) {
+	return s.deleteWhere(ctx, map[string]any{
+		"must": []map[string]any{
+			{
+				"key":   "session_id",
+				"match": map[string]any{"value": sessionId},
+			},
+		},
+	})
+}
+
+func (s *qdrantStorer) DeleteBySpace(ctx context.Context, spaceId string) (int, error) {
+	return s.deleteWhere(ctx, map[string]any{
+		"must": []map[string]any{
+			{
+				"key":   "space_id",
+				"match": map[string]any{"value": spaceId},
+			},
+		},
+	})
+}
+
+func (s *qdrantStorer) DeleteExpired(ctx context.Context) (int, error) {
+	cutoff, ok := storer.ExpiredBefore(s.options.TTL)
+	if !ok {
+		return 0, nil
+	}
+
+	return s.deleteWhere(ctx, map[string]any{
+		"must": []map[string]any{
+			{
+				"key":   "created_at",
+				"range": map[string]any{"lt": cutoff.Format(time.RFC3339Nano)},
+			},
+		},
+	})
+}
+
+func (s *qdrantStorer) deleteWhere(ctx context.Context, filter map[string]any) (int, error) {
+	points, err := s.scroll(ctx, filter, false)
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]string, 0, len(points))
+	for _, p := range points {
+		ids = append(ids, p.Id)
+	}
+
+	return s.deletePoints(ctx, ids)
+}
+
+func (s *qdrantStorer) deletePoints(ctx context.Context, ids []string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
 	req := map[string]any{
-		"ids":          ids,
-		"with_vector":  true,
-		"with_payload": true,
+		"points": ids,
 	}
 
-	var rsp qdrantEnvelope[[]qdrantPointResult]
+	var rsp qdrantEnvelope[json.RawMessage]
 
-	path := fmt.Sprintf("/collections/%s/points", url.PathEscape(s.options.Collection))
+	path := fmt.Sprintf("/collections/%s/points/delete?wait=true", url.PathEscape(s.options.Collection))
 
 	if err := s.do(ctx, http.MethodPost, path, req, &rsp); err != nil {
-		return nil, err
+		return 0, err
+	}
+
+	if err := s.removeEdgesTo(ctx, ids); err != nil {
+		return 0, err
 	}
 
-	return rsp.Result, nil
+	return len(ids), nil
+}
+
+func (s *qdrantStorer) removeEdgesTo(ctx context.Context, ids []string) error {
+	// edges live in the source point's payload so rewrite any that point at deleted ids
+	points, err := s.scroll(ctx, map[string]any{
+		"must": []map[string]any{
+			{
+				"key":   edgeTargetKey,
+				"match": map[string]any{"any": ids},
+			},
+		},
+	}, true)
+	if err != nil {
+		return err
+	}
+
+	deleted := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		deleted[id] = struct{}{}
+	}
+
+	path := fmt.Sprintf("/collections/%s/points/payload?wait=true", url.PathEscape(s.options.Collection))
+
+	for _, p := range points {
+		metadata := getsafe.Metadata(p.Payload, "metadata")
+		if !storer.RemoveEdgesTo(metadata, deleted) {
+			continue
+		}
+
+		req := map[string]any{
+			"payload": map[string]any{"metadata": metadata},
+			"points":  []string{p.Id},
+		}
+
+		var rsp qdrantEnvelope[json.RawMessage]
+
+		if err := s.do(ctx, http.MethodPost, path, req, &rsp); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *qdrantStorer) scroll(ctx context.Context, filter map[string]any, withPayload bool) ([]qdrantPointResult, error) {
+	var points []qdrantPointResult
+	var offset any
+
+	path := fmt.Sprintf("/collections/%s/points/scroll", url.PathEscape(s.options.Collection))
+
+	for {
+		req := map[string]any{
+			"filter":       filter,
+			"limit":        256,
+			"with_payload": withPayload,
+			"with_vector":  false,
+		}
+		if offset != nil {
+			req["offset"] = offset
+		}
+
+		var rsp qdrantEnvelope[qdrantScrollResult]
+
+		if err := s.do(ctx, http.MethodPost, path, req, &rsp); err != nil {
+			return nil, err
+		}
+
+		points = append(points, rsp.Result.Points...)
+
+		if rsp.Result.NextPageOffset == nil {
+			return points, nil
+		}
+
+		offset = rsp.Result.NextPageOffset
+	}
+}
+
+func (s *qdrantStorer) retrievePoints(ctx context.Context, ids []string) ([]qdrantPointResult, error) {
+	var points []qdrantPointResult
+
+	path := fmt.Sprintf("/collections/%s/points", url.PathEscape(s.options.Collection))
+
+	for start := 0; start < len(ids); start += maxPointsPerRequest {
+		req := map[string]any{
+			"ids":          ids[start:min(start+maxPointsPerRequest, len(ids))],
+			"with_vector":  true,
+			"with_payload": true,
+		}
+
+		var rsp qdrantEnvelope[[]qdrantPointResult]
+
+		if err := s.do(ctx, http.MethodPost, path, req, &rsp); err != nil {
+			return nil, err
+		}
+
+		points = append(points, rsp.Result...)
+	}
+
+	return points, nil
 }
 
 func (s *qdrantStorer) do(ctx context.Context, method string, path string, req any, rsp any) error {
@@ -230,14 +514,15 @@ func (s *qdrantStorer) mapToStorerRecord(point qdrantPointResult) storer.Record
 	createdAt, _ := time.Parse(time.RFC3339Nano, getsafe.String(payload, "created_at"))
 
 	rec := storer.Record{
-		Id:        point.Id,
-		SessionId: getsafe.String(payload, "session_id"),
-		Content:   getsafe.String(payload, "content"),
-		Metadata:  getsafe.Metadata(payload, "metadata"),
-		Embedding: point.Vector,
-		Score:     float32(point.Score),
-		SpaceId:   getsafe.String(payload, "space_id"),
-		CreatedAt: createdAt,
+		Id:             point.Id,
+		SessionId:      getsafe.String(payload, "session_id"),
+		Content:        getsafe.String(payload, "content"),
+		Metadata:       getsafe.Metadata(payload, "metadata"),
+		Embedding:      []float32(point.Vector),
+		Score:          float32(point.Score),
+		SpaceId:        getsafe.String(payload, "space_id"),
+		CreatedAt:      createdAt,
+		EmbeddingModel: getsafe.String(payload, storer.FieldEmbeddingModel),
 	}
 
 	return rec
@@ -249,11 +534,33 @@ func (s *qdrantStorer) configure(ctx context.Context) error {
 		return err
 	}
 
-	if exists {
-		return nil
+	if !exists {
+		if err := s.createCollection(ctx); err != nil {
+			return err
+		}
 	}
 
-	return s.createCollection(ctx)
+	// creating an index that already exists is a no-op so older collections pick them up too
+	return s.createPayloadIndexes(ctx)
+}
+
+func (s *qdrantStorer) createPayloadIndexes(ctx context.Context) error {
+	path := fmt.Sprintf("/collections/%s/index?wait=true", url.PathEscape(s.options.Collection))
+
+	for _, field := range []string{"space_id", "session_id", edgeTargetKey, storer.FieldEmbeddingModel} {
+		req := map[string]any{
+			"field_name":   field,
+			"field_schema": "keyword",
+		}
+
+		var rsp qdrantEnvelope[json.RawMessage]
+
+		if err := s.do(ctx, http.MethodPut, path, req, &rsp); err != nil {
+			return fmt.Errorf("failed to index %s: %w", field, err)
+		}
+	}
+
+	return nil
 }
 
 func (s *qdrantStorer) collectionExists(ctx context.Context) (bool, error) {
@@ -284,6 +591,12 @@ func (s *qdrantStorer) createCollection(ctx context.Context) error {
 		},
 	}
 
+	if s.options.Lexical {
+		req["sparse_vectors"] = map[string]any{
+			sparseVectorName: map[string]any{"modifier": "idf"},
+		}
+	}
+
 	path := fmt.Sprintf("/collections/%s", url.PathEscape(s.options.Collection))
 
 	var rsp qdrantEnvelope[json.RawMessage]
@@ -0,0 +1,35 @@
+package qdrant_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+	"github.com/w-h-a/agent/memory_manager/providers/storer/qdrant"
+	"github.com/w-h-a/agent/memory_manager/providers/storer/storertest"
+)
+
+// the instance from docker-compose.yml
+const location = "http://localhost:6333"
+
+func TestConformance(t *testing.T) {
+	if os.Getenv("INTEGRATION") != "1" {
+		t.Skip("set INTEGRATION=1 to run against docker")
+	}
+
+	storertest.Run(t, qdrant.NewStorer(
+		storer.WithVectorSize(storertest.VectorSize),
+		storer.WithLocation(location),
+		storer.WithCollection("conformance"),
+	))
+
+	t.Run("ttl", func(t *testing.T) {
+		storertest.RunTTL(t, qdrant.NewStorer(
+			storer.WithVectorSize(storertest.VectorSize),
+			storer.WithLocation(location),
+			storer.WithCollection("conformance"),
+			storer.WithTTL(time.Hour),
+		), time.Hour)
+	})
+}
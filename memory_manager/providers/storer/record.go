@@ -9,7 +9,9 @@ type Record struct {
 	Content   string         `json:"content"`
 	Metadata  map[string]any `json:"metadata"`
 	Embedding []float32      `json:"embedding,omitempty"`
-	Score     float32        `json:"score,omitempty"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at,omitempty"`
+	// empty for records stored before models were tracked
+	EmbeddingModel string    `json:"embedding_model,omitempty"`
+	Score          float32   `json:"score,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at,omitempty"`
 }
@@ -0,0 +1,153 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+)
+
+func (s *sqliteStorer) Export(ctx context.Context, spaceId string, w storer.ItemWriter) error {
+	// edges are small so they are gathered up front and records streamed after
+	edges, err := s.spaceEdges(ctx, spaceId)
+	if err != nil {
+		return err
+	}
+
+	rows, err := s.conn.QueryContext(ctx, `SELECT `+columns+` FROM memories WHERE space_id = ? ORDER BY created_at, id`, spaceId)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		rec, err := scanRecord(rows, false)
+		if err != nil {
+			return err
+		}
+
+		// the edge table is authoritative over whatever metadata kept
+		delete(rec.Metadata, "edges")
+		if out := edges[rec.Id]; len(out) > 0 {
+			rec.Metadata["edges"] = out
+		}
+
+		if err := w.Write(storer.Item{Kind: storer.KindRecord, Record: &rec}); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (s *sqliteStorer) spaceEdges(ctx context.Context, spaceId string) (map[string][]map[string]string, error) {
+	query := `
+		SELECT e.source_id, e.target_id, e.type
+		FROM memory_edges e
+		INNER JOIN memories m ON m.id = e.source_id
+		WHERE m.space_id = ?
+	`
+
+	rows, err := s.conn.QueryContext(ctx, query, spaceId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	edges := map[string][]map[string]string{}
+
+	for rows.Next() {
+		var source, target, t string
+		if err := rows.Scan(&source, &target, &t); err != nil {
+			return nil, err
+		}
+		edges[source] = append(edges[source], map[string]string{"target": target, "type": t})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return edges, nil
+}
+
+func (s *sqliteStorer) Import(ctx context.Context, r storer.ItemReader) (storer.ImportStats, error) {
+	return storer.ImportItems(ctx, r, storer.ImportTarget{
+		InsertRecord: s.insert,
+		AddEdges:     s.addEdges,
+	})
+}
+
+func (s *sqliteStorer) insert(ctx context.Context, rec storer.Record) (string, error) {
+	metaJSON, err := json.Marshal(rec.Metadata)
+	if err != nil {
+		return "", err
+	}
+
+	id := uuid.New().String()
+
+	createdAt := rec.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	updatedAt := rec.UpdatedAt
+	if updatedAt.IsZero() {
+		updatedAt = createdAt
+	}
+
+	if _, err := s.conn.ExecContext(
+		ctx,
+		`INSERT INTO memories (`+columns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		id, rec.SpaceId, rec.SessionId, rec.Content, string(metaJSON), encodeVector(rec.Embedding), formatTime(createdAt), formatTime(updatedAt), rec.EmbeddingModel,
+	); err != nil {
+		return "", err
+	}
+
+	s.addToIndex(rec.SpaceId, id, rec.Embedding)
+
+	return id, nil
+}
+
+func (s *sqliteStorer) addEdges(ctx context.Context, sourceId string, edges []map[string]string) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var raw string
+	if err := tx.QueryRowContext(ctx, `SELECT metadata FROM memories WHERE id = ?`, sourceId).Scan(&raw); err != nil {
+		return err
+	}
+
+	metadata := map[string]any{}
+	json.Unmarshal([]byte(raw), &metadata)
+
+	storer.AddEdges(metadata, edges)
+
+	metaJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE memories SET metadata = ? WHERE id = ?`, string(metaJSON), sourceId); err != nil {
+		return err
+	}
+
+	now := formatTime(time.Now())
+
+	for _, edge := range edges {
+		if _, err := tx.ExecContext(
+			ctx,
+			`INSERT OR IGNORE INTO memory_edges (source_id, target_id, type, created_at) SELECT ?, id, ?, ? FROM memories WHERE id = ?`,
+			sourceId, edge["type"], now, edge["target"],
+		); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,154 @@
+package sqlite
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+)
+
+type filterQuery struct {
+	args []any
+}
+
+func (q *filterQuery) param(v any) string {
+	q.args = append(q.args, v)
+	return fmt.Sprintf("?%d", len(q.args))
+}
+
+func (q *filterQuery) where(f *storer.Filter) string {
+	if f == nil {
+		return "1"
+	}
+
+	switch f.Op {
+	case storer.OpAnd, storer.OpOr:
+		joiner, empty := " AND ", "1"
+		if f.Op == storer.OpOr {
+			joiner, empty = " OR ", "0"
+		}
+		if len(f.Filters) == 0 {
+			return empty
+		}
+		clauses := make([]string, 0, len(f.Filters))
+		for _, child := range f.Filters {
+			clauses = append(clauses, q.where(child))
+		}
+		return "(" + strings.Join(clauses, joiner) + ")"
+	}
+
+	switch f.Field {
+	case storer.FieldSessionId, storer.FieldCreatedAt, storer.FieldEmbeddingModel:
+		return q.column(f)
+	}
+
+	return q.metadata(f)
+}
+
+func (q *filterQuery) column(f *storer.Filter) string {
+	col := f.Field
+
+	switch f.Op {
+	case storer.OpEq:
+		return fmt.Sprintf("%s = %s", col, q.param(columnValue(col, f.Value)))
+	case storer.OpIn:
+		if len(f.Values) == 0 {
+			return "0"
+		}
+		params := make([]string, 0, len(f.Values))
+		for _, v := range f.Values {
+			params = append(params, q.param(columnValue(col, v)))
+		}
+		return fmt.Sprintf("%s IN (%s)", col, strings.Join(params, ", "))
+	case storer.OpRange:
+		clauses := []string{}
+		if f.Min != nil {
+			clauses = append(clauses, fmt.Sprintf("%s >= %s", col, q.param(columnValue(col, f.Min))))
+		}
+		if f.Max != nil {
+			clauses = append(clauses, fmt.Sprintf("%s <= %s", col, q.param(columnValue(col, f.Max))))
+		}
+		return "(" + strings.Join(clauses, " AND ") + ")"
+	case storer.OpExists:
+		return fmt.Sprintf("%s <> ''", col)
+	}
+
+	return "0"
+}
+
+func (q *filterQuery) metadata(f *storer.Filter) string {
+	// numbered parameters let the path be bound once and referenced repeatedly
+	path := q.param("$." + f.Field)
+	value := fmt.Sprintf("json_extract(metadata, %s)", path)
+	kind := fmt.Sprintf("json_type(metadata, %s)", path)
+
+	switch f.Op {
+	case storer.OpEq:
+		return q.match(value, kind, f.Value)
+	case storer.OpIn:
+		if len(f.Values) == 0 {
+			return "0"
+		}
+		clauses := make([]string, 0, len(f.Values))
+		for _, v := range f.Values {
+			clauses = append(clauses, q.match(value, kind, v))
+		}
+		return "(" + strings.Join(clauses, " OR ") + ")"
+	case storer.OpRange:
+		clauses := []string{}
+		for _, bound := range []struct {
+			v  any
+			op string
+		}{{f.Min, ">="}, {f.Max, "<="}} {
+			if bound.v == nil {
+				continue
+			}
+			// sqlite orders numbers before text so each bound is guarded by type
+			if n, ok := storer.ToFloat(bound.v); ok {
+				clauses = append(clauses, fmt.Sprintf("(%s IN ('integer', 'real') AND %s %s %s)", kind, value, bound.op, q.param(n)))
+				continue
+			}
+			clauses = append(clauses, fmt.Sprintf("(%s = 'text' AND %s %s %s)", kind, value, bound.op, q.param(fmt.Sprint(jsonValue(bound.v)))))
+		}
+		return "(" + strings.Join(clauses, " AND ") + ")"
+	case storer.OpExists:
+		return fmt.Sprintf("coalesce(%s, 'null') <> 'null'", kind)
+	}
+
+	return "0"
+}
+
+func (q *filterQuery) match(value string, kind string, v any) string {
+	switch t := v.(type) {
+	case bool:
+		if t {
+			return fmt.Sprintf("%s = 'true'", kind)
+		}
+		return fmt.Sprintf("%s = 'false'", kind)
+	case nil:
+		return "0"
+	}
+
+	if n, ok := storer.ToFloat(v); ok {
+		return fmt.Sprintf("(%s IN ('integer', 'real') AND %s = %s)", kind, value, q.param(n))
+	}
+
+	return fmt.Sprintf("(%s = 'text' AND %s = %s)", kind, value, q.param(fmt.Sprint(jsonValue(v))))
+}
+
+func columnValue(col string, v any) any {
+	if col == storer.FieldCreatedAt {
+		if t, ok := storer.ToTime(v); ok {
+			return formatTime(t)
+		}
+	}
+	return fmt.Sprint(v)
+}
+
+func jsonValue(v any) any {
+	if t, ok := v.(time.Time); ok {
+		return t.UTC().Format(time.RFC3339Nano)
+	}
+	return v
+}
@@ -0,0 +1,266 @@
+package sqlite
+
+import (
+	"context"
+	"math"
+
+	memorymanager "github.com/w-h-a/agent/memory_manager"
+)
+
+const (
+	ivfIterations = 10
+	// below this many records per list a full scan is just as fast
+	ivfMinPerList = 8
+	// the index is rebuilt once a space has grown by half since the last build
+	ivfGrowth = 1.5
+)
+
+type ivfIndex struct {
+	centroids [][]float32
+	lists     [][]string
+	built     int
+	count     int
+}
+
+type ivfEntry struct {
+	id     string
+	vector []float32
+}
+
+func (s *sqliteStorer) probe(ctx context.Context, spaceId string, vector []float32) ([]string, bool) {
+	if s.ivf == nil {
+		return nil, false
+	}
+
+	s.mtx.Lock()
+	idx, ok := s.indexes[spaceId]
+	_, inFlight := s.building[spaceId]
+	rebuild := !inFlight && (!ok || float64(idx.count) >= ivfGrowth*float64(idx.built))
+	generation := s.generation
+	if rebuild {
+		s.building[spaceId] = nil
+	}
+	s.mtx.Unlock()
+
+	// the scan and clustering run unlocked so concurrent searches keep using
+	// the previous index, or a full scan, until the new one is swapped in
+	if rebuild {
+		built, err := s.buildIndex(ctx, spaceId)
+
+		s.mtx.Lock()
+		pending := s.building[spaceId]
+		delete(s.building, spaceId)
+		// a reset while building means the scan may have read stale rows
+		if err == nil && generation == s.generation {
+			built.merge(pending)
+			s.indexes[spaceId] = built
+			idx, ok = built, true
+		}
+		s.mtx.Unlock()
+	}
+
+	if !ok {
+		return nil, false
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if len(idx.centroids) == 0 {
+		return nil, false
+	}
+
+	probes := min(s.ivf.Probes, len(idx.centroids))
+
+	// pick the probes nearest centroids
+	chosen := make([]int, 0, probes)
+	taken := make([]bool, len(idx.centroids))
+	for range probes {
+		best, bestScore := -1, math.Inf(-1)
+		for i, c := range idx.centroids {
+			if taken[i] {
+				continue
+			}
+			if score := memorymanager.CosineSimilarity(vector, c); score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		taken[best] = true
+		chosen = append(chosen, best)
+	}
+
+	ids := []string{}
+	for _, i := range chosen {
+		ids = append(ids, idx.lists[i]...)
+	}
+
+	return ids, true
+}
+
+func (s *sqliteStorer) buildIndex(ctx context.Context, spaceId string) (*ivfIndex, error) {
+	rows, err := s.conn.QueryContext(ctx, `SELECT id, embedding FROM memories WHERE space_id = ?`, spaceId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	var vectors [][]float32
+
+	for rows.Next() {
+		var id string
+		var embedding []byte
+		if err := rows.Scan(&id, &embedding); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+		vectors = append(vectors, decodeVector(embedding))
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	idx := &ivfIndex{built: len(ids), count: len(ids)}
+
+	// too small to partition, an empty index falls back to a full scan until it grows
+	if len(ids) < s.ivf.Lists*ivfMinPerList {
+		idx.built = max(len(ids), 1)
+		return idx, nil
+	}
+
+	k := s.ivf.Lists
+
+	// seed with evenly spaced records so the build is deterministic
+	centroids := make([][]float32, k)
+	for i := range k {
+		centroids[i] = append([]float32(nil), vectors[i*len(vectors)/k]...)
+	}
+
+	assignment := make([]int, len(vectors))
+
+	for range ivfIterations {
+		changed := false
+		for i, v := range vectors {
+			if c := nearest(centroids, v); c != assignment[i] {
+				assignment[i] = c
+				changed = true
+			}
+		}
+
+		sums := make([][]float64, k)
+		counts := make([]int, k)
+		for i, v := range vectors {
+			c := assignment[i]
+			if sums[c] == nil {
+				sums[c] = make([]float64, len(v))
+			}
+			if len(sums[c]) != len(v) {
+				continue
+			}
+			for d, x := range v {
+				sums[c][d] += float64(x)
+			}
+			counts[c]++
+		}
+
+		for c := range k {
+			if counts[c] == 0 {
+				continue
+			}
+			for d := range centroids[c] {
+				centroids[c][d] = float32(sums[c][d] / float64(counts[c]))
+			}
+		}
+
+		if !changed {
+			break
+		}
+	}
+
+	idx.centroids = centroids
+	idx.lists = make([][]string, k)
+	for i, id := range ids {
+		idx.lists[assignment[i]] = append(idx.lists[assignment[i]], id)
+	}
+
+	return idx, nil
+}
+
+func (s *sqliteStorer) addToIndex(spaceId string, id string, vector []float32) {
+	if s.ivf == nil {
+		return
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if pending, ok := s.building[spaceId]; ok {
+		s.building[spaceId] = append(pending, ivfEntry{id: id, vector: vector})
+	}
+
+	if idx, ok := s.indexes[spaceId]; ok {
+		idx.add(id, vector)
+	}
+}
+
+func (idx *ivfIndex) add(id string, vector []float32) {
+	idx.count++
+
+	if len(idx.centroids) > 0 {
+		c := nearest(idx.centroids, vector)
+		idx.lists[c] = append(idx.lists[c], id)
+	}
+}
+
+// merge adds what was stored during a build and the build's scan missed
+func (idx *ivfIndex) merge(pending []ivfEntry) {
+	if len(pending) == 0 {
+		return
+	}
+
+	missing := make(map[string]bool, len(pending))
+	for _, entry := range pending {
+		missing[entry.id] = true
+	}
+
+	for _, list := range idx.lists {
+		for _, id := range list {
+			delete(missing, id)
+		}
+	}
+
+	// an unpartitioned index keeps no lists so its ids cannot be checked
+	if len(idx.centroids) == 0 {
+		idx.count += len(pending)
+		return
+	}
+
+	for _, entry := range pending {
+		if missing[entry.id] {
+			idx.add(entry.id, entry.vector)
+		}
+	}
+}
+
+func (s *sqliteStorer) resetIndexes() {
+	if s.ivf == nil {
+		return
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	clear(s.indexes)
+	s.generation++
+}
+
+func nearest(centroids [][]float32, v []float32) int {
+	best, bestScore := 0, math.Inf(-1)
+	for i, c := range centroids {
+		if score := memorymanager.CosineSimilarity(v, c); score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
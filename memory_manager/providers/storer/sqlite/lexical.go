@@ -0,0 +1,75 @@
+package sqlite
+
+import (
+	"context"
+	"strings"
+
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+)
+
+const lexicalSchema = `
+	CREATE VIRTUAL TABLE IF NOT EXISTS memories_fts USING fts5(id UNINDEXED, content);
+
+	CREATE TRIGGER IF NOT EXISTS memories_fts_insert AFTER INSERT ON memories BEGIN
+		INSERT INTO memories_fts (id, content) VALUES (new.id, new.content);
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS memories_fts_delete AFTER DELETE ON memories BEGIN
+		DELETE FROM memories_fts WHERE id = old.id;
+	END;
+
+	INSERT INTO memories_fts (id, content)
+	SELECT id, content FROM memories WHERE id NOT IN (SELECT id FROM memories_fts);
+`
+
+func (s *sqliteStorer) configureLexical(ctx context.Context) error {
+	_, err := s.conn.ExecContext(ctx, lexicalSchema)
+	return err
+}
+
+func (s *sqliteStorer) SearchLexical(ctx context.Context, spaceId string, query string, limit int, filter *storer.Filter) ([]storer.Record, error) {
+	tokens := storer.Tokenize(query)
+	if limit < 1 || !s.options.Lexical || len(tokens) == 0 {
+		return nil, nil
+	}
+
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	filter = storer.Unexpired(s.options.TTL, filter)
+
+	// each token is quoted so fts5 never reads it as query syntax
+	terms := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		terms = append(terms, `"`+strings.ReplaceAll(token, `"`, `""`)+`"`)
+	}
+
+	q := &filterQuery{args: []any{strings.Join(terms, " OR "), spaceId}}
+	where := q.where(filter)
+	lim := q.param(limit)
+
+	// bm25 is lower for better matches
+	sqlQuery := `
+		SELECT
+			m.id,
+			m.space_id,
+			m.session_id,
+			m.content,
+			m.metadata,
+			m.embedding,
+			m.created_at,
+			m.updated_at,
+			m.embedding_model,
+			-bm25(memories_fts) AS score
+		FROM memories_fts
+		INNER JOIN memories m ON m.id = memories_fts.id
+		WHERE memories_fts MATCH ?1
+			AND m.space_id = ?2
+			AND ` + where + `
+		ORDER BY bm25(memories_fts)
+		LIMIT ` + lim + `
+	`
+
+	return s.query(ctx, sqlQuery, q.args...)
+}
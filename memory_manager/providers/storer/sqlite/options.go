@@ -0,0 +1,28 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+)
+
+type ivfKey struct{}
+
+// IVFConfig is how WithIVF partitions each space
+type IVFConfig struct {
+	Lists  int
+	Probes int
+}
+
+// WithIVF partitions each space into lists clusters and scores only the
+// probes closest clusters on search instead of every record
+func WithIVF(lists int, probes int) storer.Option {
+	return func(o *storer.Options) {
+		o.Context = context.WithValue(o.Context, ivfKey{}, IVFConfig{Lists: lists, Probes: probes})
+	}
+}
+
+func IVFFrom(ctx context.Context) (IVFConfig, bool) {
+	cfg, ok := ctx.Value(ivfKey{}).(IVFConfig)
+	return cfg, ok && cfg.Lists > 1 && cfg.Probes > 0
+}
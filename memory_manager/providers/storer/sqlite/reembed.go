@@ -0,0 +1,83 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+)
+
+func (s *sqliteStorer) PendingEmbeddings(ctx context.Context, spaceId string, model string, limit int) ([]storer.Record, error) {
+	if limit < 1 {
+		return nil, nil
+	}
+
+	// oldest first so a resumed job walks the space in the same order
+	return s.query(
+		ctx,
+		`SELECT `+columns+` FROM memories
+		WHERE space_id = ?1 AND embedding_model <> ?2 AND coalesce(staged_model, '') <> ?2
+		ORDER BY created_at, id
+		LIMIT ?3`,
+		spaceId, model, limit,
+	)
+}
+
+func (s *sqliteStorer) StageEmbeddings(ctx context.Context, model string, vectors map[string][]float32) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for id, vector := range vectors {
+		if _, err := tx.ExecContext(
+			ctx,
+			`UPDATE memories SET staged_embedding = ?, staged_model = ? WHERE id = ?`,
+			encodeVector(vector), model, id,
+		); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *sqliteStorer) PromoteEmbeddings(ctx context.Context, spaceId string, model string) (int, error) {
+	res, err := s.conn.ExecContext(
+		ctx,
+		`UPDATE memories
+		SET embedding = staged_embedding, embedding_model = staged_model, staged_embedding = NULL, staged_model = NULL
+		WHERE space_id = ?1 AND staged_model = ?2`,
+		spaceId, model,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	// ivf lists were clustered on the old vectors
+	if n > 0 {
+		s.resetIndexes()
+	}
+
+	return int(n), nil
+}
+
+func (s *sqliteStorer) OtherEmbeddingModel(ctx context.Context, spaceId string, model string) (string, error) {
+	var other string
+	err := s.conn.QueryRowContext(
+		ctx,
+		`SELECT embedding_model FROM memories WHERE space_id = ?1 AND embedding_model NOT IN (?2, '') LIMIT 1`,
+		spaceId, model,
+	).Scan(&other)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return other, err
+}
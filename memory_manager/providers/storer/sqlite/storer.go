@@ -0,0 +1,463 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	memorymanager "github.com/w-h-a/agent/memory_manager"
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+	_ "modernc.org/sqlite"
+)
+
+const (
+	schema = `
+		CREATE TABLE IF NOT EXISTS memories (
+			id TEXT PRIMARY KEY,
+			space_id TEXT NOT NULL DEFAULT '',
+			session_id TEXT NOT NULL DEFAULT '',
+			content TEXT NOT NULL,
+			metadata TEXT NOT NULL DEFAULT '{}',
+			embedding BLOB,
+			created_at TEXT NOT NULL,
+			updated_at TEXT NOT NULL,
+			embedding_model TEXT NOT NULL DEFAULT '',
+			staged_embedding BLOB,
+			staged_model TEXT
+		);
+
+		CREATE INDEX IF NOT EXISTS memories_space_idx ON memories (space_id);
+		CREATE INDEX IF NOT EXISTS memories_session_idx ON memories (session_id);
+		CREATE INDEX IF NOT EXISTS memories_created_idx ON memories (created_at);
+
+		CREATE TABLE IF NOT EXISTS memory_edges (
+			source_id TEXT NOT NULL REFERENCES memories(id) ON DELETE CASCADE,
+			target_id TEXT NOT NULL REFERENCES memories(id) ON DELETE CASCADE,
+			type TEXT NOT NULL,
+			created_at TEXT NOT NULL,
+			PRIMARY KEY (source_id, target_id, type)
+		);
+
+		CREATE INDEX IF NOT EXISTS memory_edges_target_idx ON memory_edges (target_id);
+	`
+
+	// fixed width so timestamps sort correctly as text
+	timeLayout = "2006-01-02T15:04:05.000000000Z"
+
+	columns = `id, space_id, session_id, content, metadata, embedding, created_at, updated_at, embedding_model`
+)
+
+// columns added after the first release are backfilled onto older files
+var addedColumns = []struct {
+	name string
+	def  string
+}{
+	{"embedding_model", `TEXT NOT NULL DEFAULT ''`},
+	{"staged_embedding", `BLOB`},
+	{"staged_model", `TEXT`},
+}
+
+type sqliteStorer struct {
+	options storer.Options
+	conn    *sql.DB
+	ivf     *IVFConfig
+	indexes map[string]*ivfIndex
+	// records stored in a space while its index is being rebuilt
+	building   map[string][]ivfEntry
+	generation int
+	mtx        sync.Mutex
+}
+
+func (s *sqliteStorer) Store(ctx context.Context, spaceId string, sessionId string, content string, metadata map[string]any, vector []float32) (string, error) {
+	if err := storer.CheckDimension(s.options.VectorSize, vector); err != nil {
+		return "", err
+	}
+
+	edges := storer.SanitizeEdges(metadata)
+	model := storer.TakeEmbeddingModel(metadata)
+
+	metaJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return "", err
+	}
+
+	id := uuid.New().String()
+	now := formatTime(time.Now())
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO memories (`+columns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		id, spaceId, sessionId, content, string(metaJSON), encodeVector(vector), now, now, model,
+	); err != nil {
+		return "", err
+	}
+
+	// edges to memories that no longer exist are skipped rather than failing the insert
+	for _, edge := range edges {
+		if _, err := tx.ExecContext(
+			ctx,
+			`INSERT OR IGNORE INTO memory_edges (source_id, target_id, type, created_at) SELECT ?, id, ?, ? FROM memories WHERE id = ?`,
+			id, edge["type"], now, edge["target"],
+		); err != nil {
+			return "", err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+
+	s.addToIndex(spaceId, id, vector)
+
+	return id, nil
+}
+
+func (s *sqliteStorer) Search(ctx context.Context, spaceId string, vector []float32, limit int, filter *storer.Filter) ([]storer.Record, error) {
+	if limit < 1 {
+		return nil, nil
+	}
+
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	filter = storer.Unexpired(s.options.TTL, filter)
+
+	if err := storer.CheckDimension(s.options.VectorSize, vector); err != nil {
+		return nil, err
+	}
+
+	q := &filterQuery{args: []any{spaceId}}
+	where := q.where(filter)
+
+	query := `SELECT ` + columns + ` FROM memories WHERE space_id = ?1 AND ` + where
+
+	// with an ivf index only the closest clusters are scored
+	if ids, ok := s.probe(ctx, spaceId, vector); ok {
+		if len(ids) == 0 {
+			return nil, nil
+		}
+		bs, err := json.Marshal(ids)
+		if err != nil {
+			return nil, err
+		}
+		query += ` AND id IN (SELECT value FROM json_each(` + q.param(string(bs)) + `))`
+	}
+
+	records, err := s.query(ctx, query, q.args...)
+	if err != nil {
+		return nil, err
+	}
+
+	// vectors of another size come from another model and are never compared
+	records = slices.DeleteFunc(records, func(rec storer.Record) bool {
+		return len(rec.Embedding) != len(vector)
+	})
+
+	for i := range records {
+		records[i].Score = float32(memorymanager.CosineSimilarity(vector, records[i].Embedding))
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Score > records[j].Score
+	})
+
+	if len(records) > limit {
+		records = records[:limit]
+	}
+
+	return records, nil
+}
+
+func (s *sqliteStorer) SearchNeighborhood(ctx context.Context, seedIds []string, hops int, limit int, filter *storer.Filter) ([]storer.Record, error) {
+	if limit < 1 || len(seedIds) == 0 {
+		return nil, nil
+	}
+
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	filter = storer.Unexpired(s.options.TTL, filter)
+
+	seeds, err := json.Marshal(seedIds)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &filterQuery{args: []any{string(seeds), hops}}
+	where := q.where(filter)
+	lim := q.param(limit)
+
+	query := `
+		WITH RECURSIVE walk(id, depth) AS (
+			SELECT value, 0 FROM json_each(?1)
+
+			UNION
+
+			SELECT e.target_id, w.depth + 1
+			FROM memory_edges e
+			INNER JOIN walk w ON w.id = e.source_id
+			WHERE w.depth < ?2
+		)
+		SELECT ` + columns + `
+		FROM memories
+		WHERE id IN (SELECT id FROM walk)
+			AND id NOT IN (SELECT value FROM json_each(?1))
+			AND ` + where + `
+		LIMIT ` + lim + `
+	`
+
+	return s.query(ctx, query, q.args...)
+}
+
+func (s *sqliteStorer) Delete(ctx context.Context, ids []string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	bs, err := json.Marshal(ids)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.delete(ctx, `DELETE FROM memories WHERE id IN (SELECT value FROM json_each(?))`, string(bs))
+}
+
+func (s *sqliteStorer) DeleteBySession(ctx context.Context, sessionId string) (int, error) {
+	return s.delete(ctx, `DELETE FROM memories WHERE session_id = ?`, sessionId)
+}
+
+func (s *sqliteStorer) DeleteBySpace(ctx context.Context, spaceId string) (int, error) {
+	return s.delete(ctx, `DELETE FROM memories WHERE space_id = ?`, spaceId)
+}
+
+func (s *sqliteStorer) DeleteExpired(ctx context.Context) (int, error) {
+	cutoff, ok := storer.ExpiredBefore(s.options.TTL)
+	if !ok {
+		return 0, nil
+	}
+
+	return s.delete(ctx, `DELETE FROM memories WHERE created_at < ?`, formatTime(cutoff))
+}
+
+func (s *sqliteStorer) delete(ctx context.Context, query string, args ...any) (int, error) {
+	// memory_edges rows go with their endpoints via ON DELETE CASCADE
+	res, err := s.conn.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if n > 0 {
+		s.resetIndexes()
+	}
+
+	return int(n), nil
+}
+
+func (s *sqliteStorer) query(ctx context.Context, query string, args ...any) ([]storer.Record, error) {
+	rows, err := s.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	// lexical search appends its rank as a trailing score column
+	scored := len(cols) > 9
+
+	var records []storer.Record
+
+	for rows.Next() {
+		rec, err := scanRecord(rows, scored)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+func scanRecord(rows *sql.Rows, scored bool) (storer.Record, error) {
+	var rec storer.Record
+	var metadata string
+	var embedding []byte
+	var createdAt, updatedAt string
+	var score float64
+
+	dest := []any{
+		&rec.Id,
+		&rec.SpaceId,
+		&rec.SessionId,
+		&rec.Content,
+		&metadata,
+		&embedding,
+		&createdAt,
+		&updatedAt,
+		&rec.EmbeddingModel,
+	}
+
+	if scored {
+		dest = append(dest, &score)
+	}
+
+	if err := rows.Scan(dest...); err != nil {
+		return storer.Record{}, err
+	}
+
+	if err := json.Unmarshal([]byte(metadata), &rec.Metadata); err != nil || rec.Metadata == nil {
+		rec.Metadata = map[string]any{}
+	}
+
+	rec.Score = float32(score)
+	rec.Embedding = decodeVector(embedding)
+	rec.CreatedAt, _ = time.Parse(timeLayout, createdAt)
+	rec.UpdatedAt, _ = time.Parse(timeLayout, updatedAt)
+
+	return rec, nil
+}
+
+func addColumns(ctx context.Context, conn *sql.DB) error {
+	rows, err := conn.QueryContext(ctx, `SELECT name FROM pragma_table_info('memories')`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	existing := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		existing[name] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, col := range addedColumns {
+		if existing[col.name] {
+			continue
+		}
+		if _, err := conn.ExecContext(ctx, `ALTER TABLE memories ADD COLUMN `+col.name+` `+col.def); err != nil {
+			return err
+		}
+	}
+
+	// indexes on added columns wait until older files have them
+	_, err = conn.ExecContext(ctx, `CREATE INDEX IF NOT EXISTS memories_space_model_idx ON memories (space_id, embedding_model)`)
+
+	return err
+}
+
+func encodeVector(vector []float32) []byte {
+	bs := make([]byte, 4*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(bs[i*4:], math.Float32bits(v))
+	}
+	return bs
+}
+
+func decodeVector(bs []byte) []float32 {
+	vector := make([]float32, len(bs)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(bs[i*4:]))
+	}
+	return vector
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+func dsn(location string) string {
+	if len(location) == 0 {
+		location = "memory.db"
+	}
+
+	pragmas := "_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)"
+
+	if strings.Contains(location, "?") {
+		return location + "&" + pragmas
+	}
+
+	return location + "?" + pragmas
+}
+
+func NewStorer(opts ...storer.Option) storer.Storer {
+	options := storer.NewOptions(opts...)
+
+	s := &sqliteStorer{
+		options:  options,
+		indexes:  map[string]*ivfIndex{},
+		building: map[string][]ivfEntry{},
+		mtx:      sync.Mutex{},
+	}
+
+	if cfg, ok := IVFFrom(options.Context); ok {
+		s.ivf = &cfg
+	}
+
+	// memory.db or file:/path/to/memory.db
+	conn, err := sql.Open("sqlite", dsn(options.Location))
+	if err != nil {
+		detail := "failed to open sqlite storer"
+		slog.ErrorContext(context.Background(), detail, "error", err)
+		panic(detail)
+	}
+
+	if _, err := conn.Exec(schema); err != nil {
+		detail := "failed to create sqlite storer schema"
+		slog.ErrorContext(context.Background(), detail, "error", err)
+		panic(detail)
+	}
+
+	if err := addColumns(context.Background(), conn); err != nil {
+		detail := "failed to upgrade sqlite storer schema"
+		slog.ErrorContext(context.Background(), detail, "error", err)
+		panic(detail)
+	}
+
+	s.conn = conn
+
+	if options.Lexical {
+		if err := s.configureLexical(context.Background()); err != nil {
+			detail := "failed to create sqlite storer full-text index"
+			slog.ErrorContext(context.Background(), detail, "error", err)
+			panic(detail)
+		}
+	}
+
+	return s
+}
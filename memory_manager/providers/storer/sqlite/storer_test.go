@@ -0,0 +1,27 @@
+package sqlite_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+	"github.com/w-h-a/agent/memory_manager/providers/storer/sqlite"
+	"github.com/w-h-a/agent/memory_manager/providers/storer/storertest"
+)
+
+func TestConformance(t *testing.T) {
+	storertest.Run(t, sqlite.NewStorer(
+		storer.WithVectorSize(storertest.VectorSize),
+		storer.WithLocation(filepath.Join(t.TempDir(), "storer.db")),
+	))
+
+	t.Run("ttl", func(t *testing.T) {
+		storertest.RunTTL(t, sqlite.NewStorer(
+			storer.WithVectorSize(storertest.VectorSize),
+			storer.WithLocation(filepath.Join(t.TempDir(), "ttl.db")),
+			storer.WithTTL(time.Hour),
+			storer.WithLexicalIndex(true),
+		), time.Hour)
+	})
+}
@@ -3,7 +3,22 @@ package storer
 import "context"
 
 type Storer interface {
-	Store(ctx context.Context, spaceId string, sessionId string, content string, metadata map[string]any, vector []float32) error
-	Search(ctx context.Context, spaceId string, vector []float32, limit int) ([]Record, error)
-	SearchNeighborhood(ctx context.Context, seedIds []string, hops int, limit int) ([]Record, error)
+	Store(ctx context.Context, spaceId string, sessionId string, content string, metadata map[string]any, vector []float32) (string, error)
+	Search(ctx context.Context, spaceId string, vector []float32, limit int, filter *Filter) ([]Record, error)
+	SearchNeighborhood(ctx context.Context, seedIds []string, hops int, limit int, filter *Filter) ([]Record, error)
+	Delete(ctx context.Context, ids []string) (int, error)
+	DeleteBySession(ctx context.Context, sessionId string) (int, error)
+	DeleteBySpace(ctx context.Context, spaceId string) (int, error)
+	DeleteExpired(ctx context.Context) (int, error)
+}
+
+type LexicalSearcher interface {
+	SearchLexical(ctx context.Context, spaceId string, query string, limit int, filter *Filter) ([]Record, error)
+}
+
+type EntityStorer interface {
+	UpsertEntity(ctx context.Context, spaceId string, entity Entity) (string, error)
+	SearchEntities(ctx context.Context, spaceId string, vector []float32, limit int) ([]Entity, error)
+	Relate(ctx context.Context, spaceId string, relation Relation) error
+	SearchEntityGraph(ctx context.Context, spaceId string, seedIds []string, hops int, limit int) ([]Entity, []Relation, error)
 }
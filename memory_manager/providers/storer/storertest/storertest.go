@@ -0,0 +1,418 @@
+package storertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+)
+
+// VectorSize is the dimension of every vector the suite stores. Storers
+// with a fixed dimension must be configured with it.
+const VectorSize = 8
+
+// Run checks s against what every storer must do. Each case writes to a
+// fresh space and removes it when done, so they can run against a shared
+// database.
+func Run(t *testing.T, s storer.Storer) {
+	for _, c := range []struct {
+		name string
+		run  func(ctx context.Context, s storer.Storer) error
+	}{
+		{"round trip", roundTrip},
+		{"space isolation", spaceIsolation},
+		{"limits", limits},
+		{"ordering", ordering},
+		{"metadata filter", metadataFilter},
+		{"edge traversal", edgeTraversal},
+		{"deletes", deletes},
+		{"concurrent stores", concurrentStores},
+		{"invalid filter", invalidFilter},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.run(t.Context(), s); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// Axis is the unit vector along dimension i. Mixing two axes gives a
+// vector that sits between them, which is all the ordering checks need.
+func Axis(i int) []float32 {
+	vec := make([]float32, VectorSize)
+	vec[i%VectorSize] = 1
+	return vec
+}
+
+func mix(a []float32, b []float32, weight float32) []float32 {
+	vec := make([]float32, len(a))
+	for i := range a {
+		vec[i] = (1-weight)*a[i] + weight*b[i]
+	}
+	return vec
+}
+
+func newSpace(ctx context.Context, s storer.Storer) (string, func()) {
+	spaceId := uuid.New().String()
+	return spaceId, func() { s.DeleteBySpace(context.WithoutCancel(ctx), spaceId) }
+}
+
+func roundTrip(ctx context.Context, s storer.Storer) error {
+	spaceId, cleanup := newSpace(ctx, s)
+	defer cleanup()
+
+	sessionId := uuid.New().String()
+
+	id, err := s.Store(ctx, spaceId, sessionId, "the launch code is banana", map[string]any{"topic": "launch"}, Axis(0))
+	if err != nil {
+		return fmt.Errorf("store: %w", err)
+	}
+	if len(id) == 0 {
+		return errors.New("store: empty id")
+	}
+
+	records, err := s.Search(ctx, spaceId, Axis(0), 5, nil)
+	if err != nil {
+		return fmt.Errorf("search: %w", err)
+	}
+	if len(records) != 1 {
+		return fmt.Errorf("search: got %d records, want 1", len(records))
+	}
+
+	rec := records[0]
+
+	switch {
+	case rec.Id != id:
+		return fmt.Errorf("search: got id %q, want %q", rec.Id, id)
+	case rec.SessionId != sessionId:
+		return fmt.Errorf("search: got session %q, want %q", rec.SessionId, sessionId)
+	case rec.Content != "the launch code is banana":
+		return fmt.Errorf("search: got content %q", rec.Content)
+	case rec.Metadata["topic"] != "launch":
+		return fmt.Errorf("search: got metadata %v", rec.Metadata)
+	case len(rec.Embedding) != VectorSize:
+		return fmt.Errorf("search: got embedding of size %d, want %d", len(rec.Embedding), VectorSize)
+	case rec.CreatedAt.IsZero():
+		return errors.New("search: created at is not set")
+	}
+
+	return nil
+}
+
+func spaceIsolation(ctx context.Context, s storer.Storer) error {
+	first, cleanupFirst := newSpace(ctx, s)
+	defer cleanupFirst()
+
+	second, cleanupSecond := newSpace(ctx, s)
+	defer cleanupSecond()
+
+	if _, err := s.Store(ctx, first, uuid.New().String(), "first", nil, Axis(0)); err != nil {
+		return fmt.Errorf("store: %w", err)
+	}
+
+	if _, err := s.Store(ctx, second, uuid.New().String(), "second", nil, Axis(0)); err != nil {
+		return fmt.Errorf("store: %w", err)
+	}
+
+	for spaceId, want := range map[string]string{first: "first", second: "second"} {
+		records, err := s.Search(ctx, spaceId, Axis(0), 10, nil)
+		if err != nil {
+			return fmt.Errorf("search: %w", err)
+		}
+		if len(records) != 1 || records[0].Content != want {
+			return fmt.Errorf("search: want only %q, got %v", want, contents(records))
+		}
+	}
+
+	return nil
+}
+
+func limits(ctx context.Context, s storer.Storer) error {
+	spaceId, cleanup := newSpace(ctx, s)
+	defer cleanup()
+
+	sessionId := uuid.New().String()
+
+	for i := range 5 {
+		if _, err := s.Store(ctx, spaceId, sessionId, fmt.Sprintf("record %d", i), nil, mix(Axis(0), Axis(1), float32(i)/10)); err != nil {
+			return fmt.Errorf("store: %w", err)
+		}
+	}
+
+	for _, limit := range []int{0, 1, 3, 5, 10} {
+		records, err := s.Search(ctx, spaceId, Axis(0), limit, nil)
+		if err != nil {
+			return fmt.Errorf("search with limit %d: %w", limit, err)
+		}
+		if want := min(limit, 5); len(records) != want {
+			return fmt.Errorf("search with limit %d: got %d records, want %d", limit, len(records), want)
+		}
+	}
+
+	return nil
+}
+
+func ordering(ctx context.Context, s storer.Storer) error {
+	spaceId, cleanup := newSpace(ctx, s)
+	defer cleanup()
+
+	sessionId := uuid.New().String()
+
+	// stored furthest first so insertion order cannot pass for ranking
+	for _, weight := range []float32{0.9, 0.6, 0.3, 0} {
+		content := fmt.Sprintf("%.1f", weight)
+		if _, err := s.Store(ctx, spaceId, sessionId, content, nil, mix(Axis(0), Axis(1), weight)); err != nil {
+			return fmt.Errorf("store: %w", err)
+		}
+	}
+
+	records, err := s.Search(ctx, spaceId, Axis(0), 4, nil)
+	if err != nil {
+		return fmt.Errorf("search: %w", err)
+	}
+
+	if got, want := contents(records), []string{"0.0", "0.3", "0.6", "0.9"}; !slices.Equal(got, want) {
+		return fmt.Errorf("search: want nearest first %v, got %v", want, got)
+	}
+
+	for i := 1; i < len(records); i++ {
+		if records[i].Score > records[i-1].Score {
+			return fmt.Errorf("search: scores are not descending: %v then %v", records[i-1].Score, records[i].Score)
+		}
+	}
+
+	return nil
+}
+
+func metadataFilter(ctx context.Context, s storer.Storer) error {
+	spaceId, cleanup := newSpace(ctx, s)
+	defer cleanup()
+
+	sessionId := uuid.New().String()
+
+	for i, kind := range []string{"fact", "opinion", "fact"} {
+		if _, err := s.Store(ctx, spaceId, sessionId, fmt.Sprintf("%s %d", kind, i), map[string]any{"kind": kind}, Axis(i)); err != nil {
+			return fmt.Errorf("store: %w", err)
+		}
+	}
+
+	records, err := s.Search(ctx, spaceId, Axis(1), 10, storer.Eq("kind", "fact"))
+	if err != nil {
+		return fmt.Errorf("search: %w", err)
+	}
+
+	if len(records) != 2 {
+		return fmt.Errorf("search: want the 2 facts, got %v", contents(records))
+	}
+
+	for _, rec := range records {
+		if rec.Metadata["kind"] != "fact" {
+			return fmt.Errorf("search: filter let through %q", rec.Content)
+		}
+	}
+
+	records, err = s.Search(ctx, spaceId, Axis(1), 10, storer.Eq(storer.FieldSessionId, uuid.New().String()))
+	if err != nil {
+		return fmt.Errorf("search: %w", err)
+	}
+
+	if len(records) != 0 {
+		return fmt.Errorf("search: session filter let through %v", contents(records))
+	}
+
+	return nil
+}
+
+func edgeTraversal(ctx context.Context, s storer.Storer) error {
+	spaceId, cleanup := newSpace(ctx, s)
+	defer cleanup()
+
+	sessionId := uuid.New().String()
+
+	// head -> middle -> tail, plus an unlinked record that must never show up
+	tail, err := s.Store(ctx, spaceId, sessionId, "tail", nil, Axis(2))
+	if err != nil {
+		return fmt.Errorf("store: %w", err)
+	}
+
+	middle, err := s.Store(ctx, spaceId, sessionId, "middle", edges(tail), Axis(1))
+	if err != nil {
+		return fmt.Errorf("store: %w", err)
+	}
+
+	head, err := s.Store(ctx, spaceId, sessionId, "head", edges(middle), Axis(0))
+	if err != nil {
+		return fmt.Errorf("store: %w", err)
+	}
+
+	if _, err := s.Store(ctx, spaceId, sessionId, "island", nil, Axis(3)); err != nil {
+		return fmt.Errorf("store: %w", err)
+	}
+
+	for hops, want := range map[int][]string{1: {"middle"}, 2: {"middle", "tail"}} {
+		records, err := s.SearchNeighborhood(ctx, []string{head}, hops, 10, nil)
+		if err != nil {
+			return fmt.Errorf("neighborhood with %d hops: %w", hops, err)
+		}
+
+		got := contents(records)
+		slices.Sort(got)
+
+		if !slices.Equal(got, want) {
+			return fmt.Errorf("neighborhood with %d hops: want %v, got %v", hops, want, got)
+		}
+	}
+
+	records, err := s.SearchNeighborhood(ctx, []string{head}, 2, 1, nil)
+	if err != nil {
+		return fmt.Errorf("neighborhood with limit: %w", err)
+	}
+
+	if len(records) != 1 {
+		return fmt.Errorf("neighborhood with limit 1: got %v", contents(records))
+	}
+
+	records, err = s.SearchNeighborhood(ctx, []string{head}, 2, 10, storer.Eq("kind", "missing"))
+	if err != nil {
+		return fmt.Errorf("neighborhood with filter: %w", err)
+	}
+
+	if len(records) != 0 {
+		return fmt.Errorf("neighborhood with filter: got %v", contents(records))
+	}
+
+	return nil
+}
+
+func deletes(ctx context.Context, s storer.Storer) error {
+	spaceId, cleanup := newSpace(ctx, s)
+	defer cleanup()
+
+	first := uuid.New().String()
+	second := uuid.New().String()
+
+	var ids []string
+
+	for i, sessionId := range []string{first, first, second, second} {
+		id, err := s.Store(ctx, spaceId, sessionId, fmt.Sprintf("record %d", i), nil, Axis(i))
+		if err != nil {
+			return fmt.Errorf("store: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if n, err := s.DeleteBySession(ctx, first); err != nil || n != 2 {
+		return fmt.Errorf("delete by session: got %d, %v, want 2", n, err)
+	}
+
+	if n, err := s.Delete(ctx, []string{ids[2]}); err != nil || n != 1 {
+		return fmt.Errorf("delete: got %d, %v, want 1", n, err)
+	}
+
+	if n, err := s.Delete(ctx, []string{ids[2]}); err != nil || n != 0 {
+		return fmt.Errorf("delete twice: got %d, %v, want 0", n, err)
+	}
+
+	records, err := s.Search(ctx, spaceId, Axis(0), 10, nil)
+	if err != nil {
+		return fmt.Errorf("search: %w", err)
+	}
+
+	if len(records) != 1 || records[0].Id != ids[3] {
+		return fmt.Errorf("search after deletes: want only %q, got %v", "record 3", contents(records))
+	}
+
+	if n, err := s.DeleteBySpace(ctx, spaceId); err != nil || n != 1 {
+		return fmt.Errorf("delete by space: got %d, %v, want 1", n, err)
+	}
+
+	return nil
+}
+
+func concurrentStores(ctx context.Context, s storer.Storer) error {
+	spaceId, cleanup := newSpace(ctx, s)
+	defer cleanup()
+
+	const writers = 16
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+
+	for i := range writers {
+		wg.Go(func() {
+			sessionId := uuid.New().String()
+			if _, err := s.Store(ctx, spaceId, sessionId, fmt.Sprintf("writer %d", i), nil, mix(Axis(0), Axis(i), 0.5)); err != nil {
+				errs <- err
+				return
+			}
+			if _, err := s.Search(ctx, spaceId, Axis(i), 3, nil); err != nil {
+				errs <- err
+			}
+		})
+	}
+
+	wg.Wait()
+	close(errs)
+
+	if err := <-errs; err != nil {
+		return fmt.Errorf("concurrent store: %w", err)
+	}
+
+	records, err := s.Search(ctx, spaceId, Axis(0), writers*2, nil)
+	if err != nil {
+		return fmt.Errorf("search: %w", err)
+	}
+
+	if len(records) != writers {
+		return fmt.Errorf("search: got %d records, want %d", len(records), writers)
+	}
+
+	return nil
+}
+
+func invalidFilter(ctx context.Context, s storer.Storer) error {
+	spaceId, cleanup := newSpace(ctx, s)
+	defer cleanup()
+
+	id, err := s.Store(ctx, spaceId, uuid.New().String(), "record", nil, Axis(0))
+	if err != nil {
+		return fmt.Errorf("store: %w", err)
+	}
+
+	for _, filter := range []*storer.Filter{
+		{Op: "nope", Field: "kind"},
+		storer.Eq("not a field", "x"),
+		storer.Range(storer.FieldCreatedAt, nil, nil),
+	} {
+		if _, err := s.Search(ctx, spaceId, Axis(0), 5, filter); err == nil {
+			return fmt.Errorf("search: want an error for filter %+v", filter)
+		}
+		if _, err := s.SearchNeighborhood(ctx, []string{id}, 1, 5, filter); err == nil {
+			return fmt.Errorf("neighborhood: want an error for filter %+v", filter)
+		}
+	}
+
+	return nil
+}
+
+func edges(targets ...string) map[string]any {
+	list := make([]map[string]string, 0, len(targets))
+	for _, target := range targets {
+		list = append(list, map[string]string{"target": target, "type": "next"})
+	}
+	return map[string]any{"edges": list}
+}
+
+func contents(records []storer.Record) []string {
+	out := make([]string, 0, len(records))
+	for _, rec := range records {
+		out = append(out, rec.Content)
+	}
+	return out
+}
@@ -0,0 +1,86 @@
+package storertest
+
+import (
+	"io"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/w-h-a/agent/memory_manager/providers/storer"
+)
+
+// RunTTL checks that s, configured with ttl, never returns a record older
+// than it even before the record is deleted. Records are backdated through
+// an import so s must be an Importer.
+func RunTTL(t *testing.T, s storer.Storer, ttl time.Duration) {
+	importer, ok := s.(storer.Importer)
+	if !ok {
+		t.Fatal("storer cannot import backdated records")
+	}
+
+	ctx := t.Context()
+
+	spaceId, cleanup := newSpace(ctx, s)
+	defer cleanup()
+
+	sessionId := uuid.New().String()
+	now := time.Now().UTC()
+
+	items := []storer.Item{
+		{Kind: storer.KindRecord, Record: &storer.Record{
+			Id: "stale", SpaceId: spaceId, SessionId: sessionId,
+			Content: "the stale launch code", Embedding: mix(Axis(0), Axis(1), 0.1),
+			CreatedAt: now.Add(-2 * ttl),
+		}},
+		{Kind: storer.KindRecord, Record: &storer.Record{
+			Id: "fresh", SpaceId: spaceId, SessionId: sessionId,
+			Content: "the fresh launch code", Embedding: Axis(0),
+			Metadata:  edges("stale"),
+			CreatedAt: now,
+		}},
+	}
+
+	if _, err := importer.Import(ctx, storer.ItemReaderFunc(func() (storer.Item, error) {
+		if len(items) == 0 {
+			return storer.Item{}, io.EOF
+		}
+		item := items[0]
+		items = items[1:]
+		return item, nil
+	})); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+
+	records, err := s.Search(ctx, spaceId, Axis(0), 10, nil)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+
+	if got := contents(records); !slices.Equal(got, []string{"the fresh launch code"}) {
+		t.Fatalf("search: want only the fresh record, got %v", got)
+	}
+
+	neighbors, err := s.SearchNeighborhood(ctx, []string{records[0].Id}, 1, 10, nil)
+	if err != nil {
+		t.Fatalf("neighborhood: %v", err)
+	}
+
+	if len(neighbors) != 0 {
+		t.Fatalf("neighborhood: want nothing, got %v", contents(neighbors))
+	}
+
+	if ls, ok := s.(storer.LexicalSearcher); ok {
+		matches, err := ls.SearchLexical(ctx, spaceId, "stale launch", 10, nil)
+		if err != nil {
+			t.Fatalf("lexical search: %v", err)
+		}
+		if slices.Contains(contents(matches), "the stale launch code") {
+			t.Fatalf("lexical search: got the stale record in %v", contents(matches))
+		}
+	}
+
+	if n, err := s.DeleteExpired(ctx); err != nil || n < 1 {
+		t.Fatalf("delete expired: got %d, %v, want at least 1", n, err)
+	}
+}
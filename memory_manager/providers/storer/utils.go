@@ -3,6 +3,7 @@ package storer
 import (
 	"encoding/json"
 	"strings"
+	"time"
 )
 
 func SanitizeEdges(metadata map[string]any) []map[string]string {
@@ -80,3 +81,60 @@ func SanitizeType(t string) string {
 	}
 	return t
 }
+
+func RemoveEdgesTo(metadata map[string]any, deleted map[string]struct{}) bool {
+	edges := ValidateEdges(metadata["edges"])
+	if len(edges) == 0 {
+		return false
+	}
+
+	kept := make([]map[string]string, 0, len(edges))
+	for _, edge := range edges {
+		if _, ok := deleted[edge["target"]]; !ok {
+			kept = append(kept, edge)
+		}
+	}
+
+	if len(kept) == len(edges) {
+		return false
+	}
+
+	if len(kept) == 0 {
+		delete(metadata, "edges")
+	} else {
+		metadata["edges"] = kept
+	}
+
+	return true
+}
+
+func AddEdges(metadata map[string]any, edges []map[string]string) {
+	merged := append(ValidateEdges(metadata["edges"]), edges...)
+
+	if valid := ValidateEdges(merged); len(valid) > 0 {
+		metadata["edges"] = valid
+	}
+}
+
+func ExpiredBefore(ttl time.Duration) (time.Time, bool) {
+	if ttl <= 0 {
+		return time.Time{}, false
+	}
+	return time.Now().UTC().Add(-ttl), true
+}
+
+// Unexpired narrows filter to records younger than ttl, so expired records
+// are never returned whether or not they have been deleted yet
+func Unexpired(ttl time.Duration, filter *Filter) *Filter {
+	cutoff, ok := ExpiredBefore(ttl)
+	if !ok {
+		return filter
+	}
+
+	fresh := Range(FieldCreatedAt, cutoff, nil)
+	if filter == nil {
+		return fresh
+	}
+
+	return And(filter, fresh)
+}
@@ -0,0 +1,33 @@
+package memorymanager
+
+import (
+	"context"
+
+	"github.com/w-h-a/agent/memory_manager/providers/embedder"
+)
+
+// Reembedder is implemented by memory managers that can move a space's
+// long-term memories onto another embedder while it keeps serving searches.
+// The job runs until done or stopped, whatever happens to the context it
+// was started with. Progress lives in the storer so calling Reembed again
+// after a failure or restart carries on from where the last job stopped.
+type Reembedder interface {
+	Reembed(ctx context.Context, spaceId string, next embedder.Embedder, opts ...ReembedOption) (ReembedJob, error)
+}
+
+type ReembedJob interface {
+	Progress() ReembedProgress
+	// Wait blocks until the space has moved over or the job failed
+	Wait() (ReembedProgress, error)
+	// Stop cancels the job, which carries on from where it stopped the next
+	// time the space is re-embedded
+	Stop()
+}
+
+type ReembedProgress struct {
+	SpaceId  string `json:"space_id"`
+	Model    string `json:"model"`
+	Staged   int    `json:"staged"`
+	Promoted int    `json:"promoted"`
+	Done     bool   `json:"done"`
+}
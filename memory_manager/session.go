@@ -0,0 +1,17 @@
+package memorymanager
+
+import "context"
+
+// SessionLister is implemented by memory managers that keep sessions
+// beyond the life of the process, so every session of a space can be
+// found and not only those created since start up
+type SessionLister interface {
+	ListSessionIds(ctx context.Context, spaceId string) ([]string, error)
+}
+
+// ChildSessionLister is implemented by memory managers that keep the
+// parent of each session, so a delegated conversation can be found again
+// after a restart. Ids are oldest first.
+type ChildSessionLister interface {
+	ListChildSessionIds(ctx context.Context, spaceId string, parentId string) ([]string, error)
+}
@@ -1,6 +1,7 @@
 package memorymanager
 
 import (
+	"context"
 	"encoding/json"
 )
 
@@ -11,3 +12,11 @@ type Task struct {
 	Data      json.RawMessage `json:"data"`
 	Status    string          `json:"status"`
 }
+
+// TaskManager is implemented by memory managers that let agents write the
+// tasks ListShortTerm returns
+type TaskManager interface {
+	// UpsertTask creates the task when its Id is empty and replaces it otherwise
+	UpsertTask(ctx context.Context, sessionId string, task Task) (Task, error)
+	DeleteTask(ctx context.Context, sessionId string, taskId string) error
+}
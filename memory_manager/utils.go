@@ -2,6 +2,7 @@ package memorymanager
 
 import (
 	"math"
+	"sort"
 
 	"github.com/w-h-a/agent/memory_manager/providers/storer"
 )
@@ -59,6 +60,44 @@ func Select(records []storer.Record, vec []float32, limit int, relevance float64
 	return selected
 }
 
+// FuseReciprocalRank merges ranked lists by summing 1/(k+rank) per record. Scores
+// are scaled so a record ranked first in every list scores 1.
+func FuseReciprocalRank(k int, lists ...[]storer.Record) []storer.Record {
+	if len(lists) == 0 {
+		return nil
+	}
+
+	scores := map[string]float64{}
+	fused := []storer.Record{}
+	index := map[string]int{}
+
+	for _, list := range lists {
+		for rank, rec := range list {
+			scores[rec.Id] += 1 / float64(k+rank+1)
+			if i, ok := index[rec.Id]; ok {
+				if len(fused[i].Embedding) == 0 {
+					fused[i].Embedding = rec.Embedding
+				}
+				continue
+			}
+			index[rec.Id] = len(fused)
+			fused = append(fused, rec)
+		}
+	}
+
+	best := float64(len(lists)) / float64(k+1)
+
+	for i := range fused {
+		fused[i].Score = float32(scores[fused[i].Id] / best)
+	}
+
+	sort.SliceStable(fused, func(i, j int) bool {
+		return fused[i].Score > fused[j].Score
+	})
+
+	return fused
+}
+
 func CosineSimilarity(a, b []float32) float64 {
 	if len(a) != len(b) || len(a) == 0 || len(b) == 0 {
 		return 0.0
@@ -78,12 +117,13 @@ func CosineSimilarity(a, b []float32) float64 {
 	return dotProduct / (math.Sqrt(normA) * math.Sqrt(normB))
 }
 
-func NormalizeWeights(weights Weights) (sim, rec float64) {
-	sum := weights.Similarity + weights.Recency
+func NormalizeWeights(weights Weights) (sim, rec, rerank float64) {
+	sum := weights.Similarity + weights.Recency + weights.Rerank
 	if sum == 0 {
-		return 0.5, 0.5
+		return 0.5, 0.5, 0
 	}
 	sim = weights.Similarity / sum
 	rec = weights.Recency / sum
+	rerank = weights.Rerank / sum
 	return
 }
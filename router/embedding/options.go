@@ -0,0 +1,36 @@
+package embedding
+
+import (
+	"context"
+
+	"github.com/w-h-a/agent/memory_manager/providers/embedder"
+	"github.com/w-h-a/agent/router"
+)
+
+type embedderKey struct{}
+
+func WithEmbedder(emb embedder.Embedder) router.Option {
+	return func(o *router.Options) {
+		o.Context = context.WithValue(o.Context, embedderKey{}, emb)
+	}
+}
+
+func EmbedderFrom(ctx context.Context) (embedder.Embedder, bool) {
+	emb, ok := ctx.Value(embedderKey{}).(embedder.Embedder)
+	return emb, ok
+}
+
+type thresholdKey struct{}
+
+// WithThreshold is the similarity a route must reach before it is picked
+// over the caller's default
+func WithThreshold(threshold float64) router.Option {
+	return func(o *router.Options) {
+		o.Context = context.WithValue(o.Context, thresholdKey{}, threshold)
+	}
+}
+
+func ThresholdFrom(ctx context.Context) (float64, bool) {
+	threshold, ok := ctx.Value(thresholdKey{}).(float64)
+	return threshold, ok
+}
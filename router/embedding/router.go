@@ -0,0 +1,112 @@
+package embedding
+
+import (
+	"context"
+	"sync"
+
+	memorymanager "github.com/w-h-a/agent/memory_manager"
+	"github.com/w-h-a/agent/memory_manager/providers/embedder"
+	"github.com/w-h-a/agent/router"
+)
+
+const defaultThreshold = 0.35
+
+type embeddingRouter struct {
+	options   router.Options
+	embedder  embedder.Embedder
+	threshold float64
+	vectors   map[string][]float32
+	mtx       sync.RWMutex
+}
+
+func (r *embeddingRouter) Route(ctx context.Context, input string, routes []router.Route) (string, error) {
+	if len(routes) == 0 {
+		return "", nil
+	}
+
+	vectors, err := r.routeVectors(ctx, routes)
+	if err != nil {
+		return "", err
+	}
+
+	query, err := r.embedder.Embed(ctx, input)
+	if err != nil {
+		return "", err
+	}
+
+	best, bestScore := "", r.threshold
+
+	for i, route := range routes {
+		if score := memorymanager.CosineSimilarity(query, vectors[i]); score >= bestScore {
+			best, bestScore = route.Name, score
+		}
+	}
+
+	return best, nil
+}
+
+// routeVectors embeds the routes' descriptions, reusing the vectors from
+// earlier calls since routes rarely change between messages
+func (r *embeddingRouter) routeVectors(ctx context.Context, routes []router.Route) ([][]float32, error) {
+	texts := make([]string, len(routes))
+	vectors := make([][]float32, len(routes))
+
+	var missing []int
+
+	r.mtx.RLock()
+	for i, route := range routes {
+		texts[i] = route.Name + ": " + route.Description
+		if vec, ok := r.vectors[texts[i]]; ok {
+			vectors[i] = vec
+			continue
+		}
+		missing = append(missing, i)
+	}
+	r.mtx.RUnlock()
+
+	if len(missing) == 0 {
+		return vectors, nil
+	}
+
+	batch := make([]string, 0, len(missing))
+	for _, i := range missing {
+		batch = append(batch, texts[i])
+	}
+
+	embedded, err := r.embedder.EmbedBatch(ctx, batch)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	for j, i := range missing {
+		vectors[i] = embedded[j]
+		r.vectors[texts[i]] = embedded[j]
+	}
+
+	return vectors, nil
+}
+
+func NewRouter(opts ...router.Option) router.Router {
+	options := router.NewOptions(opts...)
+
+	emb, ok := EmbedderFrom(options.Context)
+	if !ok || emb == nil {
+		panic("missing embedder for embedding router")
+	}
+
+	threshold, ok := ThresholdFrom(options.Context)
+	if !ok {
+		threshold = defaultThreshold
+	}
+
+	return &embeddingRouter{
+		options:   options,
+		embedder:  emb,
+		threshold: threshold,
+		vectors:   map[string][]float32{},
+		mtx:       sync.RWMutex{},
+	}
+}
@@ -0,0 +1,21 @@
+package llm
+
+import (
+	"context"
+
+	"github.com/w-h-a/agent/generator"
+	"github.com/w-h-a/agent/router"
+)
+
+type generatorKey struct{}
+
+func WithGenerator(gen generator.Generator) router.Option {
+	return func(o *router.Options) {
+		o.Context = context.WithValue(o.Context, generatorKey{}, gen)
+	}
+}
+
+func GeneratorFrom(ctx context.Context) (generator.Generator, bool) {
+	gen, ok := ctx.Value(generatorKey{}).(generator.Generator)
+	return gen, ok
+}
@@ -0,0 +1,72 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/w-h-a/agent/generator"
+	"github.com/w-h-a/agent/router"
+	extractjson "github.com/w-h-a/agent/util/extract_json"
+)
+
+type llmRouter struct {
+	options   router.Options
+	generator generator.Generator
+}
+
+func (r *llmRouter) Route(ctx context.Context, input string, routes []router.Route) (string, error) {
+	if len(routes) == 0 {
+		return "", nil
+	}
+
+	if len(routes) == 1 {
+		return routes[0].Name, nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("You route a user's message to the agent best placed to handle it.\n\n")
+	sb.WriteString("Agents:\n")
+	for _, route := range routes {
+		sb.WriteString(fmt.Sprintf("- %s: %s\n", route.Name, strings.ReplaceAll(route.Description, "\n", " ")))
+	}
+	sb.WriteString("\nMessage:\n")
+	sb.WriteString(input)
+	sb.WriteString("\n\nReply with only a JSON object such as {\"agent\": \"<name>\"}.\n")
+
+	rsp, err := r.generator.Generate(ctx, sb.String())
+	if err != nil {
+		return "", err
+	}
+
+	var picked struct {
+		Agent string `json:"agent"`
+	}
+
+	if err := extractjson.Into(rsp, &picked); err != nil {
+		return "", fmt.Errorf("failed to parse routing decision: %w", err)
+	}
+
+	// a name the model made up counts as no decision
+	for _, route := range routes {
+		if strings.EqualFold(strings.TrimSpace(picked.Agent), route.Name) {
+			return route.Name, nil
+		}
+	}
+
+	return "", nil
+}
+
+func NewRouter(opts ...router.Option) router.Router {
+	options := router.NewOptions(opts...)
+
+	gen, ok := GeneratorFrom(options.Context)
+	if !ok || gen == nil {
+		panic("missing generator for llm router")
+	}
+
+	return &llmRouter{
+		options:   options,
+		generator: gen,
+	}
+}
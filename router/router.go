@@ -0,0 +1,15 @@
+package router
+
+import "context"
+
+// Route is an agent a router can send a message to
+type Route struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type Router interface {
+	// Route picks the route that should handle input. An empty name means
+	// no route stood out and the caller should fall back to its default.
+	Route(ctx context.Context, input string, routes []Route) (string, error)
+}
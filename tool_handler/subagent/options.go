@@ -0,0 +1,76 @@
+package subagent
+
+import (
+	"context"
+
+	toolhandler "github.com/w-h-a/agent/tool_handler"
+)
+
+type agentKey struct{}
+
+func WithAgent(a Agent) toolhandler.Option {
+	return func(o *toolhandler.Options) {
+		o.Context = context.WithValue(o.Context, agentKey{}, a)
+	}
+}
+
+func AgentFrom(ctx context.Context) (Agent, bool) {
+	a, ok := ctx.Value(agentKey{}).(Agent)
+	return a, ok
+}
+
+type spaceIdKey struct{}
+
+// WithSpaceId is the sub-agent's own space. Its child sessions and what it
+// learns in them stay there, apart from the coordinator's memory.
+func WithSpaceId(spaceId string) toolhandler.Option {
+	return func(o *toolhandler.Options) {
+		o.Context = context.WithValue(o.Context, spaceIdKey{}, spaceId)
+	}
+}
+
+func SpaceIdFrom(ctx context.Context) (string, bool) {
+	spaceId, ok := ctx.Value(spaceIdKey{}).(string)
+	return spaceId, ok
+}
+
+type nameKey struct{}
+
+func WithToolName(name string) toolhandler.Option {
+	return func(o *toolhandler.Options) {
+		o.Context = context.WithValue(o.Context, nameKey{}, name)
+	}
+}
+
+func ToolNameFrom(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(nameKey{}).(string)
+	return name, ok
+}
+
+type descriptionKey struct{}
+
+func WithDescription(description string) toolhandler.Option {
+	return func(o *toolhandler.Options) {
+		o.Context = context.WithValue(o.Context, descriptionKey{}, description)
+	}
+}
+
+func DescriptionFrom(ctx context.Context) (string, bool) {
+	description, ok := ctx.Value(descriptionKey{}).(string)
+	return description, ok
+}
+
+type maxDepthKey struct{}
+
+// WithMaxDepth caps how deep delegation may nest below the coordinator,
+// so sub-agents that call each other cannot recurse forever
+func WithMaxDepth(depth int) toolhandler.Option {
+	return func(o *toolhandler.Options) {
+		o.Context = context.WithValue(o.Context, maxDepthKey{}, depth)
+	}
+}
+
+func MaxDepthFrom(ctx context.Context) (int, bool) {
+	depth, ok := ctx.Value(maxDepthKey{}).(int)
+	return depth, ok
+}
@@ -0,0 +1,146 @@
+package subagent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/w-h-a/agent/internal/service/agent"
+	memorymanager "github.com/w-h-a/agent/memory_manager"
+	toolhandler "github.com/w-h-a/agent/tool_handler"
+)
+
+const defaultMaxDepth = 3
+
+// Agent is the part of a configured agent a coordinator needs to delegate
+// to it. *agent.ADK satisfies it.
+type Agent interface {
+	ChildSessionId(ctx context.Context, spaceId string, parentId string) (string, error)
+	CreateChildSession(ctx context.Context, spaceId string, parentId string) (string, error)
+	Generate(ctx context.Context, sessionId string, userInput string, files map[string]memorymanager.InputFile) (string, error)
+}
+
+type subAgentToolHandler struct {
+	options  toolhandler.Options
+	agent    Agent
+	spaceId  string
+	spec     toolhandler.ToolSpec
+	maxDepth int
+}
+
+func (th *subAgentToolHandler) Spec() toolhandler.ToolSpec {
+	return th.spec
+}
+
+func (th *subAgentToolHandler) Invoke(ctx context.Context, req toolhandler.ToolRequest) (toolhandler.ToolResponse, error) {
+	if depth := agent.Depth(ctx); depth > th.maxDepth {
+		return toolhandler.ToolResponse{}, fmt.Errorf("sub-agent %s not called: delegation is already %d deep", th.spec.Name, depth)
+	}
+
+	task := argument(req.Arguments, "task")
+	if len(task) == 0 {
+		task = argument(req.Arguments, "input")
+	}
+	if len(task) == 0 {
+		return toolhandler.ToolResponse{}, errors.New("missing 'task' argument")
+	}
+
+	childId, err := th.child(ctx, req.SessionId)
+	if err != nil {
+		return toolhandler.ToolResponse{}, fmt.Errorf("failed to start sub-agent session: %w", err)
+	}
+
+	// ctx carries the parent's cancellation, depth and usage meter
+	answer, err := th.agent.Generate(ctx, childId, task, nil)
+	if err != nil {
+		return toolhandler.ToolResponse{}, fmt.Errorf("sub-agent %s failed: %w", th.spec.Name, err)
+	}
+
+	return toolhandler.ToolResponse{
+		Content: answer,
+		Metadata: map[string]string{
+			"sub_agent":     th.spec.Name,
+			"child_session": childId,
+		},
+	}, nil
+}
+
+// child returns the session the sub-agent keeps for parentId so follow up
+// questions in the same conversation see its earlier work. The agent
+// remembers which session that is, so it survives restarts and a deleted
+// child is simply replaced. Two first calls racing may each start one;
+// the latest wins from then on.
+func (th *subAgentToolHandler) child(ctx context.Context, parentId string) (string, error) {
+	id, err := th.agent.ChildSessionId(ctx, th.spaceId, parentId)
+	if err != nil {
+		return "", err
+	}
+
+	if len(id) > 0 {
+		return id, nil
+	}
+
+	return th.agent.CreateChildSession(ctx, th.spaceId, parentId)
+}
+
+func argument(args map[string]any, key string) string {
+	v, ok := args[key].(string)
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(v)
+}
+
+func NewToolHandler(opts ...toolhandler.Option) toolhandler.ToolHandler {
+	options := toolhandler.NewOptions(opts...)
+
+	a, ok := AgentFrom(options.Context)
+	if !ok || a == nil {
+		panic("missing agent for sub-agent tool handler")
+	}
+
+	name, _ := ToolNameFrom(options.Context)
+	if len(strings.TrimSpace(name)) == 0 {
+		panic("missing tool name for sub-agent tool handler")
+	}
+
+	spaceId, _ := SpaceIdFrom(options.Context)
+	if len(strings.TrimSpace(spaceId)) == 0 {
+		panic("missing space id for sub-agent tool handler")
+	}
+
+	description, ok := DescriptionFrom(options.Context)
+	if !ok || len(description) == 0 {
+		description = fmt.Sprintf("Delegates a task to the %s sub-agent and returns its final answer.", name)
+	}
+
+	maxDepth, ok := MaxDepthFrom(options.Context)
+	if !ok || maxDepth < 1 {
+		maxDepth = defaultMaxDepth
+	}
+
+	return &subAgentToolHandler{
+		options: options,
+		agent:   a,
+		spaceId: spaceId,
+		spec: toolhandler.ToolSpec{
+			Name:        name,
+			Description: description,
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"task": map[string]any{
+						"type":        "string",
+						"description": "Everything the sub-agent needs to know to do the task on its own.",
+					},
+				},
+				"required": []string{"task"},
+			},
+			Examples: []map[string]any{
+				{"task": "Summarise the open incidents and suggest next steps."},
+			},
+		},
+		maxDepth: maxDepth,
+	}
+}
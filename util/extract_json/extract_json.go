@@ -0,0 +1,71 @@
+package extractjson
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var ErrNotFound = errors.New("no json found in text")
+
+func Into(text string, v any) error {
+	raw, err := Find(text)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(raw), v)
+}
+
+func Find(text string) (string, error) {
+	text = strings.TrimSpace(text)
+
+	for start := 0; start < len(text); start++ {
+		if text[start] != '{' && text[start] != '[' {
+			continue
+		}
+		if end := matchClose(text, start); end > start {
+			candidate := text[start : end+1]
+			if json.Valid([]byte(candidate)) {
+				return candidate, nil
+			}
+		}
+	}
+
+	return "", ErrNotFound
+}
+
+func matchClose(text string, start int) int {
+	depth := 0
+	inString := false
+	escaped := false
+
+	for i := start; i < len(text); i++ {
+		c := text[i]
+
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
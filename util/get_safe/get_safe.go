@@ -33,3 +33,18 @@ func Metadata(payload map[string]any, key string) map[string]any {
 	}
 	return nil
 }
+
+// Float32s reads a vector that came back as a list of json or driver numbers
+func Float32s(payload map[string]any, key string) []float32 {
+	v, ok := payload[key].([]any)
+	if !ok {
+		return nil
+	}
+	out := make([]float32, 0, len(v))
+	for _, f := range v {
+		if x, ok := f.(float64); ok {
+			out = append(out, float32(x))
+		}
+	}
+	return out
+}
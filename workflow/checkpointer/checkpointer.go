@@ -0,0 +1,40 @@
+package checkpointer
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrNotFound = errors.New("checkpoint not found")
+
+type Status string
+
+const (
+	StatusRunning Status = "running"
+	StatusWaiting Status = "waiting"
+	StatusDone    Status = "done"
+	StatusFailed  Status = "failed"
+)
+
+// Checkpoint is a workflow run as of its last completed step. Node is the
+// next node to run, or the human node the run is waiting on.
+type Checkpoint struct {
+	RunId     string         `json:"run_id"`
+	Workflow  string         `json:"workflow"`
+	SessionId string         `json:"session_id,omitempty"`
+	Status    Status         `json:"status"`
+	Node      string         `json:"node,omitempty"`
+	State     map[string]any `json:"state"`
+	Steps     int            `json:"steps"`
+	Prompt    string         `json:"prompt,omitempty"`
+	Error     string         `json:"error,omitempty"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+type Checkpointer interface {
+	// Save replaces the run's previous checkpoint
+	Save(ctx context.Context, checkpoint Checkpoint) error
+	Load(ctx context.Context, runId string) (Checkpoint, error)
+	Delete(ctx context.Context, runId string) error
+}
@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/w-h-a/agent/workflow/checkpointer"
+)
+
+type memoryCheckpointer struct {
+	options     checkpointer.Options
+	checkpoints map[string][]byte
+	mtx         sync.RWMutex
+}
+
+func (c *memoryCheckpointer) Save(ctx context.Context, checkpoint checkpointer.Checkpoint) error {
+	// stored encoded so later changes to the run's state cannot reach it
+	bs, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.checkpoints[checkpoint.RunId] = bs
+
+	return nil
+}
+
+func (c *memoryCheckpointer) Load(ctx context.Context, runId string) (checkpointer.Checkpoint, error) {
+	c.mtx.RLock()
+	bs, ok := c.checkpoints[runId]
+	c.mtx.RUnlock()
+
+	if !ok {
+		return checkpointer.Checkpoint{}, checkpointer.ErrNotFound
+	}
+
+	var checkpoint checkpointer.Checkpoint
+	if err := json.Unmarshal(bs, &checkpoint); err != nil {
+		return checkpointer.Checkpoint{}, err
+	}
+
+	return checkpoint, nil
+}
+
+func (c *memoryCheckpointer) Delete(ctx context.Context, runId string) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	delete(c.checkpoints, runId)
+
+	return nil
+}
+
+func NewCheckpointer(opts ...checkpointer.Option) checkpointer.Checkpointer {
+	options := checkpointer.NewOptions(opts...)
+
+	return &memoryCheckpointer{
+		options:     options,
+		checkpoints: map[string][]byte{},
+		mtx:         sync.RWMutex{},
+	}
+}
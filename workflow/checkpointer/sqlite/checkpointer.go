@@ -0,0 +1,111 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/w-h-a/agent/workflow/checkpointer"
+	_ "modernc.org/sqlite"
+)
+
+const (
+	schema = `
+CREATE TABLE IF NOT EXISTS workflow_checkpoints (
+	run_id     TEXT PRIMARY KEY,
+	workflow   TEXT NOT NULL,
+	status     TEXT NOT NULL,
+	checkpoint TEXT NOT NULL,
+	updated_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS workflow_checkpoints_status_idx ON workflow_checkpoints (status);
+`
+)
+
+type sqliteCheckpointer struct {
+	options checkpointer.Options
+	conn    *sql.DB
+}
+
+func (c *sqliteCheckpointer) Save(ctx context.Context, checkpoint checkpointer.Checkpoint) error {
+	bs, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.conn.ExecContext(
+		ctx,
+		`INSERT INTO workflow_checkpoints (run_id, workflow, status, checkpoint, updated_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (run_id) DO UPDATE SET status = excluded.status, checkpoint = excluded.checkpoint, updated_at = excluded.updated_at`,
+		checkpoint.RunId, checkpoint.Workflow, string(checkpoint.Status), string(bs), checkpoint.UpdatedAt.UnixNano(),
+	)
+
+	return err
+}
+
+func (c *sqliteCheckpointer) Load(ctx context.Context, runId string) (checkpointer.Checkpoint, error) {
+	var raw string
+
+	err := c.conn.QueryRowContext(ctx, `SELECT checkpoint FROM workflow_checkpoints WHERE run_id = ?`, runId).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return checkpointer.Checkpoint{}, checkpointer.ErrNotFound
+	}
+	if err != nil {
+		return checkpointer.Checkpoint{}, err
+	}
+
+	var checkpoint checkpointer.Checkpoint
+	if err := json.Unmarshal([]byte(raw), &checkpoint); err != nil {
+		return checkpointer.Checkpoint{}, err
+	}
+
+	return checkpoint, nil
+}
+
+func (c *sqliteCheckpointer) Delete(ctx context.Context, runId string) error {
+	_, err := c.conn.ExecContext(ctx, `DELETE FROM workflow_checkpoints WHERE run_id = ?`, runId)
+	return err
+}
+
+func dsn(location string) string {
+	if len(location) == 0 {
+		location = "workflows.db"
+	}
+
+	pragmas := "_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_pragma=synchronous(FULL)"
+
+	if strings.Contains(location, "?") {
+		return location + "&" + pragmas
+	}
+
+	return location + "?" + pragmas
+}
+
+func NewCheckpointer(opts ...checkpointer.Option) checkpointer.Checkpointer {
+	options := checkpointer.NewOptions(opts...)
+
+	c := &sqliteCheckpointer{
+		options: options,
+	}
+
+	// workflows.db or file:/path/to/workflows.db
+	conn, err := sql.Open("sqlite", dsn(options.Location))
+	if err != nil {
+		detail := "failed to open sqlite checkpointer"
+		slog.ErrorContext(context.Background(), detail, "error", err)
+		panic(detail)
+	}
+
+	if _, err := conn.Exec(schema); err != nil {
+		detail := "failed to create sqlite checkpointer schema"
+		slog.ErrorContext(context.Background(), detail, "error", err)
+		panic(detail)
+	}
+
+	c.conn = conn
+
+	return c
+}
@@ -0,0 +1,267 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultMaxSteps = 100
+
+type Kind string
+
+const (
+	KindLLM      Kind = "llm"
+	KindTool     Kind = "tool"
+	KindAgent    Kind = "agent"
+	KindFunc     Kind = "func"
+	KindBranch   Kind = "branch"
+	KindParallel Kind = "parallel"
+	KindHuman    Kind = "human"
+)
+
+// Type is the type a state field holds. Values written to a field are
+// checked against it and model output is parsed into it.
+type Type string
+
+const (
+	TypeString Type = "string"
+	TypeNumber Type = "number"
+	TypeBool   Type = "bool"
+	TypeList   Type = "list"
+	TypeObject Type = "object"
+	TypeAny    Type = "any"
+)
+
+// Definition is a directed graph of nodes starting at Start. A node with
+// no Next ends the run. Templates in prompts, inputs, arguments and
+// conditions are text/template over the state, as in {{.question}}.
+type Definition struct {
+	Name     string          `json:"name" yaml:"name"`
+	Start    string          `json:"start" yaml:"start"`
+	State    map[string]Type `json:"state" yaml:"state"`
+	Nodes    []Node          `json:"nodes" yaml:"nodes"`
+	MaxSteps int             `json:"max_steps,omitempty" yaml:"max_steps,omitempty"`
+}
+
+type Node struct {
+	Id   string `json:"id" yaml:"id"`
+	Kind Kind   `json:"kind" yaml:"kind"`
+	// Prompt is the llm prompt or the question put to a human
+	Prompt string `json:"prompt,omitempty" yaml:"prompt,omitempty"`
+	// Recall adds long-term memories relevant to the prompt to an llm node
+	Recall bool `json:"recall,omitempty" yaml:"recall,omitempty"`
+	// Tool, Agent and Func name what tool, agent and func nodes call
+	Tool      string            `json:"tool,omitempty" yaml:"tool,omitempty"`
+	Arguments map[string]string `json:"arguments,omitempty" yaml:"arguments,omitempty"`
+	Agent     string            `json:"agent,omitempty" yaml:"agent,omitempty"`
+	Func      string            `json:"func,omitempty" yaml:"func,omitempty"`
+	Input     string            `json:"input,omitempty" yaml:"input,omitempty"`
+	// Output is the state field the node's result is written to
+	Output string `json:"output,omitempty" yaml:"output,omitempty"`
+	// Cases are tried in order by a branch node, which falls back to Next
+	Cases []Case `json:"cases,omitempty" yaml:"cases,omitempty"`
+	// Parallel lists the nodes a parallel node runs at once before Next
+	Parallel []string `json:"parallel,omitempty" yaml:"parallel,omitempty"`
+	Next     string   `json:"next,omitempty" yaml:"next,omitempty"`
+}
+
+type Case struct {
+	// When is a template that renders "true" for the case to be taken
+	When string `json:"when" yaml:"when"`
+	Next string `json:"next" yaml:"next"`
+}
+
+func LLM(id string, prompt string, output string) Node {
+	return Node{Id: id, Kind: KindLLM, Prompt: prompt, Output: output}
+}
+
+func Tool(id string, tool string, arguments map[string]string, output string) Node {
+	return Node{Id: id, Kind: KindTool, Tool: tool, Arguments: arguments, Output: output}
+}
+
+func Agent(id string, agent string, input string, output string) Node {
+	return Node{Id: id, Kind: KindAgent, Agent: agent, Input: input, Output: output}
+}
+
+func Call(id string, fn string) Node {
+	return Node{Id: id, Kind: KindFunc, Func: fn}
+}
+
+func Branch(id string, otherwise string, cases ...Case) Node {
+	return Node{Id: id, Kind: KindBranch, Cases: cases, Next: otherwise}
+}
+
+func Parallel(id string, nodes ...string) Node {
+	return Node{Id: id, Kind: KindParallel, Parallel: nodes}
+}
+
+func Human(id string, prompt string, output string) Node {
+	return Node{Id: id, Kind: KindHuman, Prompt: prompt, Output: output}
+}
+
+// Then returns the node continuing to next
+func (n Node) Then(next string) Node {
+	n.Next = next
+	return n
+}
+
+// Load reads a definition from YAML and validates it
+func Load(r io.Reader) (*Definition, error) {
+	var def Definition
+
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+
+	if err := dec.Decode(&def); err != nil {
+		return nil, fmt.Errorf("failed to parse workflow: %w", err)
+	}
+
+	if err := def.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &def, nil
+}
+
+func LoadFile(path string) (*Definition, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Load(f)
+}
+
+// Validate checks the graph is well formed: every edge leads to a node,
+// every output is a declared field and every node has what its kind needs
+func (d *Definition) Validate() error {
+	if len(d.Name) == 0 {
+		return errors.New("workflow name is required")
+	}
+
+	nodes := make(map[string]Node, len(d.Nodes))
+	for _, n := range d.Nodes {
+		if len(n.Id) == 0 {
+			return errors.New("workflow node id is required")
+		}
+		if _, ok := nodes[n.Id]; ok {
+			return fmt.Errorf("workflow node %s is defined twice", n.Id)
+		}
+		nodes[n.Id] = n
+	}
+
+	if _, ok := nodes[d.Start]; !ok {
+		return fmt.Errorf("workflow start node %q is not defined", d.Start)
+	}
+
+	for name, t := range d.State {
+		if !slices.Contains([]Type{TypeString, TypeNumber, TypeBool, TypeList, TypeObject, TypeAny}, t) {
+			return fmt.Errorf("state field %s has unknown type %q", name, t)
+		}
+	}
+
+	edge := func(n Node, target string) error {
+		if _, ok := nodes[target]; !ok {
+			return fmt.Errorf("workflow node %s leads to undefined node %q", n.Id, target)
+		}
+		return nil
+	}
+
+	for _, n := range d.Nodes {
+		if len(n.Next) > 0 {
+			if err := edge(n, n.Next); err != nil {
+				return err
+			}
+		}
+
+		if len(n.Output) > 0 {
+			if _, ok := d.State[n.Output]; !ok {
+				return fmt.Errorf("workflow node %s writes undeclared state field %q", n.Id, n.Output)
+			}
+		}
+
+		var missing string
+
+		switch n.Kind {
+		case KindLLM:
+			if len(n.Prompt) == 0 {
+				missing = "prompt"
+			}
+		case KindTool:
+			if len(n.Tool) == 0 {
+				missing = "tool"
+			}
+		case KindAgent:
+			if len(n.Agent) == 0 {
+				missing = "agent"
+			} else if len(n.Input) == 0 {
+				missing = "input"
+			}
+		case KindFunc:
+			if len(n.Func) == 0 {
+				missing = "func"
+			}
+		case KindHuman:
+			if len(n.Output) == 0 {
+				missing = "output"
+			}
+		case KindBranch:
+			if len(n.Cases) == 0 {
+				missing = "cases"
+			}
+			for _, c := range n.Cases {
+				if err := edge(n, c.Next); err != nil {
+					return err
+				}
+			}
+		case KindParallel:
+			if len(n.Parallel) == 0 {
+				missing = "parallel"
+			}
+			for _, id := range n.Parallel {
+				if err := edge(n, id); err != nil {
+					return err
+				}
+				// branches run as single steps so they cannot steer or pause the run
+				switch nodes[id].Kind {
+				case KindBranch, KindParallel, KindHuman:
+					return fmt.Errorf("workflow node %s cannot run %s node %s in parallel", n.Id, nodes[id].Kind, id)
+				}
+				// the parallel node's next follows every branch, so a branch's own is never taken
+				if len(nodes[id].Next) > 0 {
+					return fmt.Errorf("workflow node %s runs node %s in parallel, which cannot have its own next", n.Id, id)
+				}
+			}
+		default:
+			return fmt.Errorf("workflow node %s has unknown kind %q", n.Id, n.Kind)
+		}
+
+		if len(missing) > 0 {
+			return fmt.Errorf("workflow %s node %s needs %s", n.Kind, n.Id, missing)
+		}
+	}
+
+	return nil
+}
+
+func (d *Definition) node(id string) (Node, bool) {
+	for _, n := range d.Nodes {
+		if n.Id == id {
+			return n, true
+		}
+	}
+	return Node{}, false
+}
+
+func (d *Definition) maxSteps() int {
+	if d.MaxSteps > 0 {
+		return d.MaxSteps
+	}
+	return defaultMaxSteps
+}
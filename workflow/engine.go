@@ -0,0 +1,382 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/w-h-a/agent/internal/service/agent"
+	memorymanager "github.com/w-h-a/agent/memory_manager"
+	toolhandler "github.com/w-h-a/agent/tool_handler"
+	"github.com/w-h-a/agent/workflow/checkpointer"
+	memorycheckpointer "github.com/w-h-a/agent/workflow/checkpointer/memory"
+)
+
+// Run is where a workflow run stands, as saved after its last step
+type Run = checkpointer.Checkpoint
+
+type Engine struct {
+	options      Options
+	catalog      *agent.ToolCatalog
+	checkpointer checkpointer.Checkpointer
+}
+
+// Start runs def from its start node with the given initial state until it
+// finishes, fails or waits on a human. sessionId may be empty when no node
+// recalls memories or calls an agent.
+func (e *Engine) Start(ctx context.Context, def *Definition, sessionId string, input map[string]any) (Run, error) {
+	if err := e.check(def); err != nil {
+		return Run{}, err
+	}
+
+	state, err := newState(def.State, input)
+	if err != nil {
+		return Run{}, err
+	}
+
+	run := Run{
+		RunId:     uuid.New().String(),
+		Workflow:  def.Name,
+		SessionId: sessionId,
+		Status:    checkpointer.StatusRunning,
+		Node:      def.Start,
+		State:     state.Snapshot(),
+	}
+
+	if err := e.save(ctx, &run); err != nil {
+		return run, err
+	}
+
+	return e.execute(ctx, def, run, state)
+}
+
+// Resume carries on a run from its last checkpoint: after a crash, after a
+// failed step, or with the answer a waiting human node asked for. Steps
+// that were cut off midway run again.
+func (e *Engine) Resume(ctx context.Context, def *Definition, runId string, answer string) (Run, error) {
+	if err := e.check(def); err != nil {
+		return Run{}, err
+	}
+
+	run, err := e.checkpointer.Load(ctx, runId)
+	if err != nil {
+		return Run{}, err
+	}
+
+	if run.Workflow != def.Name {
+		return run, fmt.Errorf("run %s belongs to workflow %s, not %s", runId, run.Workflow, def.Name)
+	}
+
+	state, err := newState(def.State, run.State)
+	if err != nil {
+		return run, err
+	}
+
+	switch run.Status {
+	case checkpointer.StatusDone:
+		return run, nil
+	case checkpointer.StatusWaiting:
+		node, ok := def.node(run.Node)
+		if !ok {
+			return run, fmt.Errorf("run %s waits on undefined node %s", runId, run.Node)
+		}
+		if err := setOutput(def, node, state, answer); err != nil {
+			return run, err
+		}
+		run.Node = node.Next
+		run.Steps++
+		run.Prompt = ""
+	}
+
+	run.Status = checkpointer.StatusRunning
+	run.Error = ""
+	run.State = state.Snapshot()
+
+	// the answer is saved before anything else runs so a crash cannot lose it
+	if err := e.save(ctx, &run); err != nil {
+		return run, err
+	}
+
+	return e.execute(ctx, def, run, state)
+}
+
+func (e *Engine) Get(ctx context.Context, runId string) (Run, error) {
+	return e.checkpointer.Load(ctx, runId)
+}
+
+func (e *Engine) execute(ctx context.Context, def *Definition, run Run, state *State) (Run, error) {
+	for {
+		run.State = state.Snapshot()
+
+		if len(run.Node) == 0 {
+			run.Status = checkpointer.StatusDone
+			return run, e.save(ctx, &run)
+		}
+
+		// a cancelled run keeps its checkpoint so it can be resumed
+		if err := ctx.Err(); err != nil {
+			return run, err
+		}
+
+		if run.Steps >= def.maxSteps() {
+			return e.fail(ctx, run, fmt.Errorf("workflow %s exceeded %d steps", def.Name, def.maxSteps()))
+		}
+
+		node, ok := def.node(run.Node)
+		if !ok {
+			return e.fail(ctx, run, fmt.Errorf("workflow %s has no node %s", def.Name, run.Node))
+		}
+
+		if node.Kind == KindHuman {
+			prompt, err := render(node.Prompt, state.view())
+			if err != nil {
+				return e.fail(ctx, run, fmt.Errorf("node %s: %w", node.Id, err))
+			}
+			run.Status = checkpointer.StatusWaiting
+			run.Prompt = prompt
+			return run, e.save(ctx, &run)
+		}
+
+		next, err := e.step(ctx, def, run, state, node)
+		if err != nil {
+			if ctx.Err() != nil {
+				return run, err
+			}
+			run.State = state.Snapshot()
+			return e.fail(ctx, run, fmt.Errorf("node %s: %w", node.Id, err))
+		}
+
+		run.Node = next
+		run.Steps++
+		run.State = state.Snapshot()
+
+		if err := e.save(ctx, &run); err != nil {
+			return run, err
+		}
+	}
+}
+
+// step runs one node and returns the node to go to next
+func (e *Engine) step(ctx context.Context, def *Definition, run Run, state *State, node Node) (string, error) {
+	switch node.Kind {
+	case KindLLM:
+		return node.Next, e.llm(ctx, def, run, state, node)
+	case KindTool:
+		return node.Next, e.tool(ctx, def, run, state, node)
+	case KindAgent:
+		return node.Next, e.agent(ctx, def, run, state, node)
+	case KindFunc:
+		return node.Next, e.options.Funcs[node.Func](ctx, state)
+	case KindBranch:
+		view := state.view()
+		for _, c := range node.Cases {
+			taken, err := render(c.When, view)
+			if err != nil {
+				return "", err
+			}
+			if strings.TrimSpace(taken) == "true" {
+				return c.Next, nil
+			}
+		}
+		return node.Next, nil
+	case KindParallel:
+		return node.Next, e.parallel(ctx, def, run, state, node)
+	default:
+		return "", fmt.Errorf("unknown node kind %q", node.Kind)
+	}
+}
+
+func (e *Engine) llm(ctx context.Context, def *Definition, run Run, state *State, node Node) error {
+	prompt, err := render(node.Prompt, state.view())
+	if err != nil {
+		return err
+	}
+
+	if node.Recall && e.options.Memory != nil && len(run.SessionId) > 0 {
+		msgs, _, _, err := e.options.Memory.SearchLongTerm(ctx, run.SessionId, prompt, memorymanager.WithSearchLongTermLimit(e.options.RecallLimit))
+		if err != nil {
+			return fmt.Errorf("failed to recall memories: %w", err)
+		}
+		prompt = withMemories(prompt, msgs)
+	}
+
+	reply, err := e.options.Generator.Generate(ctx, prompt)
+	if err != nil {
+		return err
+	}
+
+	return setOutput(def, node, state, reply)
+}
+
+func (e *Engine) tool(ctx context.Context, def *Definition, run Run, state *State, node Node) error {
+	th, _, ok := e.catalog.Get(node.Tool)
+	if !ok {
+		return fmt.Errorf("unknown tool: %s", node.Tool)
+	}
+
+	view := state.view()
+	args := make(map[string]any, len(node.Arguments))
+
+	for name, text := range node.Arguments {
+		value, err := render(text, view)
+		if err != nil {
+			return fmt.Errorf("argument %s: %w", name, err)
+		}
+		args[name] = value
+	}
+
+	rsp, err := th.Invoke(ctx, toolhandler.ToolRequest{SessionId: run.SessionId, Arguments: args})
+	if err != nil {
+		return err
+	}
+
+	return setOutput(def, node, state, rsp.Content)
+}
+
+func (e *Engine) agent(ctx context.Context, def *Definition, run Run, state *State, node Node) error {
+	cfg := e.options.Agents[node.Agent]
+
+	input, err := render(node.Input, state.view())
+	if err != nil {
+		return err
+	}
+
+	childId, err := cfg.Agent.CreateChildSession(ctx, cfg.SpaceId, run.SessionId)
+	if err != nil {
+		return fmt.Errorf("failed to start agent session: %w", err)
+	}
+
+	answer, err := cfg.Agent.Generate(ctx, childId, input, nil)
+	if err != nil {
+		return err
+	}
+
+	return setOutput(def, node, state, answer)
+}
+
+// parallel fans out to its nodes and fans back in once all have written
+// their outputs. The first failure cancels the rest.
+func (e *Engine) parallel(ctx context.Context, def *Definition, run Run, state *State, node Node) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(node.Parallel))
+
+	for _, id := range node.Parallel {
+		branch, _ := def.node(id)
+		wg.Go(func() {
+			if _, err := e.step(ctx, def, run, state, branch); err != nil {
+				errs <- fmt.Errorf("node %s: %w", branch.Id, err)
+				cancel()
+			}
+		})
+	}
+
+	wg.Wait()
+	close(errs)
+
+	return <-errs
+}
+
+func (e *Engine) fail(ctx context.Context, run Run, err error) (Run, error) {
+	run.Status = checkpointer.StatusFailed
+	run.Error = err.Error()
+
+	if saveErr := e.save(ctx, &run); saveErr != nil {
+		return run, errors.Join(err, saveErr)
+	}
+
+	return run, err
+}
+
+func (e *Engine) save(ctx context.Context, run *Run) error {
+	run.UpdatedAt = time.Now().UTC()
+
+	// a checkpoint must land even when the run was just cancelled
+	if err := e.checkpointer.Save(context.WithoutCancel(ctx), *run); err != nil {
+		return fmt.Errorf("failed to checkpoint run %s: %w", run.RunId, err)
+	}
+
+	return nil
+}
+
+// check confirms the engine has what def's nodes call on
+func (e *Engine) check(def *Definition) error {
+	if err := def.Validate(); err != nil {
+		return err
+	}
+
+	for _, n := range def.Nodes {
+		switch n.Kind {
+		case KindLLM:
+			if e.options.Generator == nil {
+				return fmt.Errorf("workflow llm node %s needs a generator", n.Id)
+			}
+		case KindTool:
+			if _, _, ok := e.catalog.Get(n.Tool); !ok {
+				return fmt.Errorf("workflow tool node %s calls unknown tool %s", n.Id, n.Tool)
+			}
+		case KindAgent:
+			if _, ok := e.options.Agents[n.Agent]; !ok {
+				return fmt.Errorf("workflow agent node %s calls unknown agent %s", n.Id, n.Agent)
+			}
+		case KindFunc:
+			if _, ok := e.options.Funcs[n.Func]; !ok {
+				return fmt.Errorf("workflow func node %s calls unknown func %s", n.Id, n.Func)
+			}
+		}
+	}
+
+	return nil
+}
+
+func setOutput(def *Definition, node Node, state *State, text string) error {
+	if len(node.Output) == 0 {
+		return nil
+	}
+
+	value, err := parse(def.State[node.Output], text)
+	if err != nil {
+		return fmt.Errorf("failed to read %s from %q: %w", def.State[node.Output], text, err)
+	}
+
+	return state.Set(node.Output, value)
+}
+
+func withMemories(prompt string, msgs []memorymanager.Message) string {
+	var sb strings.Builder
+
+	for _, msg := range msgs {
+		for _, p := range msg.Parts {
+			if p.Type == "text" && len(p.Text) > 0 {
+				sb.WriteString(fmt.Sprintf("- [%s] %s\n", msg.Role, p.Text))
+			}
+		}
+	}
+
+	if sb.Len() == 0 {
+		return prompt
+	}
+
+	return "Relevant Memories:\n" + sb.String() + "\n" + prompt
+}
+
+func NewEngine(opts ...Option) *Engine {
+	options := NewOptions(opts...)
+
+	cp := options.Checkpointer
+	if cp == nil {
+		cp = memorycheckpointer.NewCheckpointer()
+	}
+
+	return &Engine{
+		options:      options,
+		catalog:      agent.NewToolCatalog(options.ToolHandlers...),
+		checkpointer: cp,
+	}
+}
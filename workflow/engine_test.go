@@ -0,0 +1,209 @@
+package workflow_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/w-h-a/agent/workflow"
+	"github.com/w-h-a/agent/workflow/checkpointer"
+	sqlitecheckpointer "github.com/w-h-a/agent/workflow/checkpointer/sqlite"
+)
+
+func TestResumeAfterCrash(t *testing.T) {
+	location := filepath.Join(t.TempDir(), "runs.db")
+
+	def := &workflow.Definition{
+		Name:  "crash",
+		Start: "fetch",
+		State: map[string]workflow.Type{"fetched": workflow.TypeString, "written": workflow.TypeString},
+		Nodes: []workflow.Node{
+			workflow.Call("fetch", "fetch").Then("write"),
+			workflow.Call("write", "write"),
+		},
+	}
+
+	var fetches, writes atomic.Int32
+
+	ctx, crash := context.WithCancel(t.Context())
+
+	engine := func() *workflow.Engine {
+		return workflow.NewEngine(
+			workflow.WithCheckpointer(sqlitecheckpointer.NewCheckpointer(checkpointer.WithLocation(location))),
+			workflow.WithFunc("fetch", func(ctx context.Context, state *workflow.State) error {
+				fetches.Add(1)
+				return state.Set("fetched", "data")
+			}),
+			workflow.WithFunc("write", func(ctx context.Context, state *workflow.State) error {
+				// the process dies part way through the first write
+				if writes.Add(1) == 1 {
+					crash()
+					return ctx.Err()
+				}
+				return state.Set("written", state.String("fetched"))
+			}),
+		)
+	}
+
+	run, err := engine().Start(ctx, def, "", nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("start: want context.Canceled, got %v", err)
+	}
+
+	// a fresh engine over the same file stands in for a restarted process
+	restarted := engine()
+
+	saved, err := restarted.Get(t.Context(), run.RunId)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+
+	if saved.Status != checkpointer.StatusRunning || saved.Node != "write" {
+		t.Fatalf("checkpoint: want running at write, got %s at %s", saved.Status, saved.Node)
+	}
+
+	run, err = restarted.Resume(t.Context(), def, run.RunId, "")
+	if err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+
+	if run.Status != checkpointer.StatusDone {
+		t.Fatalf("resume: want done, got %s", run.Status)
+	}
+
+	if run.State["written"] != "data" {
+		t.Fatalf("resume: want written data, got %v", run.State["written"])
+	}
+
+	if fetches.Load() != 1 || writes.Load() != 2 {
+		t.Fatalf("resume: want 1 fetch and 2 writes, got %d and %d", fetches.Load(), writes.Load())
+	}
+}
+
+func TestHumanWaitsForAnswer(t *testing.T) {
+	def := &workflow.Definition{
+		Name:  "approval",
+		Start: "ask",
+		State: map[string]workflow.Type{"change": workflow.TypeString, "approved": workflow.TypeBool, "applied": workflow.TypeBool},
+		Nodes: []workflow.Node{
+			workflow.Human("ask", "Approve {{.change}}?", "approved").Then("apply"),
+			workflow.Call("apply", "apply"),
+		},
+	}
+
+	var applied atomic.Int32
+
+	engine := workflow.NewEngine(
+		workflow.WithFunc("apply", func(ctx context.Context, state *workflow.State) error {
+			applied.Add(1)
+			approved, _ := state.Get("approved")
+			return state.Set("applied", approved)
+		}),
+	)
+
+	run, err := engine.Start(t.Context(), def, "", map[string]any{"change": "the rollout"})
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	if run.Status != checkpointer.StatusWaiting || run.Node != "ask" {
+		t.Fatalf("start: want waiting at ask, got %s at %s", run.Status, run.Node)
+	}
+
+	if run.Prompt != "Approve the rollout?" {
+		t.Fatalf("start: want the rendered question, got %q", run.Prompt)
+	}
+
+	if applied.Load() != 0 {
+		t.Fatal("start: ran past the human node before it was answered")
+	}
+
+	run, err = engine.Resume(t.Context(), def, run.RunId, "true")
+	if err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+
+	if run.Status != checkpointer.StatusDone {
+		t.Fatalf("resume: want done, got %s", run.Status)
+	}
+
+	if run.State["approved"] != true || run.State["applied"] != true {
+		t.Fatalf("resume: want the answer carried through, got %v", run.State)
+	}
+
+	if len(run.Prompt) > 0 {
+		t.Fatalf("resume: question remains, got %q", run.Prompt)
+	}
+}
+
+func TestParallelFailureCancelsSiblings(t *testing.T) {
+	def := &workflow.Definition{
+		Name:  "fan out",
+		Start: "both",
+		Nodes: []workflow.Node{
+			workflow.Parallel("both", "slow", "broken").Then("after"),
+			workflow.Call("slow", "slow"),
+			workflow.Call("broken", "broken"),
+			workflow.Call("after", "after"),
+		},
+	}
+
+	var cancelled, after atomic.Bool
+
+	engine := workflow.NewEngine(
+		workflow.WithFunc("slow", func(ctx context.Context, state *workflow.State) error {
+			select {
+			case <-ctx.Done():
+				cancelled.Store(true)
+				return ctx.Err()
+			case <-time.After(5 * time.Second):
+				return nil
+			}
+		}),
+		workflow.WithFunc("broken", func(ctx context.Context, state *workflow.State) error {
+			return errors.New("upstream unavailable")
+		}),
+		workflow.WithFunc("after", func(ctx context.Context, state *workflow.State) error {
+			after.Store(true)
+			return nil
+		}),
+	)
+
+	run, err := engine.Start(t.Context(), def, "", nil)
+	if err == nil || !strings.Contains(err.Error(), "upstream unavailable") {
+		t.Fatalf("start: want the broken node's error, got %v", err)
+	}
+
+	if run.Status != checkpointer.StatusFailed || run.Node != "both" {
+		t.Fatalf("start: want failed at both, got %s at %s", run.Status, run.Node)
+	}
+
+	if !cancelled.Load() {
+		t.Fatal("start: slow node was not cancelled")
+	}
+
+	if after.Load() {
+		t.Fatal("start: ran past the failed parallel node")
+	}
+}
+
+func TestValidateRejectsNextOnParallelNode(t *testing.T) {
+	def := &workflow.Definition{
+		Name:  "fan out",
+		Start: "both",
+		Nodes: []workflow.Node{
+			workflow.Parallel("both", "left", "right").Then("after"),
+			workflow.Call("left", "left").Then("after"),
+			workflow.Call("right", "right"),
+			workflow.Call("after", "after"),
+		},
+	}
+
+	if err := def.Validate(); err == nil {
+		t.Fatal("validate: accepted a parallel node with its own next")
+	}
+}
@@ -0,0 +1,90 @@
+package workflow
+
+import (
+	"context"
+
+	"github.com/w-h-a/agent/generator"
+	memorymanager "github.com/w-h-a/agent/memory_manager"
+	toolhandler "github.com/w-h-a/agent/tool_handler"
+	"github.com/w-h-a/agent/tool_handler/subagent"
+	"github.com/w-h-a/agent/workflow/checkpointer"
+)
+
+// Func is Go code run as a node. It reads and writes the state directly.
+type Func func(ctx context.Context, state *State) error
+
+type AgentConfig struct {
+	Agent   subagent.Agent
+	SpaceId string
+}
+
+type Option func(*Options)
+
+type Options struct {
+	Generator    generator.Generator
+	ToolHandlers []toolhandler.ToolHandler
+	Memory       memorymanager.MemoryManager
+	Agents       map[string]AgentConfig
+	Funcs        map[string]Func
+	Checkpointer checkpointer.Checkpointer
+	RecallLimit  int
+	Context      context.Context
+}
+
+func WithGenerator(gen generator.Generator) Option {
+	return func(o *Options) {
+		o.Generator = gen
+	}
+}
+
+func WithToolHandlers(handlers ...toolhandler.ToolHandler) Option {
+	return func(o *Options) {
+		o.ToolHandlers = append(o.ToolHandlers, handlers...)
+	}
+}
+
+// WithMemory lets llm nodes recall long-term memories of the run's session
+func WithMemory(memory memorymanager.MemoryManager) Option {
+	return func(o *Options) {
+		o.Memory = memory
+	}
+}
+
+// WithAgent makes a configured agent available to agent nodes by name.
+// Each call runs in a child session of the run's session in spaceId.
+func WithAgent(name string, agent subagent.Agent, spaceId string) Option {
+	return func(o *Options) {
+		o.Agents[name] = AgentConfig{Agent: agent, SpaceId: spaceId}
+	}
+}
+
+func WithFunc(name string, fn Func) Option {
+	return func(o *Options) {
+		o.Funcs[name] = fn
+	}
+}
+
+func WithCheckpointer(c checkpointer.Checkpointer) Option {
+	return func(o *Options) {
+		o.Checkpointer = c
+	}
+}
+
+func WithRecallLimit(limit int) Option {
+	return func(o *Options) {
+		o.RecallLimit = limit
+	}
+}
+
+func NewOptions(opts ...Option) Options {
+	options := Options{
+		Agents:      map[string]AgentConfig{},
+		Funcs:       map[string]Func{},
+		RecallLimit: 5,
+		Context:     context.Background(),
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
@@ -0,0 +1,161 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	extractjson "github.com/w-h-a/agent/util/extract_json"
+)
+
+// State is the data a run's nodes share. Fields are declared with a type
+// in the definition and every write is checked against it.
+type State struct {
+	schema map[string]Type
+	values map[string]any
+	mtx    sync.RWMutex
+}
+
+func (s *State) Get(field string) (any, bool) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	v, ok := s.values[field]
+	return v, ok
+}
+
+func (s *State) String(field string) string {
+	v, _ := s.Get(field)
+	if v == nil {
+		return ""
+	}
+	if str, ok := v.(string); ok {
+		return str
+	}
+	return fmt.Sprint(v)
+}
+
+func (s *State) Set(field string, value any) error {
+	t, ok := s.schema[field]
+	if !ok {
+		return fmt.Errorf("state field %q is not declared", field)
+	}
+
+	if err := check(t, value); err != nil {
+		return fmt.Errorf("state field %q: %w", field, err)
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.values[field] = value
+
+	return nil
+}
+
+// Snapshot copies the values so templates and checkpoints see a
+// consistent view while parallel nodes keep writing
+func (s *State) Snapshot() map[string]any {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	return maps.Clone(s.values)
+}
+
+// view is the snapshot templates see, with unset fields at their type's
+// zero value so conditions can compare them without erroring
+func (s *State) view() map[string]any {
+	values := s.Snapshot()
+	for field, t := range s.schema {
+		if _, ok := values[field]; ok {
+			continue
+		}
+		switch t {
+		case TypeString:
+			values[field] = ""
+		case TypeNumber:
+			values[field] = float64(0)
+		case TypeBool:
+			values[field] = false
+		}
+	}
+	return values
+}
+
+func newState(schema map[string]Type, values map[string]any) (*State, error) {
+	s := &State{
+		schema: schema,
+		values: map[string]any{},
+		mtx:    sync.RWMutex{},
+	}
+
+	for field, value := range values {
+		if err := s.Set(field, value); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+func check(t Type, value any) error {
+	if value == nil || t == TypeAny {
+		return nil
+	}
+
+	kind := reflect.TypeOf(value).Kind()
+
+	var ok bool
+
+	switch t {
+	case TypeString:
+		ok = kind == reflect.String
+	case TypeNumber:
+		ok = kind >= reflect.Int && kind <= reflect.Float64
+	case TypeBool:
+		ok = kind == reflect.Bool
+	case TypeList:
+		ok = kind == reflect.Slice || kind == reflect.Array
+	case TypeObject:
+		ok = kind == reflect.Map || kind == reflect.Struct
+	}
+
+	if !ok {
+		return fmt.Errorf("want %s, got %T", t, value)
+	}
+
+	return nil
+}
+
+// parse turns a model or tool reply into a value of type t
+func parse(t Type, text string) (any, error) {
+	text = strings.TrimSpace(text)
+
+	switch t {
+	case TypeNumber:
+		return strconv.ParseFloat(strings.Trim(text, "`\"' ."), 64)
+	case TypeBool:
+		return strconv.ParseBool(strings.ToLower(strings.Trim(text, "`\"' .")))
+	case TypeList:
+		var v []any
+		if err := extractjson.Into(text, &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	case TypeObject:
+		var v map[string]any
+		if err := extractjson.Into(text, &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	case TypeAny:
+		var v any
+		if err := json.Unmarshal([]byte(text), &v); err == nil {
+			return v, nil
+		}
+		return text, nil
+	default:
+		return text, nil
+	}
+}
@@ -0,0 +1,45 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+var funcs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		bs, err := json.Marshal(v)
+		return string(bs), err
+	},
+	"lower":    strings.ToLower,
+	"upper":    strings.ToUpper,
+	"contains": strings.Contains,
+	"join": func(sep string, v []any) string {
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			parts = append(parts, fmt.Sprint(item))
+		}
+		return strings.Join(parts, sep)
+	},
+}
+
+// render executes text as a template over the state. Fields that were
+// never written render empty rather than as "<no value>".
+func render(text string, state map[string]any) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := template.New("").Funcs(funcs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, state); err != nil {
+		return "", err
+	}
+
+	return strings.ReplaceAll(sb.String(), "<no value>", ""), nil
+}